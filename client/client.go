@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"iter"
 
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/transport"
@@ -50,7 +52,9 @@ func (c *Client[Req, Res]) emitResponseDecode(ctx context.Context, ct ucan.Conta
 	return errs
 }
 
-func (c *Client[Req, Res]) Execute(execRequest execution.Request) (execution.Response, error) {
+// requestContainer creates a container with the invocation of the execution
+// request and any additional tokens from its metadata.
+func requestContainer(execRequest execution.Request) *container.Container {
 	invocations := []ucan.Invocation{execRequest.Invocation()}
 	var delegations []ucan.Delegation
 	var receipts []ucan.Receipt
@@ -59,16 +63,29 @@ func (c *Client[Req, Res]) Execute(execRequest execution.Request) (execution.Res
 		delegations = append(delegations, execRequest.Metadata().Delegations()...)
 		receipts = append(receipts, execRequest.Metadata().Receipts()...)
 	}
-	reqContainer := container.New(
+	return container.New(
 		container.WithInvocations(invocations...),
 		container.WithDelegations(delegations...),
 		container.WithReceipts(receipts...),
 	)
+}
+
+func (c *Client[Req, Res]) Execute(execRequest execution.Request) (execution.Response, error) {
+	reqContainer := requestContainer(execRequest)
 	err := c.emitRequestEncode(execRequest.Context(), reqContainer)
 	if err != nil {
 		return nil, fmt.Errorf("emitting request encode event: %w", err)
 	}
-	request, err := c.Codec.Encode(reqContainer)
+	var request Req
+	if body := execRequest.Body(); body != nil {
+		codec, ok := c.Codec.(transport.BodyOutboundCodec[Req, Res])
+		if !ok {
+			return nil, fmt.Errorf("codec %T does not support request bodies", c.Codec)
+		}
+		request, err = codec.EncodeWithBody(reqContainer, body)
+	} else {
+		request, err = c.Codec.Encode(reqContainer)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("encoding container: %w", err)
 	}
@@ -94,7 +111,7 @@ func (c *Client[Req, Res]) Execute(execRequest execution.Request) (execution.Res
 		}
 	}
 	if receipt == nil {
-		return nil, fmt.Errorf("missing receipt for task: %s", task.Link())
+		return nil, NewMissingReceiptError(task.Link())
 	}
 	return execution.NewResponse(
 		task.Link(),
@@ -102,3 +119,174 @@ func (c *Client[Req, Res]) Execute(execRequest execution.Request) (execution.Res
 		execution.WithMetadata(resContainer),
 	)
 }
+
+// ExecuteBatch sends the execution requests to the service in a single round
+// trip. The invocations and metadata of all the requests are sent in one
+// container, so tokens shared by the requests, such as proofs, are sent once.
+//
+// The responses are returned in the same order as the requests. If the service
+// did not return a receipt for the task of a request, its response is nil and
+// the returned error includes a [MissingReceiptErrorName] error for the task.
+// Errors sending the batch are returned with no responses.
+//
+// The context is attached to the encoded request if it has a WithContext
+// method, as *http.Request does.
+func (c *Client[Req, Res]) ExecuteBatch(ctx context.Context, execRequests ...execution.Request) ([]execution.Response, error) {
+	if len(execRequests) == 0 {
+		return nil, errors.New("no execution requests")
+	}
+	var invocations []ucan.Invocation
+	var delegations []ucan.Delegation
+	var receipts []ucan.Receipt
+	for _, execRequest := range execRequests {
+		if execRequest.Body() != nil {
+			return nil, errors.New("request bodies cannot be batched")
+		}
+		invocations = append(invocations, execRequest.Invocation())
+		if execRequest.Metadata() != nil {
+			invocations = append(invocations, execRequest.Metadata().Invocations()...)
+			delegations = append(delegations, execRequest.Metadata().Delegations()...)
+			receipts = append(receipts, execRequest.Metadata().Receipts()...)
+		}
+	}
+	// duplicate tokens are removed by the container
+	reqContainer := container.New(
+		container.WithInvocations(invocations...),
+		container.WithDelegations(delegations...),
+		container.WithReceipts(receipts...),
+	)
+	err := c.emitRequestEncode(ctx, reqContainer)
+	if err != nil {
+		return nil, fmt.Errorf("emitting request encode event: %w", err)
+	}
+	request, err := c.Codec.Encode(reqContainer)
+	if err != nil {
+		return nil, fmt.Errorf("encoding container: %w", err)
+	}
+	// attach the context to requests that carry one, such as *http.Request
+	if cr, ok := any(request).(interface{ WithContext(context.Context) Req }); ok {
+		request = cr.WithContext(ctx)
+	}
+	response, err := c.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, fmt.Errorf("roundtripping request: %w", err)
+	}
+	resContainer, err := c.Codec.Decode(response)
+	if err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	// the container has been decoded in full, so release the response it was
+	// decoded from, whether or not it contains any of the receipts
+	if closer, ok := resContainer.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			return nil, fmt.Errorf("closing response: %w", err)
+		}
+	}
+	err = c.emitResponseDecode(ctx, resContainer)
+	if err != nil {
+		return nil, fmt.Errorf("emitting response decode event: %w", err)
+	}
+
+	responses := make([]execution.Response, len(execRequests))
+	var errs []error
+	for i, execRequest := range execRequests {
+		task := execRequest.Invocation().Task()
+		receipt, ok := resContainer.Receipt(task.Link())
+		if !ok {
+			errs = append(errs, NewMissingReceiptError(task.Link()))
+			continue
+		}
+		responses[i], err = execution.NewResponse(
+			task.Link(),
+			execution.WithReceipt(receipt),
+			execution.WithMetadata(resContainer),
+		)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("creating response for task %s: %w", task.Link(), err))
+		}
+	}
+	return responses, errors.Join(errs...)
+}
+
+// ExecuteStream sends the execution request and returns an iterator over the
+// responses, yielded as the receipts arrive. A response is yielded for the
+// invocation of the request and for each additional invocation in its
+// metadata that the service executes. The codec must implement
+// [transport.StreamingOutboundCodec].
+//
+// An error is yielded if the stream ends without a receipt for the invocation
+// of the request. Iteration ends after an error is yielded.
+//
+// The context of the request is attached to the encoded request if it has a
+// WithContext method, as *http.Request does, so cancelling it ends the stream.
+func (c *Client[Req, Res]) ExecuteStream(execRequest execution.Request) iter.Seq2[execution.Response, error] {
+	return func(yield func(execution.Response, error) bool) {
+		codec, ok := c.Codec.(transport.StreamingOutboundCodec[Req, Res])
+		if !ok {
+			yield(nil, fmt.Errorf("codec %T does not support streaming", c.Codec))
+			return
+		}
+		if execRequest.Body() != nil {
+			yield(nil, errors.New("request bodies cannot be streamed"))
+			return
+		}
+
+		ctx := execRequest.Context()
+		reqContainer := requestContainer(execRequest)
+		err := c.emitRequestEncode(ctx, reqContainer)
+		if err != nil {
+			yield(nil, fmt.Errorf("emitting request encode event: %w", err))
+			return
+		}
+		request, err := codec.EncodeStream(reqContainer)
+		if err != nil {
+			yield(nil, fmt.Errorf("encoding container: %w", err))
+			return
+		}
+		// attach the context to requests that carry one, such as *http.Request
+		if cr, ok := any(request).(interface{ WithContext(context.Context) Req }); ok {
+			request = cr.WithContext(ctx)
+		}
+		response, err := c.Transport.RoundTrip(request)
+		if err != nil {
+			yield(nil, fmt.Errorf("roundtripping request: %w", err))
+			return
+		}
+
+		// tasks that have been requested but not yet received
+		pending := map[ucan.Link]struct{}{}
+		for _, inv := range reqContainer.Invocations() {
+			pending[inv.Task().Link()] = struct{}{}
+		}
+		for resContainer, err := range codec.DecodeStream(response) {
+			if err != nil {
+				yield(nil, fmt.Errorf("decoding response: %w", err))
+				return
+			}
+			err = c.emitResponseDecode(ctx, resContainer)
+			if err != nil {
+				yield(nil, fmt.Errorf("emitting response decode event: %w", err))
+				return
+			}
+			for _, rcpt := range resContainer.Receipts() {
+				if _, ok := pending[rcpt.Ran()]; !ok {
+					continue
+				}
+				delete(pending, rcpt.Ran())
+				res, err := execution.NewResponse(
+					rcpt.Ran(),
+					execution.WithReceipt(rcpt),
+					execution.WithMetadata(resContainer),
+				)
+				if !yield(res, err) || err != nil {
+					return
+				}
+			}
+		}
+
+		task := execRequest.Invocation().Task()
+		if _, ok := pending[task.Link()]; ok {
+			yield(nil, NewMissingReceiptError(task.Link()))
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"fmt"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+const MissingReceiptErrorName = "MissingReceipt"
+
+// NewMissingReceiptError creates an error indicating the response from the
+// service did not include a receipt for a task.
+func NewMissingReceiptError(task ucan.Link) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: MissingReceiptErrorName,
+		Message:   fmt.Sprintf("missing receipt for task: %s", task),
+	}
+}
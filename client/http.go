@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -42,6 +43,16 @@ func (c *HTTPClient) Execute(execRequest execution.Request) (execution.Response,
 	return res, nil
 }
 
+// ExecuteBatch sends the execution requests to the service in a single round
+// trip. See [Client.ExecuteBatch].
+func (c *HTTPClient) ExecuteBatch(ctx context.Context, execRequests ...execution.Request) ([]execution.Response, error) {
+	responses, err := c.Client.ExecuteBatch(ctx, execRequests...)
+	if err != nil {
+		return responses, fmt.Errorf("executing batch: %w", err)
+	}
+	return responses, nil
+}
+
 type httpTransport struct {
 	client *http.Client
 	url    *url.URL
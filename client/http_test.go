@@ -1,17 +1,28 @@
 package client_test
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alanshaw/ucantone/client"
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/result"
 	"github.com/alanshaw/ucantone/server"
 	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/invocation"
 	"github.com/stretchr/testify/require"
 )
@@ -49,4 +60,373 @@ func TestHTTPClient(t *testing.T) {
 		require.NotNil(t, o)
 		require.Equal(t, "echo!", o.(ipld.Map)["message"])
 	})
+
+	t.Run("container in header with body", func(t *testing.T) {
+		server := server.NewHTTP(service, server.WithHTTPCodec(transport.NewHTTPHeaderInboundCodec()))
+
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			body, err := io.ReadAll(req.Body())
+			if err != nil {
+				return err
+			}
+			return res.SetSuccess(ipld.Map{"body": string(body)})
+		})
+
+		c, err := client.NewHTTP(
+			testutil.Must(url.Parse("http://localhost"))(t),
+			client.WithHTTPClient(&http.Client{Transport: server}),
+			client.WithHTTPCodec(transport.NewHTTPHeaderOutboundCodec()),
+		)
+		require.NoError(t, err)
+
+		inv, err := testutil.TestEchoCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "echo!"},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		req := execution.NewRequest(t.Context(), inv, execution.WithBody(strings.NewReader("file contents")))
+		res, err := c.Execute(req)
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(res.Receipt().Out())
+		require.Nil(t, x)
+		require.Equal(t, "file contents", o.(ipld.Map)["body"])
+	})
+
+	t.Run("body with codec that does not support bodies", func(t *testing.T) {
+		c, err := client.NewHTTP(testutil.Must(url.Parse("http://localhost"))(t))
+		require.NoError(t, err)
+
+		inv, err := testutil.TestEchoCapability.Invoke(alice, alice, datamodel.Map{"message": "echo!"})
+		require.NoError(t, err)
+
+		req := execution.NewRequest(t.Context(), inv, execution.WithBody(strings.NewReader("file contents")))
+		_, err = c.Execute(req)
+		require.ErrorContains(t, err, "does not support request bodies")
+	})
+
+	t.Run("streamed execution", func(t *testing.T) {
+		server := server.NewHTTP(service, server.WithConcurrency(3))
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+		srv := httptest.NewServer(server)
+		defer srv.Close()
+
+		c, err := client.NewHTTP(testutil.Must(url.Parse(srv.URL))(t))
+		require.NoError(t, err)
+
+		var invs []ucan.Invocation
+		for i := range 3 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		req := execution.NewRequest(t.Context(), invs[0], execution.WithInvocations(invs[1:]...))
+		received := map[ucan.Link]ipld.Any{}
+		for res, err := range c.ExecuteStream(req) {
+			require.NoError(t, err)
+			o, x := result.Unwrap(res.Receipt().Out())
+			require.Nil(t, x)
+			received[res.Receipt().Ran()] = o.(ipld.Map)["n"]
+		}
+		require.Len(t, received, len(invs))
+		for i, inv := range invs {
+			require.Equal(t, int64(i), received[inv.Task().Link()])
+		}
+	})
+
+	t.Run("streamed execution missing receipt", func(t *testing.T) {
+		// the invocation is not addressed to the service, so it is not executed
+		server := server.NewHTTP(service)
+		c, err := client.NewHTTP(
+			testutil.Must(url.Parse("http://localhost"))(t),
+			client.WithHTTPClient(&http.Client{Transport: server}),
+		)
+		require.NoError(t, err)
+
+		inv, err := testutil.TestEchoCapability.Invoke(alice, alice, datamodel.Map{"message": "echo!"})
+		require.NoError(t, err)
+
+		var errs []error
+		for _, err := range c.ExecuteStream(execution.NewRequest(t.Context(), inv)) {
+			errs = append(errs, err)
+		}
+		require.Len(t, errs, 1)
+		require.ErrorContains(t, errs[0], "missing receipt")
+	})
+
+	t.Run("streamed execution fails mid-stream", func(t *testing.T) {
+		var encoded int
+		listener := responseEncodeListenerFunc(func(ctx context.Context, ct ucan.Container) error {
+			encoded++
+			if encoded > 1 {
+				return errors.New("boom")
+			}
+			return nil
+		})
+		server := server.NewHTTP(service, server.WithEventListener(listener))
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+		srv := httptest.NewServer(server)
+		defer srv.Close()
+
+		c, err := client.NewHTTP(testutil.Must(url.Parse(srv.URL))(t))
+		require.NoError(t, err)
+
+		var invs []ucan.Invocation
+		for i := range 3 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		req := execution.NewRequest(t.Context(), invs[0], execution.WithInvocations(invs[1:]...))
+		var n int
+		var errs []error
+		for _, err := range c.ExecuteStream(req) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			n++
+		}
+		require.Equal(t, 1, n)
+		require.Len(t, errs, 1)
+		require.ErrorContains(t, errs[0], "decoding response")
+		require.NotContains(t, errs[0].Error(), "missing receipt")
+	})
+
+	t.Run("streamed execution context", func(t *testing.T) {
+		release := make(chan struct{})
+		var calls atomic.Int64
+		server := server.NewHTTP(service)
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			// block whichever invocation the server executes second, since the
+			// container does not preserve the order they were sent in
+			if calls.Add(1) > 1 {
+				select {
+				case <-release:
+				case <-req.Context().Done():
+				}
+			}
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+		srv := httptest.NewServer(server)
+		defer srv.Close()
+		defer close(release)
+
+		c, err := client.NewHTTP(testutil.Must(url.Parse(srv.URL))(t))
+		require.NoError(t, err)
+
+		var invs []ucan.Invocation
+		for i := range 2 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		req := execution.NewRequest(ctx, invs[0], execution.WithInvocations(invs[1:]...))
+		var n int
+		var errs []error
+		for _, err := range c.ExecuteStream(req) {
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			// the second executed invocation does not complete until the stream ends
+			n++
+			cancel()
+		}
+		require.Equal(t, 1, n)
+		require.Len(t, errs, 1)
+		require.ErrorIs(t, errs[0], context.Canceled)
+	})
+
+	t.Run("batch execution", func(t *testing.T) {
+		server := server.NewHTTP(service)
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+
+		var sent ucan.Container
+		listener := requestEncodeListenerFunc(func(ctx context.Context, ct ucan.Container) error {
+			sent = ct
+			return nil
+		})
+		c, err := client.NewHTTP(
+			testutil.Must(url.Parse("http://localhost"))(t),
+			client.WithHTTPClient(&http.Client{Transport: server}),
+			client.WithEventListener(listener),
+		)
+		require.NoError(t, err)
+
+		dlg, err := testutil.TestEchoCapability.Delegate(service, alice, service)
+		require.NoError(t, err)
+
+		var reqs []execution.Request
+		for i := range 3 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				service,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithProofs(dlg.Link()),
+			)
+			require.NoError(t, err)
+			reqs = append(reqs, execution.NewRequest(t.Context(), inv, execution.WithProofs(dlg)))
+		}
+		// not addressed to the service, so it is not executed
+		other, err := testutil.TestEchoCapability.Invoke(alice, alice, datamodel.Map{"n": int64(3)})
+		require.NoError(t, err)
+		reqs = append(reqs, execution.NewRequest(t.Context(), other))
+
+		responses, err := c.ExecuteBatch(t.Context(), reqs...)
+		var merr edm.ErrorModel
+		require.True(t, errors.As(err, &merr))
+		require.Equal(t, client.MissingReceiptErrorName, merr.Name())
+		require.ErrorContains(t, err, other.Task().Link().String())
+
+		// the shared proof was sent once
+		require.Len(t, sent.Invocations(), len(reqs))
+		require.Len(t, sent.Delegations(), 1)
+
+		require.Len(t, responses, len(reqs))
+		for i, res := range responses[:3] {
+			require.Equal(t, reqs[i].Invocation().Task().Link(), res.Receipt().Ran())
+			o, x := result.Unwrap(res.Receipt().Out())
+			require.Nil(t, x)
+			require.Equal(t, int64(i), o.(ipld.Map)["n"])
+		}
+		require.Nil(t, responses[3])
+	})
+
+	t.Run("batch execution closes response", func(t *testing.T) {
+		// the invocation is not addressed to the service, so there are no receipts
+		server := server.NewHTTP(service)
+		var body *closeRecorder
+		c, err := client.NewHTTP(
+			testutil.Must(url.Parse("http://localhost"))(t),
+			client.WithHTTPClient(&http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				resp, err := server.RoundTrip(r)
+				if err != nil {
+					return nil, err
+				}
+				body = &closeRecorder{ReadCloser: resp.Body}
+				resp.Body = body
+				return resp, nil
+			})}),
+		)
+		require.NoError(t, err)
+
+		inv, err := testutil.TestEchoCapability.Invoke(alice, alice, datamodel.Map{"message": "echo!"})
+		require.NoError(t, err)
+
+		responses, err := c.ExecuteBatch(t.Context(), execution.NewRequest(t.Context(), inv))
+		require.ErrorContains(t, err, "missing receipt")
+		require.Equal(t, []execution.Response{nil}, responses)
+		require.True(t, body.closed)
+	})
+
+	t.Run("batch execution context", func(t *testing.T) {
+		server := server.NewHTTP(service)
+		srv := httptest.NewServer(server)
+		defer srv.Close()
+
+		c, err := client.NewHTTP(testutil.Must(url.Parse(srv.URL))(t))
+		require.NoError(t, err)
+
+		inv, err := testutil.TestEchoCapability.Invoke(alice, service, datamodel.Map{"message": "echo!"})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		_, err = c.ExecuteBatch(ctx, execution.NewRequest(t.Context(), inv))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		server := server.NewHTTP(service, server.WithLimits(limits.Limits{MaxTokens: 1}))
+
+		codecs := map[string]transport.OutboundCodec[*http.Request, *http.Response]{
+			"DAG-CBOR": transport.DefaultHTTPOutboundCodec,
+			"DAG-JSON": transport.DefaultHTTPDagJSONOutboundCodec,
+		}
+		for name, codec := range codecs {
+			t.Run(name, func(t *testing.T) {
+				c, err := client.NewHTTP(
+					testutil.Must(url.Parse("http://localhost"))(t),
+					client.WithHTTPClient(&http.Client{Transport: server}),
+					client.WithHTTPCodec(codec),
+				)
+				require.NoError(t, err)
+
+				dlg, err := testutil.TestEchoCapability.Delegate(service, alice, service)
+				require.NoError(t, err)
+
+				inv, err := testutil.TestEchoCapability.Invoke(
+					alice,
+					service,
+					datamodel.Map{"message": "echo!"},
+					invocation.WithProofs(dlg.Link()),
+				)
+				require.NoError(t, err)
+
+				_, err = c.Execute(execution.NewRequest(t.Context(), inv, execution.WithProofs(dlg)))
+				var herr *transport.HTTPError
+				require.True(t, errors.As(err, &herr))
+				require.Equal(t, http.StatusRequestEntityTooLarge, herr.StatusCode)
+				require.Equal(t, limits.ExceededErrorName, herr.Name())
+			})
+		}
+	})
+}
+
+type requestEncodeListenerFunc func(ctx context.Context, ct ucan.Container) error
+
+func (f requestEncodeListenerFunc) OnRequestEncode(ctx context.Context, ct ucan.Container) error {
+	return f(ctx, ct)
+}
+
+type responseEncodeListenerFunc func(ctx context.Context, ct ucan.Container) error
+
+func (f responseEncodeListenerFunc) OnResponseEncode(ctx context.Context, ct ucan.Container) error {
+	return f(ctx, ct)
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+type closeRecorder struct {
+	io.ReadCloser
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return c.ReadCloser.Close()
 }
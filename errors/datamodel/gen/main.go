@@ -1,6 +1,7 @@
 package main
 
 import (
+	jsg "github.com/alanshaw/dag-json-gen"
 	vdm "github.com/alanshaw/ucantone/errors/datamodel"
 	cbg "github.com/whyrusleeping/cbor-gen"
 )
@@ -11,4 +12,9 @@ func main() {
 	); err != nil {
 		panic(err)
 	}
+	if err := jsg.WriteMapEncodersToFile("../dag_json_gen.go", "datamodel",
+		vdm.ErrorModel{},
+	); err != nil {
+		panic(err)
+	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alanshaw/ucantone/examples/types"
 	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/schema"
 	"github.com/alanshaw/ucantone/principal/ed25519"
 	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	"github.com/alanshaw/ucantone/ucan/invocation"
@@ -16,6 +17,12 @@ import (
 func TestCapabilityDefinition(t *testing.T) {
 	messageSendCapability, err := capability.New(
 		"/message/send",
+		// arguments that do not match the schema are rejected as malformed
+		capability.WithSchema(schema.Struct(
+			schema.Field("to", schema.List(schema.String())),
+			schema.Field("subject", schema.String()),
+			schema.Field("message", schema.String()),
+		)),
 		capability.WithPolicyBuilder(
 			policy.NotEqual(".to", []string{}),
 		),
@@ -3,14 +3,16 @@ package bindexec
 import (
 	"fmt"
 
-	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/validator/capability"
 )
 
-const MalformedArgumentsErrorName = "MalformedArguments"
+// Deprecated: Use [capability.MalformedArgumentsErrorName].
+const MalformedArgumentsErrorName = capability.MalformedArgumentsErrorName
 
+// Deprecated: Use [capability.NewMalformedArgumentsError], which also includes
+// the invocation command.
 func NewMalformedArgumentsError(cause error) error {
-	return edm.ErrorModel{
-		ErrorName: MalformedArgumentsErrorName,
-		Message:   fmt.Sprintf("malformed arguments: %s", cause.Error()),
-	}
+	err := capability.NewMalformedArgumentsError("", cause)
+	err.Message = fmt.Sprintf("malformed arguments: %s", cause.Error())
+	return err
 }
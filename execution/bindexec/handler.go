@@ -3,18 +3,24 @@ package bindexec
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
 	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator/capability"
 	"github.com/ipfs/go-cid"
 )
 
+// Arguments are the invocation arguments passed to a handler. Use
+// [datamodel.Typed] to bind arguments to a plain Go struct using reflection.
 type Arguments interface {
 	dagcbor.Unmarshaler
 }
 
+// Success is the result of a successful execution. Use [datamodel.Typed] to
+// bind a plain Go struct using reflection.
 type Success interface {
 	dagcbor.Marshaler
 }
@@ -23,6 +29,7 @@ type requestConfig struct {
 	invocations []ucan.Invocation
 	delegations []ucan.Delegation
 	receipts    []ucan.Receipt
+	body        io.Reader
 }
 
 type RequestOption = func(cfg *requestConfig)
@@ -56,6 +63,13 @@ func WithInvocations(invocations ...ucan.Invocation) RequestOption {
 	}
 }
 
+// WithBody sets the application payload sent alongside the invocation.
+func WithBody(body io.Reader) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.body = body
+	}
+}
+
 type Request[A Arguments] struct {
 	execution.Request
 	task *Task[A]
@@ -77,6 +91,7 @@ func NewRequest[A Arguments](ctx context.Context, inv ucan.Invocation, options .
 			execution.WithInvocations(cfg.invocations...),
 			execution.WithDelegations(cfg.delegations...),
 			execution.WithReceipts(cfg.receipts...),
+			execution.WithBody(cfg.body),
 		),
 		task: task,
 	}, nil
@@ -193,7 +208,7 @@ func NewHandler[A Arguments, O Success](handler HandlerFunc[A, O]) execution.Han
 		inv := req.Invocation()
 		task, err := NewTask[A](inv.Subject(), inv.Command(), inv.Arguments(), inv.Nonce())
 		if err != nil {
-			return res.SetFailure(NewMalformedArgumentsError(err))
+			return res.SetFailure(capability.NewMalformedArgumentsError(inv.Command(), err))
 		}
 		return handler(&Request[A]{Request: req, task: task}, &Response[O]{res: res})
 	}
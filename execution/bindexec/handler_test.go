@@ -10,6 +10,7 @@ import (
 	"github.com/alanshaw/ucantone/testutil"
 	tdm "github.com/alanshaw/ucantone/testutil/datamodel"
 	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/validator/capability"
 	"github.com/stretchr/testify/require"
 )
 
@@ -44,3 +45,70 @@ func TestHandler(t *testing.T) {
 	require.NotNil(t, o)
 	require.Equal(t, "testy", o.(datamodel.Map)["str"])
 }
+
+type EchoArguments struct {
+	Message string  `ipld:"message"`
+	Repeat  *uint64 `ipld:"repeat"`
+}
+
+type EchoSuccess struct {
+	Messages []string `ipld:"messages"`
+}
+
+func TestReflectionHandler(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	handler := bindexec.NewHandler(func(req *bindexec.Request[*datamodel.Typed[EchoArguments]], res *bindexec.Response[*datamodel.Typed[EchoSuccess]]) error {
+		args := req.Task().BindArguments().Value
+		repeat := uint64(1)
+		if args.Repeat != nil {
+			repeat = *args.Repeat
+		}
+		var msgs []string
+		for range repeat {
+			msgs = append(msgs, args.Message)
+		}
+		return res.SetSuccess(&datamodel.Typed[EchoSuccess]{Value: EchoSuccess{Messages: msgs}})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		inv, err := invocation.Invoke(
+			alice,
+			alice,
+			"/test/echo",
+			datamodel.Map{"message": "hello", "repeat": 2},
+		)
+		require.NoError(t, err)
+
+		res, err := execution.NewResponse(inv.Task().Link(), execution.WithSigner(alice))
+		require.NoError(t, err)
+
+		err = handler(execution.NewRequest(t.Context(), inv), res)
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(res.Receipt().Out())
+		require.Nil(t, x)
+		require.Equal(t, []string{"hello", "hello"}, o.(datamodel.Map)["messages"])
+	})
+
+	t.Run("malformed arguments", func(t *testing.T) {
+		inv, err := invocation.Invoke(
+			alice,
+			alice,
+			"/test/echo",
+			datamodel.Map{"repeat": 2},
+		)
+		require.NoError(t, err)
+
+		res, err := execution.NewResponse(inv.Task().Link(), execution.WithSigner(alice))
+		require.NoError(t, err)
+
+		err = handler(execution.NewRequest(t.Context(), inv), res)
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(res.Receipt().Out())
+		require.Nil(t, o)
+		require.NotNil(t, x)
+		require.Equal(t, capability.MalformedArgumentsErrorName, x.(map[string]any)["name"])
+		require.Equal(t, ".message", x.(map[string]any)["path"])
+	})
+}
@@ -1,8 +1,6 @@
 package bindexec
 
 import (
-	"reflect"
-
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
 	"github.com/alanshaw/ucantone/ucan"
@@ -20,14 +18,8 @@ func NewTask[A Arguments](
 	arguments ipld.Map,
 	nonce ucan.Nonce,
 ) (*Task[A], error) {
-	var args A
-	// if args is a pointer type, then we need to create an instance of it because
-	// rebind requires a non-nil pointer.
-	typ := reflect.TypeOf(args)
-	if typ.Kind() == reflect.Ptr {
-		args = reflect.New(typ.Elem()).Interface().(A)
-	}
-	if err := datamodel.Rebind(datamodel.Map(arguments), args); err != nil {
+	args, err := datamodel.BindAs[A](arguments)
+	if err != nil {
 		return nil, err
 	}
 	task, err := invocation.NewTask(subject, command, arguments, nonce)
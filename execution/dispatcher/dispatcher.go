@@ -1,9 +1,12 @@
 package dispatcher
 
 import (
+	"errors"
 	"fmt"
 
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
 	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/execution/replay"
 	"github.com/alanshaw/ucantone/principal"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/validator"
@@ -21,6 +24,7 @@ type Dispatcher struct {
 	handlers          map[ucan.Command]handler
 	validationOpts    []validator.Option
 	receiptTimestamps bool
+	replayStore       replay.Store
 }
 
 // New creates an invocation executor that executes UCAN invocations by
@@ -38,6 +42,7 @@ func New(authority principal.Signer, options ...Option) *Dispatcher {
 		handlers:          map[ucan.Command]handler{},
 		validationOpts:    cfg.validationOpts,
 		receiptTimestamps: cfg.receiptTimestamps,
+		replayStore:       cfg.replayStore,
 	}
 }
 
@@ -92,6 +97,30 @@ func (d *Dispatcher) Execute(req execution.Request) (execution.Response, error)
 		)
 	}
 
+	if d.replayStore != nil {
+		seen, err := replay.Seen(req.Context(), d.replayStore, req.Invocation())
+		if err != nil {
+			var model edm.ErrorModel
+			if errors.As(err, &model) && model.Name() == replay.MissingExpirationErrorName {
+				return execution.NewResponse(
+					req.Invocation().Task().Link(),
+					execution.WithSigner(d.authority),
+					execution.WithReceiptTimestamp(d.receiptTimestamps),
+					execution.WithFailure(model),
+				)
+			}
+			return nil, fmt.Errorf("checking invocation replay: %w", err)
+		}
+		if seen {
+			return execution.NewResponse(
+				req.Invocation().Task().Link(),
+				execution.WithSigner(d.authority),
+				execution.WithReceiptTimestamp(d.receiptTimestamps),
+				execution.WithFailure(replay.NewReplayError(req.Invocation())),
+			)
+		}
+	}
+
 	res, err := execution.NewResponse(
 		req.Invocation().Task().Link(),
 		execution.WithSigner(d.authority),
@@ -6,6 +6,7 @@ import (
 
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/execution/dispatcher"
+	"github.com/alanshaw/ucantone/execution/replay"
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
 	"github.com/alanshaw/ucantone/result"
@@ -164,4 +165,94 @@ func TestDispatcher(t *testing.T) {
 
 		require.Equal(t, verrs.InvalidClaimErrorName, x.(ipld.Map)["name"])
 	})
+
+	t.Run("replay", func(t *testing.T) {
+		executor := dispatcher.New(service, dispatcher.WithReplayStore(replay.NewMemoryStore()))
+
+		var count int
+		executor.Handle(testutil.ConsoleLogCapability, func(req execution.Request, res execution.Response) error {
+			count++
+			return res.SetSuccess(ipld.Map{})
+		})
+
+		logInv, err := testutil.ConsoleLogCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "Hello, World!"},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		resp, err := executor.Execute(execution.NewRequest(t.Context(), logInv))
+		require.NoError(t, err)
+		_, x := result.Unwrap(resp.Receipt().Out())
+		require.Nil(t, x)
+
+		resp, err = executor.Execute(execution.NewRequest(t.Context(), logInv))
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(resp.Receipt().Out())
+		require.Nil(t, o)
+		require.NotNil(t, x)
+		t.Log(x)
+
+		require.Equal(t, replay.ReplayErrorName, x.(ipld.Map)["name"])
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("replay guarded invocation without expiration", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		executor := dispatcher.New(service, dispatcher.WithReplayStore(store))
+
+		var count int
+		executor.Handle(testutil.ConsoleLogCapability, func(req execution.Request, res execution.Response) error {
+			count++
+			return res.SetSuccess(ipld.Map{})
+		})
+
+		logInv, err := testutil.ConsoleLogCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "Hello, World!"},
+			invocation.WithAudience(service),
+			invocation.WithNoExpiration(),
+		)
+		require.NoError(t, err)
+
+		resp, err := executor.Execute(execution.NewRequest(t.Context(), logInv))
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(resp.Receipt().Out())
+		require.Nil(t, o)
+		require.Equal(t, replay.MissingExpirationErrorName, x.(ipld.Map)["name"])
+		require.Equal(t, 0, count)
+		require.Equal(t, 0, store.Len())
+	})
+
+	t.Run("replay of idempotent invocation", func(t *testing.T) {
+		executor := dispatcher.New(service, dispatcher.WithReplayStore(replay.NewMemoryStore()))
+
+		var count int
+		executor.Handle(testutil.ConsoleLogCapability, func(req execution.Request, res execution.Response) error {
+			count++
+			return res.SetSuccess(ipld.Map{})
+		})
+
+		logInv, err := testutil.ConsoleLogCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "Hello, World!"},
+			invocation.WithAudience(service),
+			invocation.WithNoNonce(),
+		)
+		require.NoError(t, err)
+
+		for range 2 {
+			resp, err := executor.Execute(execution.NewRequest(t.Context(), logInv))
+			require.NoError(t, err)
+			_, x := result.Unwrap(resp.Receipt().Out())
+			require.Nil(t, x)
+		}
+		require.Equal(t, 2, count)
+	})
 }
@@ -1,6 +1,7 @@
 package dispatcher
 
 import (
+	"github.com/alanshaw/ucantone/execution/replay"
 	"github.com/alanshaw/ucantone/validator"
 )
 
@@ -10,6 +11,7 @@ type Option func(cfg *execConfig)
 type execConfig struct {
 	validationOpts    []validator.Option
 	receiptTimestamps bool
+	replayStore       replay.Store
 }
 
 func WithValidationOptions(options ...validator.Option) Option {
@@ -25,3 +27,15 @@ func WithReceiptTimestamps(enabled bool) Option {
 		cfg.receiptTimestamps = enabled
 	}
 }
+
+// WithReplayStore configures the dispatcher to reject invocations that have
+// already been executed. Invocations are recorded in the store after they have
+// been validated, until they expire. Invocations with an empty nonce are
+// idempotent and are always executed. Invocations with a nonce but no
+// expiration are rejected with a [replay.MissingExpirationErrorName] failure,
+// since they would otherwise be recorded in the store forever.
+func WithReplayStore(store replay.Store) Option {
+	return func(cfg *execConfig) {
+		cfg.replayStore = store
+	}
+}
@@ -2,6 +2,7 @@ package execution
 
 import (
 	"context"
+	"io"
 
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ucan"
@@ -13,6 +14,10 @@ type Request interface {
 	Invocation() ucan.Invocation
 	// Metadata provides additional information about the invocation.
 	Metadata() ucan.Container
+	// Body is the application payload sent alongside the invocation, or nil if
+	// there is none. When a request container holds multiple invocations, they
+	// all share the same body.
+	Body() io.Reader
 }
 
 type Response interface {
@@ -0,0 +1,31 @@
+package replay
+
+import (
+	"fmt"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+const ReplayErrorName = "Replay"
+
+// NewReplayError creates an error indicating the invocation has already been
+// executed.
+func NewReplayError(inv ucan.Invocation) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: ReplayErrorName,
+		Message:   fmt.Sprintf("invocation %q has already been executed: nonce %x from %q was already used", inv.Link(), inv.Nonce(), inv.Issuer().DID()),
+	}
+}
+
+const MissingExpirationErrorName = "MissingExpiration"
+
+// NewMissingExpirationError creates an error indicating the invocation has a
+// nonce but no expiration, so it cannot be protected against replay without
+// being recorded forever.
+func NewMissingExpirationError(inv ucan.Invocation) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: MissingExpirationErrorName,
+		Message:   fmt.Sprintf("invocation %q has a nonce but no expiration", inv.Link()),
+	}
+}
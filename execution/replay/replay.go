@@ -0,0 +1,106 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// Store records the invocations that have been seen, so that replays can be
+// detected.
+type Store interface {
+	// Add records the key until the passed expiration time (or forever, if the
+	// expiration is nil). It returns false if the key was already recorded and
+	// has not yet expired. Checking and recording MUST be atomic.
+	Add(ctx context.Context, key string, expiration *ucan.UTCUnixTimestamp) (bool, error)
+}
+
+// Key is the key an invocation is recorded under in a [Store]. It is the pair
+// of issuer DID and nonce, so that an invocation is considered a replay even if
+// it has been re-signed with different fields.
+func Key(inv ucan.Invocation) string {
+	return inv.Issuer().DID().String() + "/" + hex.EncodeToString(inv.Nonce())
+}
+
+// Seen records the invocation in the store and returns true if it was
+// previously recorded i.e. the invocation is a replay.
+//
+// Invocations with an empty nonce are never considered replays and are not
+// recorded. The spec states the nonce SHOULD be empty for commands that are
+// idempotent, which may be safely re-run.
+//
+// Invocations with a nonce but no expiration would need to be recorded forever,
+// so they are rejected with a [MissingExpirationErrorName] error and are not
+// recorded.
+//
+// https://github.com/ucan-wg/invocation/blob/main/README.md#nonce
+func Seen(ctx context.Context, store Store, inv ucan.Invocation) (bool, error) {
+	if len(inv.Nonce()) == 0 {
+		return false, nil
+	}
+	if inv.Expiration() == nil {
+		return false, NewMissingExpirationError(inv)
+	}
+	added, err := store.Add(ctx, Key(inv), inv.Expiration())
+	if err != nil {
+		return false, err
+	}
+	return !added, nil
+}
+
+// MemoryStore is an in-memory [Store]. Expired entries are removed as new
+// entries are added.
+type MemoryStore struct {
+	mutex sync.Mutex
+	data  map[string]*ucan.UTCUnixTimestamp
+	// time of the next expiry, used to avoid scanning the map on every add
+	next ucan.UTCUnixTimestamp
+}
+
+// NewMemoryStore creates a new, empty, in-memory replay store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[string]*ucan.UTCUnixTimestamp{}}
+}
+
+func (ms *MemoryStore) Add(ctx context.Context, key string, expiration *ucan.UTCUnixTimestamp) (bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	now := ucan.Now()
+	if ms.next != 0 && ms.next <= now {
+		ms.prune(now)
+	}
+	if exp, ok := ms.data[key]; ok && (exp == nil || *exp > now) {
+		return false, nil
+	}
+	ms.data[key] = expiration
+	if expiration != nil && (ms.next == 0 || *expiration < ms.next) {
+		ms.next = *expiration
+	}
+	return true, nil
+}
+
+// Len returns the number of entries in the store, including any that have
+// expired but have not yet been removed.
+func (ms *MemoryStore) Len() int {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	return len(ms.data)
+}
+
+func (ms *MemoryStore) prune(now ucan.UTCUnixTimestamp) {
+	ms.next = 0
+	for key, exp := range ms.data {
+		if exp == nil {
+			continue
+		}
+		if *exp <= now {
+			delete(ms.data, key)
+		} else if ms.next == 0 || *exp < ms.next {
+			ms.next = *exp
+		}
+	}
+}
+
+var _ Store = (*MemoryStore)(nil)
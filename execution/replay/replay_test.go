@@ -0,0 +1,105 @@
+package replay_test
+
+import (
+	"errors"
+	"testing"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/execution/replay"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeen(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	nonce := testutil.RandomBytes(t, 12)
+
+	t.Run("replayed invocation", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		inv := testutil.Must(testutil.ConsoleLogCapability.Invoke(alice, alice, datamodel.Map{"message": "hi"}))(t)
+
+		seen, err := replay.Seen(t.Context(), store, inv)
+		require.NoError(t, err)
+		require.False(t, seen)
+
+		seen, err = replay.Seen(t.Context(), store, inv)
+		require.NoError(t, err)
+		require.True(t, seen)
+	})
+
+	t.Run("re-signed invocation with same nonce", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		inv0 := testutil.Must(testutil.ConsoleLogCapability.Invoke(alice, alice, datamodel.Map{"message": "hi"}, invocation.WithNonce(nonce)))(t)
+		inv1 := testutil.Must(testutil.ConsoleLogCapability.Invoke(alice, alice, datamodel.Map{"message": "bye"}, invocation.WithNonce(nonce)))(t)
+		require.NotEqual(t, inv0.Link(), inv1.Link())
+
+		seen, err := replay.Seen(t.Context(), store, inv0)
+		require.NoError(t, err)
+		require.False(t, seen)
+
+		seen, err = replay.Seen(t.Context(), store, inv1)
+		require.NoError(t, err)
+		require.True(t, seen)
+	})
+
+	t.Run("no expiration", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		inv := testutil.Must(testutil.ConsoleLogCapability.Invoke(alice, alice, datamodel.Map{"message": "hi"}, invocation.WithNoExpiration()))(t)
+
+		_, err := replay.Seen(t.Context(), store, inv)
+		var model edm.ErrorModel
+		require.True(t, errors.As(err, &model))
+		require.Equal(t, replay.MissingExpirationErrorName, model.Name())
+		require.Equal(t, 0, store.Len())
+	})
+
+	t.Run("empty nonce", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		inv := testutil.Must(testutil.ConsoleLogCapability.Invoke(alice, alice, datamodel.Map{"message": "hi"}, invocation.WithNoNonce()))(t)
+
+		for range 2 {
+			seen, err := replay.Seen(t.Context(), store, inv)
+			require.NoError(t, err)
+			require.False(t, seen)
+		}
+		require.Equal(t, 0, store.Len())
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("expires entries", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		past := ucan.Now() - 1
+		future := ucan.Now() + 60
+
+		added, err := store.Add(t.Context(), "expired", &past)
+		require.NoError(t, err)
+		require.True(t, added)
+		added, err = store.Add(t.Context(), "active", &future)
+		require.NoError(t, err)
+		require.True(t, added)
+
+		// expired entry is removed and may be added again
+		added, err = store.Add(t.Context(), "expired", &future)
+		require.NoError(t, err)
+		require.True(t, added)
+
+		added, err = store.Add(t.Context(), "active", &future)
+		require.NoError(t, err)
+		require.False(t, added)
+		require.Equal(t, 2, store.Len())
+	})
+
+	t.Run("no expiration", func(t *testing.T) {
+		store := replay.NewMemoryStore()
+		added, err := store.Add(t.Context(), "forever", nil)
+		require.NoError(t, err)
+		require.True(t, added)
+		added, err = store.Add(t.Context(), "forever", nil)
+		require.NoError(t, err)
+		require.False(t, added)
+	})
+}
@@ -2,6 +2,7 @@ package execution
 
 import (
 	"context"
+	"io"
 
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/container"
@@ -11,6 +12,7 @@ type requestConfig struct {
 	invocations []ucan.Invocation
 	delegations []ucan.Delegation
 	receipts    []ucan.Receipt
+	body        io.Reader
 }
 
 type RequestOption = func(cfg *requestConfig)
@@ -44,10 +46,19 @@ func WithInvocations(invocations ...ucan.Invocation) RequestOption {
 	}
 }
 
+// WithBody sets the application payload sent alongside the invocation, for
+// example the HTTP body when the container is carried in a header.
+func WithBody(body io.Reader) RequestOption {
+	return func(cfg *requestConfig) {
+		cfg.body = body
+	}
+}
+
 type ExecRequest struct {
 	ctx        context.Context
 	invocation ucan.Invocation
 	metadata   ucan.Container
+	body       io.Reader
 }
 
 func NewRequest(ctx context.Context, inv ucan.Invocation, options ...RequestOption) *ExecRequest {
@@ -67,6 +78,7 @@ func NewRequest(ctx context.Context, inv ucan.Invocation, options ...RequestOpti
 		ctx:        ctx,
 		invocation: inv,
 		metadata:   meta,
+		body:       cfg.body,
 	}
 	return req
 }
@@ -82,3 +94,7 @@ func (r *ExecRequest) Invocation() ucan.Invocation {
 func (r *ExecRequest) Metadata() ucan.Container {
 	return r.metadata
 }
+
+func (r *ExecRequest) Body() io.Reader {
+	return r.body
+}
@@ -0,0 +1,127 @@
+// Package blockstore stores IPLD blocks by CID.
+package blockstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/ipfs/go-cid"
+)
+
+// ErrNotFound is returned when a block is not found in the store.
+var ErrNotFound = errors.New("block not found")
+
+// Blockstore persists blocks and allows them to be retrieved by CID.
+type Blockstore interface {
+	// Get retrieves a block by CID. It returns [ErrNotFound] if the block is not
+	// in the store.
+	Get(ctx context.Context, link cid.Cid) (ipld.Block, error)
+	// Put adds a block to the store.
+	Put(ctx context.Context, blk ipld.Block) error
+	// Has reports whether the block is in the store.
+	Has(ctx context.Context, link cid.Cid) (bool, error)
+	// Delete removes a block from the store. It is not an error to delete a
+	// block that is not in the store.
+	Delete(ctx context.Context, link cid.Cid) error
+	// AllKeys returns the CIDs of all the blocks in the store, ordered by CID.
+	AllKeys(ctx context.Context) ([]cid.Cid, error)
+}
+
+type block struct {
+	link cid.Cid
+	data []byte
+}
+
+func (b *block) Link() cid.Cid {
+	return b.link
+}
+
+func (b *block) Bytes() []byte {
+	return b.data
+}
+
+// NewBlock creates a block from the CID and the encoded data it identifies.
+func NewBlock(link cid.Cid, data []byte) ipld.Block {
+	return &block{link: link, data: data}
+}
+
+// MemoryStore is a [Blockstore] that keeps blocks in memory.
+type MemoryStore struct {
+	mutex sync.RWMutex
+	data  map[cid.Cid][]byte
+}
+
+// NewMemoryStore creates a new, empty, in-memory block store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[cid.Cid][]byte{}}
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, link cid.Cid) (ipld.Block, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	data, ok := ms.data[link]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return NewBlock(link, slices.Clone(data)), nil
+}
+
+func (ms *MemoryStore) Put(ctx context.Context, blk ipld.Block) error {
+	if err := verify(blk.Link(), blk.Bytes()); err != nil {
+		return err
+	}
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.data[blk.Link()] = slices.Clone(blk.Bytes())
+	return nil
+}
+
+func (ms *MemoryStore) Has(ctx context.Context, link cid.Cid) (bool, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	_, ok := ms.data[link]
+	return ok, nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, link cid.Cid) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	delete(ms.data, link)
+	return nil
+}
+
+func (ms *MemoryStore) AllKeys(ctx context.Context) ([]cid.Cid, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	keys := make([]cid.Cid, 0, len(ms.data))
+	for link := range ms.data {
+		keys = append(keys, link)
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// verify checks that the data is the content identified by the CID.
+func verify(link cid.Cid, data []byte) error {
+	sum, err := link.Prefix().Sum(data)
+	if err != nil {
+		return fmt.Errorf("hashing block %q: %w", link, err)
+	}
+	if !sum.Equals(link) {
+		return fmt.Errorf("block %q contains data for %q", link, sum)
+	}
+	return nil
+}
+
+func sortKeys(keys []cid.Cid) {
+	slices.SortFunc(keys, func(a, b cid.Cid) int {
+		return bytes.Compare(a.Bytes(), b.Bytes())
+	})
+}
+
+var _ Blockstore = (*MemoryStore)(nil)
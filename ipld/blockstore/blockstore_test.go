@@ -0,0 +1,151 @@
+package blockstore_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/blockstore"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func randomBlock(t *testing.T) ipld.Block {
+	t.Helper()
+	data := testutil.RandomBytes(t, 32)
+	link, err := cid.V1Builder{Codec: cid.Raw, MhType: multihash.SHA2_256}.Sum(data)
+	require.NoError(t, err)
+	return blockstore.NewBlock(link, data)
+}
+
+func TestBlockstore(t *testing.T) {
+	impls := map[string]func(t *testing.T) blockstore.Blockstore{
+		"memory": func(t *testing.T) blockstore.Blockstore {
+			return blockstore.NewMemoryStore()
+		},
+		"file": func(t *testing.T) blockstore.Blockstore {
+			return testutil.Must(blockstore.NewFileStore(t.TempDir()))(t)
+		},
+	}
+
+	for name, newStore := range impls {
+		t.Run(name, func(t *testing.T) {
+			t.Run("put and get", func(t *testing.T) {
+				s := newStore(t)
+				blk := randomBlock(t)
+
+				require.NoError(t, s.Put(t.Context(), blk))
+				// putting the same block again is a no-op
+				require.NoError(t, s.Put(t.Context(), blk))
+
+				got, err := s.Get(t.Context(), blk.Link())
+				require.NoError(t, err)
+				require.Equal(t, blk.Link(), got.Link())
+				require.Equal(t, blk.Bytes(), got.Bytes())
+
+				has, err := s.Has(t.Context(), blk.Link())
+				require.NoError(t, err)
+				require.True(t, has)
+			})
+
+			t.Run("put mismatched data", func(t *testing.T) {
+				s := newStore(t)
+				blk := randomBlock(t)
+
+				err := s.Put(t.Context(), blockstore.NewBlock(blk.Link(), []byte("other")))
+				require.Error(t, err)
+
+				has, err := s.Has(t.Context(), blk.Link())
+				require.NoError(t, err)
+				require.False(t, has)
+			})
+
+			t.Run("get not found", func(t *testing.T) {
+				s := newStore(t)
+				_, err := s.Get(t.Context(), testutil.RandomCID(t))
+				require.True(t, errors.Is(err, blockstore.ErrNotFound))
+
+				has, err := s.Has(t.Context(), testutil.RandomCID(t))
+				require.NoError(t, err)
+				require.False(t, has)
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				s := newStore(t)
+				blk := randomBlock(t)
+				require.NoError(t, s.Put(t.Context(), blk))
+				require.NoError(t, s.Delete(t.Context(), blk.Link()))
+
+				_, err := s.Get(t.Context(), blk.Link())
+				require.True(t, errors.Is(err, blockstore.ErrNotFound))
+
+				// deleting again is not an error
+				require.NoError(t, s.Delete(t.Context(), blk.Link()))
+			})
+
+			t.Run("all keys", func(t *testing.T) {
+				s := newStore(t)
+				keys, err := s.AllKeys(t.Context())
+				require.NoError(t, err)
+				require.Empty(t, keys)
+
+				var links []cid.Cid
+				for range 3 {
+					blk := randomBlock(t)
+					require.NoError(t, s.Put(t.Context(), blk))
+					links = append(links, blk.Link())
+				}
+
+				keys, err = s.AllKeys(t.Context())
+				require.NoError(t, err)
+				require.ElementsMatch(t, links, keys)
+			})
+		})
+	}
+
+	t.Run("memory store copies data", func(t *testing.T) {
+		s := blockstore.NewMemoryStore()
+		blk := randomBlock(t)
+		data := bytes.Clone(blk.Bytes())
+		require.NoError(t, s.Put(t.Context(), blk))
+
+		// modifying the put or retrieved bytes does not alter the stored block
+		blk.Bytes()[0] ^= 0xff
+		got, err := s.Get(t.Context(), blk.Link())
+		require.NoError(t, err)
+		require.Equal(t, data, got.Bytes())
+
+		got.Bytes()[0] ^= 0xff
+		got, err = s.Get(t.Context(), blk.Link())
+		require.NoError(t, err)
+		require.Equal(t, data, got.Bytes())
+	})
+
+	t.Run("file store persists blocks", func(t *testing.T) {
+		dir := t.TempDir()
+		blk := randomBlock(t)
+		require.NoError(t, testutil.Must(blockstore.NewFileStore(dir))(t).Put(t.Context(), blk))
+
+		s := testutil.Must(blockstore.NewFileStore(dir))(t)
+		got, err := s.Get(t.Context(), blk.Link())
+		require.NoError(t, err)
+		require.Equal(t, blk.Bytes(), got.Bytes())
+	})
+
+	t.Run("file store detects corrupt blocks", func(t *testing.T) {
+		dir := t.TempDir()
+		s := testutil.Must(blockstore.NewFileStore(dir))(t)
+		blk := randomBlock(t)
+		require.NoError(t, s.Put(t.Context(), blk))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, blk.Link().String()), []byte("corrupt"), 0644))
+
+		_, err := s.Get(t.Context(), blk.Link())
+		require.Error(t, err)
+		require.False(t, errors.Is(err, blockstore.ErrNotFound))
+	})
+}
@@ -0,0 +1,110 @@
+package blockstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/ipfs/go-cid"
+)
+
+// FileStore is a [Blockstore] that persists blocks to a directory on disk. Each
+// block is stored in a file named by its CID. Blocks are verified against
+// their CID when they are read.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a block store that persists blocks in the passed
+// directory. The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating block store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (fs *FileStore) Get(ctx context.Context, link cid.Cid) (ipld.Block, error) {
+	data, err := os.ReadFile(fs.path(link))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("reading block file: %w", err)
+	}
+	if err := verify(link, data); err != nil {
+		return nil, err
+	}
+	return NewBlock(link, data), nil
+}
+
+func (fs *FileStore) Put(ctx context.Context, blk ipld.Block) error {
+	if err := verify(blk.Link(), blk.Bytes()); err != nil {
+		return err
+	}
+	// write to a temporary file and rename so that partially written blocks are
+	// never read
+	tmp, err := os.CreateTemp(fs.dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("creating block file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(blk.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing block file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing block file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fs.path(blk.Link())); err != nil {
+		return fmt.Errorf("renaming block file: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStore) Has(ctx context.Context, link cid.Cid) (bool, error) {
+	_, err := os.Stat(fs.path(link))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("checking block file: %w", err)
+	}
+	return true, nil
+}
+
+func (fs *FileStore) Delete(ctx context.Context, link cid.Cid) error {
+	if err := os.Remove(fs.path(link)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing block file: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStore) AllKeys(ctx context.Context) ([]cid.Cid, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading block store directory: %w", err)
+	}
+	var keys []cid.Cid
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		link, err := cid.Parse(entry.Name())
+		if err != nil {
+			continue // not a block file
+		}
+		keys = append(keys, link)
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+func (fs *FileStore) path(link cid.Cid) string {
+	return filepath.Join(fs.dir, link.String())
+}
+
+var _ Blockstore = (*FileStore)(nil)
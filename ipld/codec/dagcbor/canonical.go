@@ -0,0 +1,260 @@
+package dagcbor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"unicode/utf8"
+
+	cid "github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+)
+
+// ErrNonCanonical is the error wrapped by errors returned from
+// [CheckCanonical] when data is valid CBOR but is not canonical DAG-CBOR.
+var ErrNonCanonical = errors.New("non-canonical DAG-CBOR")
+
+// CheckCanonical walks the CBOR encoded data item without decoding it, and
+// returns an error if it is not encoded in the canonical DAG-CBOR form. That
+// is, integers and lengths use the shortest encoding, map keys are unique
+// strings sorted by length and then bytewise, floats are 64 bit, only the CID
+// tag (42) is used, and there are no indefinite lengths or trailing bytes.
+//
+// Decoders that accept non-canonical data allow byte-different encodings of
+// the same value, which hash to different CIDs.
+func CheckCanonical(data []byte) error {
+	type frame struct {
+		remaining uint64
+		isMap     bool
+		// key is true when the next item in a map is a key
+		key     bool
+		prevKey []byte
+	}
+	stack := []frame{{remaining: 1}}
+	pos := 0
+	for len(stack) > 0 {
+		top := len(stack) - 1
+		if stack[top].remaining == 0 {
+			stack = stack[:top]
+			continue
+		}
+		stack[top].remaining--
+		isKey := stack[top].isMap && stack[top].key
+		if stack[top].isMap {
+			stack[top].key = !stack[top].key
+		}
+
+		maj, low, arg, next, err := readHead(data, pos)
+		if err != nil {
+			return err
+		}
+		pos = next
+
+		if maj != 7 && !isMinimal(low, arg) {
+			return fmt.Errorf("%w: integer or length %d is not minimally encoded", ErrNonCanonical, arg)
+		}
+		if isKey && maj != 3 {
+			return fmt.Errorf("%w: map keys must be strings", ErrNonCanonical)
+		}
+
+		switch maj {
+		case 2, 3: // bytes, string
+			if arg > uint64(len(data)-pos) {
+				return errTruncated
+			}
+			content := data[pos : pos+int(arg)]
+			pos += int(arg)
+			if maj == 3 && !utf8.Valid(content) {
+				return fmt.Errorf("%w: string is not valid UTF-8", ErrNonCanonical)
+			}
+			if isKey {
+				prev := stack[top].prevKey
+				if prev != nil && !keyLess(prev, content) {
+					if bytes.Equal(prev, content) {
+						return fmt.Errorf("%w: duplicate map key %q", ErrNonCanonical, content)
+					}
+					return fmt.Errorf("%w: map key %q is not in canonical order", ErrNonCanonical, content)
+				}
+				stack[top].prevKey = content
+			}
+		case 4: // list
+			if arg > uint64(len(data)) {
+				return errTruncated
+			}
+			stack = append(stack, frame{remaining: arg})
+		case 5: // map
+			if arg > uint64(len(data)) {
+				return errTruncated
+			}
+			stack = append(stack, frame{remaining: arg * 2, isMap: true, key: true})
+		case 6: // tag, the tagged item follows
+			if arg != 42 {
+				return fmt.Errorf("%w: unsupported tag %d", ErrNonCanonical, arg)
+			}
+			if pos >= len(data) || data[pos]>>5 != 2 {
+				return fmt.Errorf("%w: CID tag must be followed by bytes", ErrNonCanonical)
+			}
+			stack[top].remaining++
+			// the tagged item is the same map entry as the tag
+			if stack[top].isMap {
+				stack[top].key = !stack[top].key
+			}
+		case 7: // simple values and floats
+			switch low {
+			case 20, 21, 22: // false, true, null
+			case 27:
+				f := math.Float64frombits(arg)
+				if math.IsNaN(f) || math.IsInf(f, 0) {
+					return fmt.Errorf("%w: unsupported float value %v", ErrNonCanonical, f)
+				}
+			case 25, 26:
+				return fmt.Errorf("%w: floats must be encoded as 64 bit", ErrNonCanonical)
+			default:
+				return fmt.Errorf("%w: unsupported simple value %d", ErrNonCanonical, low)
+			}
+		}
+	}
+	if pos != len(data) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrNonCanonical, len(data)-pos)
+	}
+	return nil
+}
+
+// Canonicalize re-encodes the first CBOR data item in data with the shortest
+// integer and length encodings, and with map keys sorted in canonical order. If
+// a map key appears more than once, the last value is kept. It returns the
+// re-encoded item and the number of bytes of data that were read.
+//
+// It allows data that was not strictly encoded to be decoded by decoders that
+// require canonical maps. Other non-canonical forms, such as 16 or 32 bit
+// floats, are copied unchanged.
+func Canonicalize(data []byte) ([]byte, int, error) {
+	var buf bytes.Buffer
+	n, err := canonicalize(&buf, data, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), n, nil
+}
+
+// DecodeLenient prepares DAG-CBOR data that may not be canonically encoded to
+// be decoded. It returns the canonical form of the data for decoders that
+// require canonical maps, a copy of the data as it was received and the CID of
+// the received data. An error wrapping [ErrNonCanonical] is returned if there
+// are bytes after the first data item.
+func DecodeLenient(data []byte) (canonical, raw []byte, link cid.Cid, err error) {
+	canonical, n, err := Canonicalize(data)
+	if err != nil {
+		return nil, nil, cid.Undef, err
+	}
+	if n != len(data) {
+		return nil, nil, cid.Undef, fmt.Errorf("%w: %d trailing bytes", ErrNonCanonical, len(data)-n)
+	}
+	raw = slices.Clone(data)
+	link, err = cid.V1Builder{Codec: Code, MhType: multihash.SHA2_256}.Sum(raw)
+	if err != nil {
+		return nil, nil, cid.Undef, fmt.Errorf("hashing data: %w", err)
+	}
+	return canonical, raw, link, nil
+}
+
+func canonicalize(w *bytes.Buffer, data []byte, pos int) (int, error) {
+	start := pos
+	maj, _, arg, pos, err := readHead(data, pos)
+	if err != nil {
+		return pos, err
+	}
+	switch maj {
+	case 0, 1: // integers
+		writeHead(w, maj, arg)
+	case 2, 3: // bytes, string
+		if arg > uint64(len(data)-pos) {
+			return pos, errTruncated
+		}
+		writeHead(w, maj, arg)
+		w.Write(data[pos : pos+int(arg)])
+		pos += int(arg)
+	case 4: // list
+		if arg > uint64(len(data)) {
+			return pos, errTruncated
+		}
+		writeHead(w, maj, arg)
+		for range arg {
+			if pos, err = canonicalize(w, data, pos); err != nil {
+				return pos, err
+			}
+		}
+	case 5: // map
+		if arg > uint64(len(data)) {
+			return pos, errTruncated
+		}
+		entries := map[string][]byte{}
+		for range arg {
+			kmaj, _, klen, next, err := readHead(data, pos)
+			if err != nil {
+				return pos, err
+			}
+			if kmaj != 3 {
+				return pos, fmt.Errorf("%w: map keys must be strings", ErrNonCanonical)
+			}
+			if klen > uint64(len(data)-next) {
+				return pos, errTruncated
+			}
+			key := string(data[next : next+int(klen)])
+			var value bytes.Buffer
+			if pos, err = canonicalize(&value, data, next+int(klen)); err != nil {
+				return pos, err
+			}
+			entries[key] = value.Bytes()
+		}
+		keys := make([][]byte, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, []byte(k))
+		}
+		slices.SortFunc(keys, func(a, b []byte) int {
+			if keyLess(a, b) {
+				return -1
+			}
+			return 1
+		})
+		writeHead(w, maj, uint64(len(keys)))
+		for _, k := range keys {
+			writeHead(w, 3, uint64(len(k)))
+			w.Write(k)
+			w.Write(entries[string(k)])
+		}
+	case 6: // tag, the tagged item follows
+		writeHead(w, maj, arg)
+		return canonicalize(w, data, pos)
+	case 7: // simple values and floats
+		w.Write(data[start:pos])
+	}
+	return pos, nil
+}
+
+// isMinimal reports whether the argument was encoded in the fewest bytes
+// possible given the additional information it was encoded with.
+func isMinimal(low byte, arg uint64) bool {
+	switch low {
+	case 24:
+		return arg >= 24
+	case 25:
+		return arg > math.MaxUint8
+	case 26:
+		return arg > math.MaxUint16
+	case 27:
+		return arg > math.MaxUint32
+	}
+	return true
+}
+
+// keyLess reports whether map key a sorts before b in canonical order: shorter
+// keys first, then bytewise.
+func keyLess(a, b []byte) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return bytes.Compare(a, b) < 0
+}
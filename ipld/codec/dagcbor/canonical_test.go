@@ -0,0 +1,137 @@
+package dagcbor_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	cid "github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCanonical(t *testing.T) {
+	valid := map[string]string{
+		"integer":         "1818",
+		"negative":        "3818",
+		"string":          "6461626364",
+		"list":            "83010203",
+		"sorted map":      "a2616101626262626202",
+		"empty key":       "a26001616102",
+		"nested":          "a1616182a0f6",
+		"float":           "fb3ff8000000000000",
+		"simple values":   "83f4f5f6",
+		"cid":             "d82a4a00015500000000000000",
+		"map with cid":    "a26161d82a4a000155000000000000006162f6",
+		"max uint8 arg":   "18ff",
+		"min uint16 arg":  "190100",
+		"min uint32 arg":  "1a00010000",
+		"min uint64 arg":  "1b0000000100000000",
+		"bytes":           "43010203",
+		"unicode string":  "62c3a9",
+		"empty map":       "a0",
+		"empty list":      "80",
+		"empty bytes key": "a16000",
+	}
+	for name, h := range valid {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, dagcbor.CheckCanonical(mustHex(t, h)))
+		})
+	}
+
+	invalid := map[string]string{
+		"non-minimal integer": "1801",
+		"non-minimal uint16":  "1900ff",
+		"non-minimal length":  "780161",
+		"unsorted keys":       "a2616201616102",
+		"shorter key last":    "a2626262016161f6",
+		"duplicate keys":      "a2616101616102",
+		"integer key":         "a10101",
+		"float16":             "f93c00",
+		"float32":             "fa3fc00000",
+		"NaN":                 "fb7ff8000000000000",
+		"undefined":           "f7",
+		"unsupported tag":     "c11a514b67b0",
+		"cid tag on string":   "d82a6161",
+		"trailing bytes":      "0101",
+		"invalid utf-8":       "62c328",
+	}
+	for name, h := range invalid {
+		t.Run(name, func(t *testing.T) {
+			err := dagcbor.CheckCanonical(mustHex(t, h))
+			require.True(t, errors.Is(err, dagcbor.ErrNonCanonical), "unexpected error: %v", err)
+		})
+	}
+
+	t.Run("truncated", func(t *testing.T) {
+		err := dagcbor.CheckCanonical(mustHex(t, "830102"))
+		require.Error(t, err)
+		require.False(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("indefinite length", func(t *testing.T) {
+		require.Error(t, dagcbor.CheckCanonical(mustHex(t, "9f01ff")))
+	})
+}
+
+func TestCanonicalize(t *testing.T) {
+	cases := map[string][2]string{
+		"canonical":           {"a2616101626262626202", "a2616101626262626202"},
+		"non-minimal integer": {"1801", "01"},
+		"non-minimal length":  {"780161", "6161"},
+		"unsorted keys":       {"a2616201616102", "a2616102616201"},
+		"shorter key last":    {"a2626262016161f6", "a26161f662626201"},
+		"duplicate keys":      {"a2616101616102", "a1616102"},
+		"nested":              {"a1616181a2616201616102", "a1616181a2616102616201"},
+		"cid":                 {"d82a4a00015500000000000000", "d82a4a00015500000000000000"},
+		"float16":             {"f93c00", "f93c00"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			input := mustHex(t, c[0])
+			out, n, err := dagcbor.Canonicalize(append(input, 0x00))
+			require.NoError(t, err)
+			require.Equal(t, len(input), n)
+			require.Equal(t, c[1], hex.EncodeToString(out))
+		})
+	}
+
+	t.Run("integer key", func(t *testing.T) {
+		_, _, err := dagcbor.Canonicalize(mustHex(t, "a10101"))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, _, err := dagcbor.Canonicalize(mustHex(t, "830102"))
+		require.Error(t, err)
+	})
+}
+
+func TestDecodeLenient(t *testing.T) {
+	input := mustHex(t, "a2616201616102")
+
+	canonical, raw, link, err := dagcbor.DecodeLenient(input)
+	require.NoError(t, err)
+	require.Equal(t, "a2616102616201", hex.EncodeToString(canonical))
+	require.Equal(t, input, raw)
+	expected, err := cid.V1Builder{Codec: dagcbor.Code, MhType: multihash.SHA2_256}.Sum(input)
+	require.NoError(t, err)
+	require.Equal(t, expected, link)
+
+	// the raw bytes do not alias the input
+	input[0] = 0x00
+	require.Equal(t, byte(0xa2), raw[0])
+
+	t.Run("trailing bytes", func(t *testing.T) {
+		_, _, _, err := dagcbor.DecodeLenient(mustHex(t, "a2616201616102ff"))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
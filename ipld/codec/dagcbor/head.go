@@ -0,0 +1,62 @@
+package dagcbor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var errTruncated = errors.New("truncated CBOR data")
+
+// readHead reads the head of the CBOR data item at pos, returning the major
+// type, the additional information, the argument and the position of the
+// content that follows the head. Indefinite lengths are not supported.
+func readHead(data []byte, pos int) (byte, byte, uint64, int, error) {
+	if pos >= len(data) {
+		return 0, 0, 0, pos, errTruncated
+	}
+	maj := data[pos] >> 5
+	low := data[pos] & 0x1f
+	pos++
+	switch {
+	case low < 24:
+		return maj, low, uint64(low), pos, nil
+	case low <= 27:
+		size := 1 << (low - 24)
+		if pos+size > len(data) {
+			return 0, 0, 0, pos, errTruncated
+		}
+		var arg uint64
+		switch size {
+		case 1:
+			arg = uint64(data[pos])
+		case 2:
+			arg = uint64(binary.BigEndian.Uint16(data[pos:]))
+		case 4:
+			arg = uint64(binary.BigEndian.Uint32(data[pos:]))
+		case 8:
+			arg = binary.BigEndian.Uint64(data[pos:])
+		}
+		return maj, low, arg, pos + size, nil
+	}
+	return 0, 0, 0, pos, fmt.Errorf("unsupported CBOR additional information: %d", low)
+}
+
+// writeHead writes the head of a CBOR data item with the given major type,
+// using the shortest encoding of the argument.
+func writeHead(w *bytes.Buffer, maj byte, arg uint64) {
+	switch {
+	case arg < 24:
+		w.WriteByte(maj<<5 | byte(arg))
+	case arg <= math.MaxUint8:
+		w.Write([]byte{maj<<5 | 24, byte(arg)})
+	case arg <= math.MaxUint16:
+		w.Write(binary.BigEndian.AppendUint16([]byte{maj<<5 | 25}, uint16(arg)))
+	case arg <= math.MaxUint32:
+		w.Write(binary.BigEndian.AppendUint32([]byte{maj<<5 | 26}, uint32(arg)))
+	default:
+		w.Write(binary.BigEndian.AppendUint64([]byte{maj<<5 | 27}, arg))
+	}
+}
@@ -0,0 +1,54 @@
+package dagcbor
+
+import (
+	"github.com/alanshaw/ucantone/limits"
+)
+
+// CheckNesting walks the CBOR encoded data item without decoding it, and
+// returns a limit exceeded error if lists or maps are nested deeper than max.
+// It allows the depth of untrusted data to be checked before it is passed to
+// a recursive decoder.
+func CheckNesting(data []byte, max int) error {
+	// remaining number of items to read at each level, starting with the single
+	// top level item
+	remaining := []uint64{1}
+	pos := 0
+	for len(remaining) > 0 {
+		top := len(remaining) - 1
+		if remaining[top] == 0 {
+			remaining = remaining[:top]
+			continue
+		}
+		remaining[top]--
+
+		maj, _, arg, next, err := readHead(data, pos)
+		if err != nil {
+			return err
+		}
+		pos = next
+
+		switch maj {
+		case 2, 3: // bytes, string
+			if arg > uint64(len(data)-pos) {
+				return errTruncated
+			}
+			pos += int(arg)
+		case 4, 5: // list, map
+			if len(remaining) > max {
+				return limits.NewExceededError(limits.NestingLimit, int64(max))
+			}
+			if maj == 5 {
+				if arg > uint64(len(data)) {
+					return errTruncated
+				}
+				arg *= 2
+			}
+			if arg > 0 {
+				remaining = append(remaining, arg)
+			}
+		case 6: // tag, the tagged item follows
+			remaining[top]++
+		}
+	}
+	return nil
+}
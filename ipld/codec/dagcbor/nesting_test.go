@@ -0,0 +1,27 @@
+package dagcbor_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckNesting(t *testing.T) {
+	list := func(depth int) []byte {
+		b := bytes.Repeat([]byte{0x81}, depth) // list of 1 item
+		return append(b, 0x01)
+	}
+	dmap := func(depth int) []byte {
+		b := bytes.Repeat([]byte{0xa1, 0x61, 0x61}, depth) // map of 1 item {"a": ...}
+		return append(b, 0x01)
+	}
+
+	require.NoError(t, dagcbor.CheckNesting(list(8), 8))
+	require.NoError(t, dagcbor.CheckNesting(dmap(8), 8))
+	require.ErrorContains(t, dagcbor.CheckNesting(list(9), 8), limits.NestingLimit)
+	require.ErrorContains(t, dagcbor.CheckNesting(dmap(9), 8), limits.NestingLimit)
+	require.Error(t, dagcbor.CheckNesting(list(8)[:8], 8))
+}
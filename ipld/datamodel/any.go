@@ -3,12 +3,16 @@ package datamodel
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"maps"
+	"math"
 	"reflect"
 	"slices"
+	"strconv"
+	"strings"
 
 	jsg "github.com/alanshaw/dag-json-gen"
 	"github.com/alanshaw/ucantone/ipld"
@@ -22,6 +26,7 @@ import (
 //   - Null (nil)
 //   - Boolean (bool)
 //   - Integer (int64, int)
+//   - Float (float64)
 //   - String (string)
 //   - Bytes ([]byte)
 //   - List ([]Any)
@@ -40,6 +45,8 @@ type Any struct {
 //   - bool
 //   - int
 //   - int64
+//   - float64
+//   - float32
 //   - string
 //   - []byte
 //   - slice
@@ -66,6 +73,10 @@ func (a *Any) MarshalCBOR(w io.Writer) error {
 		return cbg.CborInt(v).MarshalCBOR(w)
 	case int:
 		return cbg.CborInt(v).MarshalCBOR(w)
+	case float64:
+		return writeCborFloat(w, v)
+	case float32:
+		return writeCborFloat(w, float64(v))
 	case bool:
 		return cbg.CborBool(v).MarshalCBOR(w)
 	case cid.Cid:
@@ -120,6 +131,29 @@ func (a *Any) MarshalCBOR(w io.Writer) error {
 
 func (a *Any) UnmarshalCBOR(r io.Reader) (err error) {
 	*a = Any{}
+	// Floats are identified by the initial byte, since the header extra does not
+	// convey how many bytes the value was encoded with.
+	br := cbg.GetPeeker(r)
+	first, err := br.ReadByte()
+	if err != nil {
+		return err
+	}
+	if err := br.UnreadByte(); err != nil {
+		return err
+	}
+	switch first {
+	case cborFloat16, cborFloat32:
+		return errors.New("floats must be encoded as 64 bit")
+	case cborFloat64:
+		f, err := readCborFloat(br)
+		if err != nil {
+			return err
+		}
+		a.Value = f
+		return nil
+	}
+	r = br
+
 	maj, extra, pr, err := peekCborHeader(r)
 	if err != nil {
 		return fmt.Errorf("peeking CBOR header: %w", err)
@@ -246,6 +280,10 @@ func (a *Any) MarshalDagJSON(w io.Writer) error {
 		return jw.WriteInt64(v)
 	case int:
 		return jw.WriteInt64(int64(v))
+	case float64:
+		return writeDagJsonFloat(jw, v)
+	case float32:
+		return writeDagJsonFloat(jw, float64(v))
 	case bool:
 		return jw.WriteBool(v)
 	case cid.Cid:
@@ -315,11 +353,23 @@ func (a *Any) UnmarshalDagJSON(r io.Reader) (err error) {
 		}
 		a.Value = v
 	case "number":
-		v, err := jr.ReadNumberAsInt64()
+		n, err := jr.ReadNumberAsString(maxNumberLength)
 		if err != nil {
 			return err
 		}
-		a.Value = v
+		if strings.ContainsAny(n, ".eE") {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return err
+			}
+			a.Value = v
+		} else {
+			v, err := strconv.ParseInt(n, 10, 64)
+			if err != nil {
+				return err
+			}
+			a.Value = v
+		}
 	case "array":
 		if err := jr.ReadArrayOpen(); err != nil {
 			return err
@@ -419,6 +469,58 @@ func (a *Any) UnmarshalDagJSON(r io.Reader) (err error) {
 	return nil
 }
 
+const (
+	cborFloat16 = byte(cbg.MajOther<<5 | 25)
+	cborFloat32 = byte(cbg.MajOther<<5 | 26)
+	cborFloat64 = byte(cbg.MajOther<<5 | 27)
+)
+
+// maxNumberLength is the maximum length of a number in DAG-JSON.
+const maxNumberLength = 512
+
+// writeCborFloat writes a float in its canonical DAG-CBOR form, which is
+// always 64 bit. NaN and infinities are not supported.
+func writeCborFloat(w io.Writer, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("unsupported float value: %v", f)
+	}
+	var buf [9]byte
+	buf[0] = cborFloat64
+	binary.BigEndian.PutUint64(buf[1:], math.Float64bits(f))
+	_, err := w.Write(buf[:])
+	return err
+}
+
+func readCborFloat(r io.Reader) (float64, error) {
+	var buf [9]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+	f := math.Float64frombits(binary.BigEndian.Uint64(buf[1:]))
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("unsupported float value: %v", f)
+	}
+	return f, nil
+}
+
+// writeDagJsonFloat writes a float using the shortest representation that
+// round trips. A decimal point is added to integral values so that they are
+// decoded as floats. NaN and infinities are not supported.
+func writeDagJsonFloat(jw *jsg.DagJsonWriter, f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("unsupported float value: %v", f)
+	}
+	n := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(n, ".e") {
+		n += ".0"
+	}
+	_, err := jw.Write([]byte(n))
+	return err
+}
+
 func peekCborHeader(r io.Reader) (byte, uint64, io.Reader, error) {
 	cr := cbg.NewCborReader(r)
 	maj, extra, err := cr.ReadHeader()
@@ -3,6 +3,7 @@ package datamodel_test
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/alanshaw/ucantone/ipld"
@@ -14,6 +15,9 @@ import (
 func TestAny(t *testing.T) {
 	values := []any{
 		int64(138),
+		1.5,
+		float64(-138),
+		1e300,
 		true,
 		false,
 		nil,
@@ -21,11 +25,13 @@ func TestAny(t *testing.T) {
 		"test",
 		[]byte{1, 2, 3},
 		[]string{"one", "two", "three"},
+		[]float64{0, 0.1, 2},
 		map[string]ipld.Any{"bytes": []byte{1}},
 		map[string]ipld.Any{
 			"str":   "X",
 			"bytes": []byte{2},
 		},
+		map[string]ipld.Any{"amount": 0.25},
 		// map[string]cid.Cid{
 		// 	"await/ok": testutil.RandomCID(t),
 		// },
@@ -61,3 +67,49 @@ func TestAny(t *testing.T) {
 		})
 	}
 }
+
+func TestFloat(t *testing.T) {
+	t.Run("dag-cbor canonical encoding", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := datamodel.NewAny(1.5).MarshalCBOR(&buf)
+		require.NoError(t, err)
+		require.Equal(t, []byte{0xfb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}, buf.Bytes())
+	})
+
+	t.Run("dag-cbor non 64 bit", func(t *testing.T) {
+		var decoded datamodel.Any
+		// 1.5 as a 16 bit float
+		err := decoded.UnmarshalCBOR(bytes.NewReader([]byte{0xf9, 0x3e, 0x00}))
+		require.Error(t, err)
+	})
+
+	t.Run("dag-json integral", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := datamodel.NewAny(float64(2)).MarshalDagJSON(&buf)
+		require.NoError(t, err)
+		require.Equal(t, "2.0", buf.String())
+
+		var decoded datamodel.Any
+		err = decoded.UnmarshalDagJSON(&buf)
+		require.NoError(t, err)
+		require.Equal(t, float64(2), decoded.Value)
+	})
+
+	t.Run("dag-json exponent", func(t *testing.T) {
+		var decoded datamodel.Any
+		err := decoded.UnmarshalDagJSON(bytes.NewReader([]byte("1e3")))
+		require.NoError(t, err)
+		require.Equal(t, float64(1000), decoded.Value)
+	})
+
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		t.Run(fmt.Sprintf("unsupported %v", f), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := datamodel.NewAny(f).MarshalCBOR(&buf)
+			require.Error(t, err)
+
+			err = datamodel.NewAny(f).MarshalDagJSON(&buf)
+			require.Error(t, err)
+		})
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 	jsg "github.com/alanshaw/dag-json-gen"
 	"github.com/alanshaw/ucantone/ipld"
-	"github.com/ipfs/go-cid"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	cbg "github.com/whyrusleeping/cbor-gen"
 )
 
@@ -32,15 +32,7 @@ func (mp Map) MarshalCBOR(w io.Writer) error {
 
 	keys := slices.Collect(maps.Keys(mp))
 	sort.Slice(keys, func(i, j int) bool {
-		fi := keys[i]
-		fj := keys[j]
-		if len(fi) < len(fj) {
-			return true
-		}
-		if len(fi) > len(fj) {
-			return false
-		}
-		return fi < fj
+		return keyLess(keys[i], keys[j])
 	})
 
 	for _, k := range keys {
@@ -60,6 +52,17 @@ func (mp Map) MarshalCBOR(w io.Writer) error {
 	return nil
 }
 
+// keyLess reports whether map key a sorts before b in canonical DAG-CBOR order:
+// shorter keys first, then bytewise.
+func keyLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// UnmarshalCBOR decodes a map, returning an error if the map keys are not
+// unique or are not in canonical DAG-CBOR order.
 func (mp *Map) UnmarshalCBOR(r io.Reader) (err error) {
 	cr := cbg.NewCborReader(r)
 
@@ -83,21 +86,26 @@ func (mp *Map) UnmarshalCBOR(r io.Reader) (err error) {
 
 	m := Map{}
 	n := extra
-	nameBuf := make([]byte, 2048)
-	for range n {
+	nameBuf := make([]byte, 8192)
+	var prev string
+	for i := range n {
 		nameLen, ok, err := cbg.ReadFullStringIntoBuf(cr, nameBuf, 8192)
 		if err != nil {
 			return err
 		}
 
 		if !ok {
-			if err := cbg.ScanForLinks(cr, func(cid.Cid) {}); err != nil {
-				return err
-			}
-			continue
+			return fmt.Errorf("map key longer than %d bytes", len(nameBuf))
 		}
 
 		name := string(nameBuf[:nameLen])
+		if i > 0 && !keyLess(prev, name) {
+			if prev == name {
+				return fmt.Errorf(`%w: duplicate map key "%s"`, dagcbor.ErrNonCanonical, name)
+			}
+			return fmt.Errorf(`%w: map key "%s" is not in canonical order`, dagcbor.ErrNonCanonical, name)
+		}
+		prev = name
 		var a Any
 		if err := a.UnmarshalCBOR(cr); err != nil {
 			return fmt.Errorf(`unmarshaling map value for key "%s": %w`, name, err)
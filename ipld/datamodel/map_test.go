@@ -2,10 +2,13 @@ package datamodel_test
 
 import (
 	"bytes"
+	"errors"
 	"maps"
 	"slices"
+	"strings"
 	"testing"
 
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
 	"github.com/stretchr/testify/require"
 )
@@ -53,4 +56,36 @@ func TestMap(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, slices.Collect(maps.Keys(decoded)), 0)
 	})
+
+	t.Run("long key", func(t *testing.T) {
+		key := strings.Repeat("k", 4096)
+		initial := datamodel.Map{key: int64(1)}
+
+		var buf bytes.Buffer
+		err := initial.MarshalCBOR(&buf)
+		require.NoError(t, err)
+
+		var decoded datamodel.Map
+		err = decoded.UnmarshalCBOR(&buf)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), decoded[key])
+	})
+
+	t.Run("unsorted keys", func(t *testing.T) {
+		// {"b": 1, "a": 2}
+		input := []byte{0xa2, 0x61, 'b', 0x01, 0x61, 'a', 0x02}
+
+		var decoded datamodel.Map
+		err := decoded.UnmarshalCBOR(bytes.NewReader(input))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		// {"a": 1, "a": 2}
+		input := []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'a', 0x02}
+
+		var decoded datamodel.Map
+		err := decoded.UnmarshalCBOR(bytes.NewReader(input))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
 }
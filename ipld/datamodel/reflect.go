@@ -0,0 +1,529 @@
+package datamodel
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/schema"
+	"github.com/ipfs/go-cid"
+)
+
+// Struct binds an ordinary Go struct to and from the IPLD data model using
+// reflection, so that no code generation step is required. It implements the
+// CBOR and DAG-JSON marshaler and unmarshaler interfaces, which means it can be
+// used with [Rebind]. e.g.
+//
+//	var args MyArguments
+//	err := datamodel.Rebind(datamodel.Map(inv.Arguments()), datamodel.NewStruct(&args))
+//
+// Exported struct fields are mapped to keys named by the "ipld" struct tag, or
+// the field name if there is no tag. The tag may also specify "omitempty" to
+// omit zero values when marshaling, or "-" to ignore the field entirely:
+//
+//	type MyArguments struct {
+//		To      did.DID  `ipld:"to"`
+//		Size    int      `ipld:"size"`
+//		Note    *string  `ipld:"note"`
+//		Tags    []string `ipld:"tags,omitempty"`
+//		Content cid.Cid  `ipld:"content"`
+//	}
+//
+// Pointer fields are optional. They are omitted when nil and may be absent or
+// null when unmarshaling. Other fields are required unless they specify
+// "omitempty". Fields whose types implement CBOR marshaling themselves (like
+// did.DID or types generated by cbor-gen) are bound using their own
+// implementation.
+type Struct struct {
+	Value any
+}
+
+// NewStruct creates a reflection based binding for the passed value. The value
+// must be a non-nil pointer in order to unmarshal into it.
+func NewStruct(v any) *Struct {
+	return &Struct{Value: v}
+}
+
+func (s *Struct) MarshalCBOR(w io.Writer) error {
+	m, err := Unbind(s.Value)
+	if err != nil {
+		return err
+	}
+	return m.MarshalCBOR(w)
+}
+
+func (s *Struct) UnmarshalCBOR(r io.Reader) error {
+	var m Map
+	if err := m.UnmarshalCBOR(r); err != nil {
+		return err
+	}
+	return Bind(m, s.Value)
+}
+
+func (s *Struct) MarshalDagJSON(w io.Writer) error {
+	m, err := Unbind(s.Value)
+	if err != nil {
+		return err
+	}
+	return m.MarshalDagJSON(w)
+}
+
+func (s *Struct) UnmarshalDagJSON(r io.Reader) error {
+	var m Map
+	if err := m.UnmarshalDagJSON(r); err != nil {
+		return err
+	}
+	return Bind(m, s.Value)
+}
+
+// Typed binds a value of type T, typically a struct, to and from the IPLD data
+// model using reflection (see [Struct]). A pointer to it implements CBOR and
+// DAG-JSON marshaling, so it may be used where generated types are expected,
+// for example as the arguments of a bindcap capability or bindexec handler:
+//
+//	handler := bindexec.NewHandler(func(req *bindexec.Request[*datamodel.Typed[MyArguments]], res *bindexec.Response[*datamodel.Typed[MyResult]]) error {
+//		args := req.Task().BindArguments().Value
+//		...
+//		return res.SetSuccess(&datamodel.Typed[MyResult]{Value: result})
+//	})
+type Typed[T any] struct {
+	Value T
+}
+
+func (t *Typed[T]) MarshalCBOR(w io.Writer) error {
+	return NewStruct(&t.Value).MarshalCBOR(w)
+}
+
+func (t *Typed[T]) UnmarshalCBOR(r io.Reader) error {
+	return NewStruct(&t.Value).UnmarshalCBOR(r)
+}
+
+func (t *Typed[T]) MarshalDagJSON(w io.Writer) error {
+	return NewStruct(&t.Value).MarshalDagJSON(w)
+}
+
+func (t *Typed[T]) UnmarshalDagJSON(r io.Reader) error {
+	return NewStruct(&t.Value).UnmarshalDagJSON(r)
+}
+
+// BindAs binds the map to a new value of type T. If T is a pointer, a new
+// instance of the type it points to is created. Types that implement CBOR
+// unmarshaling are bound using their own implementation, and other types are
+// bound using reflection (see [Struct]).
+func BindAs[T any](m ipld.Map) (T, error) {
+	var v T
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() == reflect.Pointer {
+		v = reflect.New(typ.Elem()).Interface().(T)
+		if u, ok := any(v).(dagcbor.Unmarshaler); ok {
+			err := Rebind(Map(m), u)
+			return v, err
+		}
+		err := Bind(m, v)
+		return v, err
+	}
+	err := Bind(m, &v)
+	return v, err
+}
+
+// Bind sets the fields of the struct pointed to by ptr from the values in the
+// map. See [Struct] for details of how fields are mapped. If a value cannot be
+// bound, a [*schema.Error] with the path of the value is returned.
+func Bind(m ipld.Map, ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("binding to non-pointer or nil value: %T", ptr)
+	}
+	return bindValue(m, rv.Elem(), "")
+}
+
+// Unbind creates a map from the fields of the passed struct, or pointer to a
+// struct. See [Struct] for details of how fields are mapped.
+func Unbind(v any) (Map, error) {
+	value, err := unbindValue(reflect.ValueOf(v))
+	if err != nil {
+		return nil, err
+	}
+	m, ok := value.(ipld.Map)
+	if !ok {
+		return nil, fmt.Errorf("unbinding non-map value: %T", v)
+	}
+	return m, nil
+}
+
+var (
+	cidType         = reflect.TypeFor[cid.Cid]()
+	bytesType       = reflect.TypeFor[[]byte]()
+	marshalerType   = reflect.TypeFor[dagcbor.Marshaler]()
+	unmarshalerType = reflect.TypeFor[dagcbor.Unmarshaler]()
+)
+
+// isBytes returns true if the type is a byte slice or array, which is bound to
+// IPLD bytes rather than a list.
+func isBytes(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && typ.Elem().Kind() == reflect.Uint8
+}
+
+type field struct {
+	index     int
+	key       string
+	omitEmpty bool
+}
+
+// fields returns details of the exported fields of a struct type that are
+// mapped to IPLD map keys.
+func fields(typ reflect.Type) []field {
+	var fields []field
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fld := field{index: i, key: f.Name}
+		if tag, ok := f.Tag.Lookup("ipld"); ok {
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				fld.key = name
+			}
+			fld.omitEmpty = slices.Contains(strings.Split(opts, ","), "omitempty")
+		}
+		fields = append(fields, fld)
+	}
+	return fields
+}
+
+func unbindValue(rv reflect.Value) (ipld.Any, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	typ := rv.Type()
+	switch {
+	case typ == cidType:
+		c := rv.Interface().(cid.Cid)
+		if !c.Defined() {
+			return nil, nil
+		}
+		return c, nil
+	case isBytes(typ):
+		if typ.Kind() == reflect.Array {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return b, nil
+		}
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return rv.Bytes(), nil
+	case typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface && typ.Implements(marshalerType):
+		return unbindMarshaler(rv.Interface().(dagcbor.Marshaler))
+	case typ.Kind() == reflect.Struct && reflect.PointerTo(typ).Implements(marshalerType):
+		ptr := reflect.New(typ)
+		ptr.Elem().Set(rv)
+		return unbindMarshaler(ptr.Interface().(dagcbor.Marshaler))
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return unbindValue(rv.Elem())
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return nil, fmt.Errorf("integer overflow: %d", u)
+		}
+		return int64(u), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, nil
+		}
+		items := make([]any, 0, rv.Len())
+		for i := range rv.Len() {
+			item, err := unbindValue(rv.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("unbinding list index %d: %w", i, err)
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type: %s", typ.Key())
+		}
+		if rv.IsNil() {
+			return nil, nil
+		}
+		m := make(ipld.Map, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			v, err := unbindValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf(`unbinding map value for key "%s": %w`, k, err)
+			}
+			m[k] = v
+		}
+		return m, nil
+	case reflect.Struct:
+		flds := fields(typ)
+		m := make(ipld.Map, len(flds))
+		for _, f := range flds {
+			fv := rv.Field(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			if fv.Kind() == reflect.Pointer && fv.IsNil() {
+				continue
+			}
+			v, err := unbindValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf(`unbinding field "%s": %w`, f.key, err)
+			}
+			m[f.key] = v
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("unsupported type: %s", typ)
+}
+
+// unbindMarshaler converts a type that implements CBOR marshaling into an IPLD
+// value by encoding and decoding it.
+func unbindMarshaler(m dagcbor.Marshaler) (ipld.Any, error) {
+	var buf bytes.Buffer
+	if err := m.MarshalCBOR(&buf); err != nil {
+		return nil, err
+	}
+	var a Any
+	if err := a.UnmarshalCBOR(&buf); err != nil {
+		return nil, err
+	}
+	return a.Value, nil
+}
+
+// bindValue binds the value at the passed selector path. Errors are returned as
+// a [*schema.Error] with the path of the value that could not be bound.
+func bindValue(value ipld.Any, rv reflect.Value, path string) error {
+	err := bindKind(value, rv, path)
+	if err == nil {
+		return nil
+	}
+	var serr *schema.Error
+	if errors.As(err, &serr) {
+		return serr
+	}
+	if path == "" {
+		path = "."
+	}
+	return &schema.Error{Path: path, Message: err.Error()}
+}
+
+func bindKind(value ipld.Any, rv reflect.Value, path string) error {
+	typ := rv.Type()
+	switch {
+	case typ == cidType:
+		if value == nil {
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+		c, ok := value.(cid.Cid)
+		if !ok {
+			return fmt.Errorf("expected link but got %T", value)
+		}
+		rv.Set(reflect.ValueOf(c))
+		return nil
+	case typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface && reflect.PointerTo(typ).Implements(unmarshalerType):
+		var buf bytes.Buffer
+		if err := NewAny(value).MarshalCBOR(&buf); err != nil {
+			return err
+		}
+		ptr := reflect.New(typ)
+		if err := ptr.Interface().(dagcbor.Unmarshaler).UnmarshalCBOR(&buf); err != nil {
+			return err
+		}
+		rv.Set(ptr.Elem())
+		return nil
+	}
+
+	if value == nil {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			rv.Set(reflect.Zero(typ))
+			return nil
+		}
+		return fmt.Errorf("expected %s but got null", typ)
+	}
+
+	vv := reflect.ValueOf(value)
+	switch typ.Kind() {
+	case reflect.Pointer:
+		ptr := reflect.New(typ.Elem())
+		if err := bindValue(value, ptr.Elem(), path); err != nil {
+			return err
+		}
+		rv.Set(ptr)
+		return nil
+	case reflect.Interface:
+		if !vv.Type().AssignableTo(typ) {
+			return fmt.Errorf("cannot assign %T to %s", value, typ)
+		}
+		rv.Set(vv)
+		return nil
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected boolean but got %T", value)
+		}
+		rv.SetBool(b)
+		return nil
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected string but got %T", value)
+		}
+		rv.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch vv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = vv.Int()
+		default:
+			return fmt.Errorf("expected integer but got %T", value)
+		}
+		if rv.OverflowInt(i) {
+			return fmt.Errorf("integer overflow: %d does not fit in %s", i, typ)
+		}
+		rv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch vv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if vv.Int() < 0 {
+				return fmt.Errorf("expected unsigned integer but got %d", vv.Int())
+			}
+			u = uint64(vv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u = vv.Uint()
+		default:
+			return fmt.Errorf("expected integer but got %T", value)
+		}
+		if rv.OverflowUint(u) {
+			return fmt.Errorf("integer overflow: %d does not fit in %s", u, typ)
+		}
+		rv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch vv.Kind() {
+		case reflect.Float32, reflect.Float64:
+			rv.SetFloat(vv.Float())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			rv.SetFloat(float64(vv.Int()))
+		default:
+			return fmt.Errorf("expected float but got %T", value)
+		}
+		return nil
+	case reflect.Slice:
+		if isBytes(typ) {
+			b, ok := value.([]byte)
+			if !ok {
+				return fmt.Errorf("expected bytes but got %T", value)
+			}
+			rv.SetBytes(b)
+			return nil
+		}
+		if vv.Kind() != reflect.Slice || vv.Type() == bytesType {
+			return fmt.Errorf("expected list but got %T", value)
+		}
+		s := reflect.MakeSlice(typ, vv.Len(), vv.Len())
+		for i := range vv.Len() {
+			if err := bindValue(vv.Index(i).Interface(), s.Index(i), schema.IndexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+		return nil
+	case reflect.Array:
+		if isBytes(typ) {
+			b, ok := value.([]byte)
+			if !ok {
+				return fmt.Errorf("expected bytes but got %T", value)
+			}
+			if len(b) != typ.Len() {
+				return fmt.Errorf("expected %d bytes but got %d", typ.Len(), len(b))
+			}
+			reflect.Copy(rv, reflect.ValueOf(b))
+			return nil
+		}
+		if vv.Kind() != reflect.Slice || vv.Type() == bytesType {
+			return fmt.Errorf("expected list but got %T", value)
+		}
+		if vv.Len() != typ.Len() {
+			return fmt.Errorf("expected list of length %d but got %d", typ.Len(), vv.Len())
+		}
+		for i := range vv.Len() {
+			if err := bindValue(vv.Index(i).Interface(), rv.Index(i), schema.IndexPath(path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type: %s", typ.Key())
+		}
+		if vv.Kind() != reflect.Map || vv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("expected map but got %T", value)
+		}
+		m := reflect.MakeMapWithSize(typ, vv.Len())
+		iter := vv.MapRange()
+		for iter.Next() {
+			k := iter.Key().String()
+			v := reflect.New(typ.Elem()).Elem()
+			if err := bindValue(iter.Value().Interface(), v, schema.FieldPath(path, k)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(typ.Key()), v)
+		}
+		rv.Set(m)
+		return nil
+	case reflect.Struct:
+		if vv.Kind() != reflect.Map || vv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("expected map but got %T", value)
+		}
+		for _, f := range fields(typ) {
+			fv := rv.Field(f.index)
+			v := vv.MapIndex(reflect.ValueOf(f.key).Convert(vv.Type().Key()))
+			if !v.IsValid() {
+				if f.omitEmpty || fv.Kind() == reflect.Pointer {
+					fv.Set(reflect.Zero(fv.Type()))
+					continue
+				}
+				return &schema.Error{Path: schema.FieldPath(path, f.key), Message: "missing required field"}
+			}
+			if err := bindValue(v.Interface(), fv, schema.FieldPath(path, f.key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return errors.New("unsupported type: " + typ.String())
+}
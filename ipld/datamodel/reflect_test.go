@@ -0,0 +1,198 @@
+package datamodel_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ipld/schema"
+	"github.com/alanshaw/ucantone/testutil"
+	tdm "github.com/alanshaw/ucantone/testutil/datamodel"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+)
+
+type Dimensions struct {
+	Width  uint32 `ipld:"width"`
+	Height uint32 `ipld:"height"`
+}
+
+type Upload struct {
+	To       did.DID           `ipld:"to"`
+	Content  cid.Cid           `ipld:"content"`
+	Size     int               `ipld:"size"`
+	Price    float64           `ipld:"price"`
+	Digest   []byte            `ipld:"digest"`
+	Tags     []string          `ipld:"tags,omitempty"`
+	Note     *string           `ipld:"note"`
+	Meta     map[string]string `ipld:"meta,omitempty"`
+	Pages    []Dimensions      `ipld:"pages"`
+	Cover    *Dimensions       `ipld:"cover"`
+	Object   *tdm.TestObject2  `ipld:"object"`
+	Internal string            `ipld:"-"`
+	Default  bool
+	private  string
+}
+
+func TestStruct(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	link := testutil.RandomCID(t)
+	note := "hello"
+
+	initial := Upload{
+		To:      alice.DID(),
+		Content: link,
+		Size:    138,
+		Price:   1.5,
+		Digest:  []byte{1, 2, 3},
+		Note:    &note,
+		Pages:   []Dimensions{{Width: 1, Height: 2}},
+		Object:  &tdm.TestObject2{Str: "nested", Bytes: []byte{9}},
+		Default: true,
+		private: "secret",
+	}
+
+	t.Run("unbind", func(t *testing.T) {
+		m, err := datamodel.Unbind(&initial)
+		require.NoError(t, err)
+		require.Equal(t, alice.DID().String(), m["to"])
+		require.Equal(t, link, m["content"])
+		require.Equal(t, int64(138), m["size"])
+		require.Equal(t, 1.5, m["price"])
+		require.Equal(t, []byte{1, 2, 3}, m["digest"])
+		require.Equal(t, "hello", m["note"])
+		require.Equal(t, []any{ipld.Map{"width": int64(1), "height": int64(2)}}, m["pages"])
+		require.Equal(t, ipld.Map{"str": "nested", "bytes": []byte{9}}, m["object"])
+		require.Equal(t, true, m["Default"])
+		for _, k := range []string{"tags", "meta", "cover", "Internal", "private"} {
+			require.NotContains(t, m, k)
+		}
+	})
+
+	t.Run("tag options", func(t *testing.T) {
+		type Options struct {
+			Tags []string `ipld:"tags,string,omitempty"`
+		}
+		m, err := datamodel.Unbind(Options{})
+		require.NoError(t, err)
+		require.NotContains(t, m, "tags")
+	})
+
+	t.Run("roundtrip", func(t *testing.T) {
+		for _, codec := range []string{"dag-cbor", "dag-json"} {
+			t.Run(codec, func(t *testing.T) {
+				var buf bytes.Buffer
+				var decoded Upload
+				if codec == "dag-cbor" {
+					require.NoError(t, datamodel.NewStruct(initial).MarshalCBOR(&buf))
+					require.NoError(t, datamodel.NewStruct(&decoded).UnmarshalCBOR(&buf))
+				} else {
+					require.NoError(t, datamodel.NewStruct(initial).MarshalDagJSON(&buf))
+					require.NoError(t, datamodel.NewStruct(&decoded).UnmarshalDagJSON(&buf))
+				}
+				expected := initial
+				expected.private = ""
+				require.Equal(t, expected, decoded)
+			})
+		}
+	})
+
+	t.Run("rebind", func(t *testing.T) {
+		args := datamodel.Map{
+			"to":      alice.DID().String(),
+			"content": link,
+			"size":    5,
+			"price":   2,
+			"digest":  []byte{4},
+			"note":    nil,
+			"pages":   []any{},
+			"cover":   datamodel.Map{"width": 10, "height": 20},
+			"object":  nil,
+			"Default": false,
+			"unknown": "ignored",
+		}
+		var upload Upload
+		err := datamodel.Rebind(args, datamodel.NewStruct(&upload))
+		require.NoError(t, err)
+		require.Equal(t, alice.DID(), upload.To)
+		require.Equal(t, 5, upload.Size)
+		require.Equal(t, float64(2), upload.Price)
+		require.Nil(t, upload.Note)
+		require.Equal(t, []Dimensions{}, upload.Pages)
+		require.Equal(t, &Dimensions{Width: 10, Height: 20}, upload.Cover)
+	})
+
+	t.Run("bind as", func(t *testing.T) {
+		m := ipld.Map{"width": int64(3), "height": int64(4)}
+
+		d, err := datamodel.BindAs[Dimensions](m)
+		require.NoError(t, err)
+		require.Equal(t, Dimensions{Width: 3, Height: 4}, d)
+
+		dp, err := datamodel.BindAs[*Dimensions](m)
+		require.NoError(t, err)
+		require.Equal(t, &Dimensions{Width: 3, Height: 4}, dp)
+
+		// generated types are bound using their own implementation
+		obj, err := datamodel.BindAs[*tdm.TestObject2](ipld.Map{"str": "generated", "bytes": []byte{}})
+		require.NoError(t, err)
+		require.Equal(t, "generated", obj.Str)
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		m := ipld.Map{"width": int64(3), "height": int64(4)}
+
+		typed, err := datamodel.BindAs[*datamodel.Typed[Dimensions]](m)
+		require.NoError(t, err)
+		require.Equal(t, Dimensions{Width: 3, Height: 4}, typed.Value)
+
+		var out datamodel.Map
+		require.NoError(t, datamodel.Rebind(typed, &out))
+		require.Equal(t, datamodel.Map{"width": int64(3), "height": int64(4)}, out)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			args ipld.Map
+		}{
+			{"missing required field", ipld.Map{"width": int64(1)}},
+			{"wrong type", ipld.Map{"width": "1", "height": int64(1)}},
+			{"negative unsigned", ipld.Map{"width": int64(-1), "height": int64(1)}},
+			{"overflow", ipld.Map{"width": int64(1 << 40), "height": int64(1)}},
+			{"null", ipld.Map{"width": nil, "height": int64(1)}},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var d Dimensions
+				err := datamodel.Bind(tc.args, &d)
+				require.Error(t, err)
+				t.Log(err)
+			})
+		}
+
+		t.Run("path", func(t *testing.T) {
+			var d Dimensions
+			err := datamodel.Bind(ipld.Map{"width": "1", "height": int64(1)}, &d)
+
+			var serr *schema.Error
+			require.True(t, errors.As(err, &serr))
+			require.Equal(t, ".width", serr.Path)
+		})
+
+		t.Run("non-pointer", func(t *testing.T) {
+			err := datamodel.Bind(ipld.Map{}, Dimensions{})
+			require.Error(t, err)
+		})
+
+		t.Run("invalid DID", func(t *testing.T) {
+			var u Upload
+			err := datamodel.Bind(ipld.Map{"to": "not a did"}, &u)
+			require.Error(t, err)
+			t.Log(err)
+		})
+	})
+}
@@ -10,6 +10,7 @@ import (
 //   - Null (nil)
 //   - Boolean (bool)
 //   - Integer (int64, int)
+//   - Float (float64)
 //   - String (string)
 //   - Bytes ([]byte)
 //   - List (slice)
@@ -0,0 +1,351 @@
+// Package schema declares the expected shape of IPLD data, such as invocation
+// arguments, and validates data against it.
+//
+// Schemas are built from Go functions, for example:
+//
+//	schema.Struct(
+//		schema.Field("to", schema.List(schema.String())),
+//		schema.Field("subject", schema.String()),
+//		schema.OptionalField("cc", schema.List(schema.String())),
+//	)
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/ipfs/go-cid"
+)
+
+// Kind is the kind of an IPLD value.
+type Kind string
+
+const (
+	KindNull   Kind = "null"
+	KindBool   Kind = "bool"
+	KindInt    Kind = "int"
+	KindFloat  Kind = "float"
+	KindString Kind = "string"
+	KindBytes  Kind = "bytes"
+	KindList   Kind = "list"
+	KindMap    Kind = "map"
+	KindLink   Kind = "link"
+	// KindAny is not a kind of value, it is used by schemas that accept values
+	// of any kind.
+	KindAny Kind = "any"
+)
+
+// Error is returned when a value does not match a schema.
+type Error struct {
+	// Path is the location of the invalid value, in selector syntax e.g.
+	// ".to[0]". The path of the root value is ".".
+	Path    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+func newError(path string, format string, args ...any) *Error {
+	return &Error{Path: rootPath(path), Message: fmt.Sprintf(format, args...)}
+}
+
+// Schema describes the expected shape of an IPLD value.
+type Schema interface {
+	// Kind is the kind of value the schema accepts.
+	Kind() Kind
+	validate(path string, value ipld.Any) error
+}
+
+// Validate checks the value matches the schema. It returns an [*Error]
+// describing the first mismatch found.
+func Validate(s Schema, value ipld.Any) error {
+	return s.validate("", value)
+}
+
+// KindOf returns the IPLD kind of the value.
+func KindOf(value ipld.Any) Kind {
+	if value == nil {
+		return KindNull
+	}
+	if _, ok := value.(cid.Cid); ok {
+		return KindLink
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return KindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return KindInt
+	case reflect.Float32, reflect.Float64:
+		return KindFloat
+	case reflect.String:
+		return KindString
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return KindBytes
+		}
+		return KindList
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			return KindMap
+		}
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return KindNull
+		}
+		return KindOf(rv.Elem().Interface())
+	}
+	return ""
+}
+
+type kindSchema struct {
+	kind Kind
+}
+
+func (ks kindSchema) Kind() Kind {
+	return ks.kind
+}
+
+func (ks kindSchema) validate(path string, value ipld.Any) error {
+	if ks.kind == KindAny {
+		return nil
+	}
+	if k := KindOf(value); k != ks.kind {
+		return newError(path, "expected %s, got %s", ks.kind, describe(k))
+	}
+	return nil
+}
+
+func describe(k Kind) string {
+	if k == "" {
+		return "unsupported type"
+	}
+	return string(k)
+}
+
+// Any accepts a value of any kind.
+func Any() Schema { return kindSchema{KindAny} }
+
+// Null accepts only null.
+func Null() Schema { return kindSchema{KindNull} }
+
+// Bool accepts a boolean.
+func Bool() Schema { return kindSchema{KindBool} }
+
+// Int accepts an integer.
+func Int() Schema { return kindSchema{KindInt} }
+
+// Float accepts a float. Integers are not accepted.
+func Float() Schema { return kindSchema{KindFloat} }
+
+// String accepts a string.
+func String() Schema { return kindSchema{KindString} }
+
+// Bytes accepts bytes.
+func Bytes() Schema { return kindSchema{KindBytes} }
+
+// Link accepts a CID.
+func Link() Schema { return kindSchema{KindLink} }
+
+type nullableSchema struct {
+	Schema
+}
+
+func (ns nullableSchema) validate(path string, value ipld.Any) error {
+	if KindOf(value) == KindNull {
+		return nil
+	}
+	return ns.Schema.validate(path, value)
+}
+
+// Nullable accepts null, or a value matching the passed schema.
+func Nullable(s Schema) Schema {
+	return nullableSchema{s}
+}
+
+type listSchema struct {
+	elem Schema
+}
+
+func (ls listSchema) Kind() Kind {
+	return KindList
+}
+
+func (ls listSchema) validate(path string, value ipld.Any) error {
+	if k := KindOf(value); k != KindList {
+		return newError(path, "expected list, got %s", describe(k))
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	for i := range rv.Len() {
+		if err := ls.elem.validate(IndexPath(path, i), rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List accepts a list whose elements all match the passed schema.
+func List(elem Schema) Schema {
+	return listSchema{elem}
+}
+
+type mapSchema struct {
+	values Schema
+}
+
+func (ms mapSchema) Kind() Kind {
+	return KindMap
+}
+
+func (ms mapSchema) validate(path string, value ipld.Any) error {
+	m, err := asMap(path, value)
+	if err != nil {
+		return err
+	}
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if err := ms.values.validate(FieldPath(path, k), m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Map accepts a map with any keys, whose values all match the passed schema.
+func Map(values Schema) Schema {
+	return mapSchema{values}
+}
+
+// FieldSchema describes a field of a struct.
+type FieldSchema struct {
+	name     string
+	schema   Schema
+	optional bool
+}
+
+// Field is a required field of a struct.
+func Field(name string, s Schema) FieldSchema {
+	return FieldSchema{name: name, schema: s}
+}
+
+// OptionalField is a field of a struct that may be absent. If present, the
+// value must match the passed schema.
+func OptionalField(name string, s Schema) FieldSchema {
+	return FieldSchema{name: name, schema: s, optional: true}
+}
+
+// StructSchema accepts a map with known fields.
+type StructSchema struct {
+	fields []FieldSchema
+	extra  bool
+}
+
+// Struct accepts a map with the passed fields. Fields that are not declared
+// are not allowed, unless [StructSchema.AllowExtraFields] is used.
+func Struct(fields ...FieldSchema) *StructSchema {
+	return &StructSchema{fields: fields}
+}
+
+// AllowExtraFields returns a copy of the schema that accepts fields that are
+// not declared. The values of extra fields are not validated.
+func (ss *StructSchema) AllowExtraFields() *StructSchema {
+	return &StructSchema{fields: ss.fields, extra: true}
+}
+
+func (ss *StructSchema) Kind() Kind {
+	return KindMap
+}
+
+func (ss *StructSchema) validate(path string, value ipld.Any) error {
+	m, err := asMap(path, value)
+	if err != nil {
+		return err
+	}
+	declared := make(map[string]struct{}, len(ss.fields))
+	for _, f := range ss.fields {
+		declared[f.name] = struct{}{}
+		v, ok := m[f.name]
+		if !ok {
+			if f.optional {
+				continue
+			}
+			return newError(FieldPath(path, f.name), "missing required field")
+		}
+		if err := f.schema.validate(FieldPath(path, f.name), v); err != nil {
+			return err
+		}
+	}
+	if !ss.extra {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			if _, ok := declared[k]; !ok {
+				return newError(FieldPath(path, k), "unexpected field")
+			}
+		}
+	}
+	return nil
+}
+
+func asMap(path string, value ipld.Any) (map[string]ipld.Any, error) {
+	if m, ok := value.(map[string]ipld.Any); ok {
+		return m, nil
+	}
+	if k := KindOf(value); k != KindMap {
+		return nil, newError(path, "expected map, got %s", describe(k))
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer {
+		rv = rv.Elem()
+	}
+	m := make(map[string]ipld.Any, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, nil
+}
+
+// FieldPath appends the map key to the path, using the quoted form if the key
+// is not a valid identifier.
+func FieldPath(path string, key string) string {
+	if isIdentifier(key) {
+		return path + "." + key
+	}
+	return rootPath(path) + "[" + strconv.Quote(key) + "]"
+}
+
+// IndexPath appends the list index to the path.
+func IndexPath(path string, i int) string {
+	return rootPath(path) + "[" + strconv.Itoa(i) + "]"
+}
+
+// rootPath returns the path, or "." if it is the path of the root value.
+func rootPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case i > 0 && c >= '0' && c <= '9':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ipld/schema"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	message := schema.Struct(
+		schema.Field("to", schema.List(schema.String())),
+		schema.Field("subject", schema.String()),
+		schema.OptionalField("attachment", schema.Link()),
+		schema.OptionalField("headers", schema.Map(schema.String())),
+		schema.OptionalField("priority", schema.Nullable(schema.Int())),
+	)
+
+	valid := map[string]ipld.Any{
+		"required fields": ipld.Map{
+			"to":      []string{"bob@example.com"},
+			"subject": "Hello!",
+		},
+		"all fields": datamodel.Map{
+			"to":         []any{"bob@example.com", "carol@example.com"},
+			"subject":    "Hello!",
+			"attachment": testutil.RandomCID(t),
+			"headers":    map[string]string{"X-Mailer": "ucantone"},
+			"priority":   int64(1),
+		},
+		"null priority": ipld.Map{
+			"to":       []string{},
+			"subject":  "Hello!",
+			"priority": nil,
+		},
+	}
+	for name, value := range valid {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, schema.Validate(message, value))
+		})
+	}
+
+	invalid := []struct {
+		name  string
+		value ipld.Any
+		path  string
+	}{
+		{"not a map", "hello", "."},
+		{"missing field", ipld.Map{"to": []string{}}, ".subject"},
+		{"wrong kind", ipld.Map{"to": []string{}, "subject": int64(1)}, ".subject"},
+		{"wrong list element", ipld.Map{"to": []any{"bob@example.com", true}, "subject": ""}, ".to[1]"},
+		{"wrong map value", ipld.Map{"to": []string{}, "subject": "", "headers": ipld.Map{"X-Priority": int64(1)}}, `.headers["X-Priority"]`},
+		{"unexpected field", ipld.Map{"to": []string{}, "subject": "", "bcc": []string{}}, ".bcc"},
+		{"bytes are not a list", ipld.Map{"to": []byte{1}, "subject": ""}, ".to"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			err := schema.Validate(message, tc.value)
+			var serr *schema.Error
+			require.True(t, errors.As(err, &serr), "unexpected error: %v", err)
+			require.Equal(t, tc.path, serr.Path)
+		})
+	}
+
+	t.Run("extra fields", func(t *testing.T) {
+		value := ipld.Map{"to": []string{}, "subject": "", "bcc": 1}
+		require.NoError(t, schema.Validate(message.AllowExtraFields(), value))
+		// the original schema is not modified
+		require.Error(t, schema.Validate(message, value))
+	})
+
+	t.Run("root list", func(t *testing.T) {
+		err := schema.Validate(schema.List(schema.Int()), []any{int64(1), "2"})
+		var serr *schema.Error
+		require.True(t, errors.As(err, &serr))
+		require.Equal(t, ".[1]", serr.Path)
+	})
+}
+
+func TestKindOf(t *testing.T) {
+	kinds := map[schema.Kind]ipld.Any{
+		schema.KindNull:   nil,
+		schema.KindBool:   true,
+		schema.KindInt:    int64(1),
+		schema.KindFloat:  1.5,
+		schema.KindString: "s",
+		schema.KindBytes:  []byte{1},
+		schema.KindList:   []int64{1},
+		schema.KindMap:    ipld.Map{},
+		schema.KindLink:   testutil.RandomCID(t),
+	}
+	for kind, value := range kinds {
+		require.Equal(t, kind, schema.KindOf(value))
+	}
+}
@@ -0,0 +1,27 @@
+package limits
+
+import (
+	"fmt"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+)
+
+// Names of the limits reported in limit exceeded errors.
+const (
+	BytesLimit           = "bytes"
+	TokensLimit          = "tokens"
+	NestingLimit         = "nesting depth"
+	PolicyDepthLimit     = "policy depth"
+	PolicyStatementLimit = "policy statements"
+	ArgumentBytesLimit   = "argument bytes"
+)
+
+const ExceededErrorName = "LimitExceeded"
+
+// NewExceededError creates an error indicating the named limit was exceeded.
+func NewExceededError(limit string, max int64) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: ExceededErrorName,
+		Message:   fmt.Sprintf("%s limit of %d exceeded", limit, max),
+	}
+}
@@ -0,0 +1,101 @@
+// Package limits defines the resource limits applied when decoding untrusted
+// input, such as containers received by a server.
+package limits
+
+import (
+	"io"
+	"math"
+)
+
+// Limits constrains the resources used when decoding untrusted input. A zero
+// value for any field means the corresponding value from [Default] is used.
+type Limits struct {
+	// MaxBytes is the maximum number of bytes read when decoding a container.
+	MaxBytes int64
+	// MaxTokens is the maximum number of tokens in a container.
+	MaxTokens int
+	// MaxNesting is the maximum nesting depth of lists and maps in a token.
+	MaxNesting int
+	// MaxPolicyDepth is the maximum nesting depth of statements in a delegation
+	// policy.
+	MaxPolicyDepth int
+	// MaxPolicyStatements is the maximum number of statements in a delegation
+	// policy, including nested statements.
+	MaxPolicyStatements int
+	// MaxArgumentBytes is the maximum encoded size of invocation arguments.
+	MaxArgumentBytes int64
+}
+
+// Default are the limits used when none are configured.
+var Default = Limits{
+	MaxBytes:            8 << 20,
+	MaxTokens:           1024,
+	MaxNesting:          64,
+	MaxPolicyDepth:      32,
+	MaxPolicyStatements: 1024,
+	MaxArgumentBytes:    1 << 20,
+}
+
+// Unlimited disables all of the limits. It may be used to decode input from a
+// trusted source that is known to exceed the defaults.
+var Unlimited = Limits{
+	MaxBytes:            math.MaxInt64,
+	MaxTokens:           math.MaxInt,
+	MaxNesting:          math.MaxInt,
+	MaxPolicyDepth:      math.MaxInt,
+	MaxPolicyStatements: math.MaxInt,
+	MaxArgumentBytes:    math.MaxInt64,
+}
+
+// OrDefault returns a copy of the limits with zero values replaced by the
+// values from [Default].
+func (l Limits) OrDefault() Limits {
+	if l.MaxBytes == 0 {
+		l.MaxBytes = Default.MaxBytes
+	}
+	if l.MaxTokens == 0 {
+		l.MaxTokens = Default.MaxTokens
+	}
+	if l.MaxNesting == 0 {
+		l.MaxNesting = Default.MaxNesting
+	}
+	if l.MaxPolicyDepth == 0 {
+		l.MaxPolicyDepth = Default.MaxPolicyDepth
+	}
+	if l.MaxPolicyStatements == 0 {
+		l.MaxPolicyStatements = Default.MaxPolicyStatements
+	}
+	if l.MaxArgumentBytes == 0 {
+		l.MaxArgumentBytes = Default.MaxArgumentBytes
+	}
+	return l
+}
+
+// NewReader returns a reader that reads from r but returns a limit exceeded
+// error if more than max bytes are available.
+func NewReader(r io.Reader, max int64) io.Reader {
+	return &reader{r: r, remaining: max, max: max}
+}
+
+type reader struct {
+	r         io.Reader
+	remaining int64
+	max       int64
+}
+
+func (l *reader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, NewExceededError(BytesLimit, l.max)
+	}
+	// read one more byte than remaining so that exceeding the limit is detected
+	if int64(len(p))-1 > l.remaining {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		n += int(l.remaining)
+		return n, NewExceededError(BytesLimit, l.max)
+	}
+	return n, err
+}
@@ -0,0 +1,48 @@
+package limits_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader(t *testing.T) {
+	data := bytes.Repeat([]byte{1}, 100)
+
+	t.Run("within limit", func(t *testing.T) {
+		b, err := io.ReadAll(limits.NewReader(bytes.NewReader(data), 100))
+		require.NoError(t, err)
+		require.Equal(t, data, b)
+	})
+
+	t.Run("within limit one byte at a time", func(t *testing.T) {
+		r := limits.NewReader(iotest.OneByteReader(bytes.NewReader(data)), 100)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, data, b)
+	})
+
+	t.Run("exceeds limit", func(t *testing.T) {
+		b, err := io.ReadAll(limits.NewReader(bytes.NewReader(data), 99))
+		require.Error(t, err)
+		require.Len(t, b, 99)
+
+		var lerr edm.ErrorModel
+		require.True(t, errors.As(err, &lerr))
+		require.Equal(t, limits.ExceededErrorName, lerr.Name())
+	})
+}
+
+func TestOrDefault(t *testing.T) {
+	l := limits.Limits{MaxTokens: 3}.OrDefault()
+	require.Equal(t, 3, l.MaxTokens)
+	require.Equal(t, limits.Default.MaxBytes, l.MaxBytes)
+	require.Equal(t, limits.Default.MaxNesting, l.MaxNesting)
+	require.Equal(t, limits.Default.MaxArgumentBytes, l.MaxArgumentBytes)
+}
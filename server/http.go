@@ -2,13 +2,17 @@ package server
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/alanshaw/ucantone/errors"
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/execution/dispatcher"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/principal"
 	"github.com/alanshaw/ucantone/transport"
 	"github.com/alanshaw/ucantone/ucan"
@@ -17,30 +21,40 @@ import (
 )
 
 type HTTPServer struct {
-	id        principal.Signer
-	executor  *dispatcher.Dispatcher
-	codec     transport.InboundCodec[*http.Request, *http.Response]
-	listeners []EventListener
+	id          principal.Signer
+	executor    *dispatcher.Dispatcher
+	codec       transport.InboundCodec[*http.Request, *http.Response]
+	listeners   []EventListener
+	concurrency int
 }
 
 // NewHTTP creates a new server capable of handling UCAN invocations over HTTP.
 func NewHTTP(id principal.Signer, options ...HTTPOption) *HTTPServer {
-	cfg := httpServerConfig{
-		codec: transport.DefaultHTTPInboundCodec,
-	}
+	cfg := httpServerConfig{}
 	for _, opt := range options {
 		opt(&cfg)
 	}
+	if cfg.codec == nil {
+		var codecOpts []transport.HTTPInboundCodecOption
+		if cfg.limits != nil {
+			codecOpts = append(codecOpts, transport.WithLimits(*cfg.limits))
+		}
+		cfg.codec = transport.NewHTTPNegotiatingInboundCodec(codecOpts...)
+	}
 	executor := dispatcher.New(
 		id,
 		dispatcher.WithValidationOptions(cfg.validationOpts...),
 		dispatcher.WithReceiptTimestamps(cfg.receiptTimestamps),
 	)
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
 	return &HTTPServer{
-		id:        id,
-		codec:     cfg.codec,
-		executor:  executor,
-		listeners: cfg.listeners,
+		id:          id,
+		codec:       cfg.codec,
+		executor:    executor,
+		listeners:   cfg.listeners,
+		concurrency: cfg.concurrency,
 	}
 }
 
@@ -86,24 +100,94 @@ func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	w.WriteHeader(resp.StatusCode)
-	io.Copy(w, resp.Body)
+	if resp.Header.Get("Content-Type") == transport.StreamContentType {
+		if err := copyFlush(w, resp.Body); err != nil {
+			resp.Body.Close()
+			// The status has been sent, so abort the response to ensure the client
+			// sees the stream fail, rather than end early.
+			panic(http.ErrAbortHandler)
+		}
+	} else {
+		io.Copy(w, resp.Body)
+	}
 	resp.Body.Close()
 }
 
+// copyFlush copies from the reader to the response writer, flushing after each
+// read so that streamed data is sent immediately.
+func copyFlush(w http.ResponseWriter, r io.Reader) error {
+	rc := http.NewResponseController(w)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
 // RoundTrip unpacks and executes an incoming request, returning the response.
+//
+// Failures are returned as error responses, encoded by the codec when it
+// implements [transport.HTTPErrorEncoder]. Requests that cannot be decoded
+// receive a 4xx status: 400 for malformed containers, 406 or 415 for media
+// types that are not supported and 413 for containers that exceed the limits.
+// All other failures receive a 500 status. An error is returned only if the
+// error response cannot be encoded.
 func (s *HTTPServer) RoundTrip(r *http.Request) (*http.Response, error) {
-	reqContainer, err := s.codec.Decode(r)
+	codec := s.codec
+	if nc, ok := codec.(transport.NegotiatingInboundCodec[*http.Request, *http.Response]); ok {
+		c, err := nc.Negotiate(r)
+		if err != nil {
+			if c != nil {
+				codec = c
+			}
+			return s.errorResponse(codec, clientErrorStatus(err), fmt.Errorf("negotiating codec: %w", err))
+		}
+		codec = c
+	}
+
+	reqContainer, err := codec.Decode(r)
 	if err != nil {
-		return nil, fmt.Errorf("decoding request: %w", err)
+		return s.errorResponse(codec, clientErrorStatus(err), fmt.Errorf("decoding request: %w", err))
 	}
-	err = s.emitRequestDecode(r.Context(), reqContainer)
+
+	resp, err := s.execute(r, codec, reqContainer)
+	if err != nil {
+		return s.errorResponse(codec, http.StatusInternalServerError, err)
+	}
+	return resp, nil
+}
+
+// execute runs the invocations in the request container that are addressed to
+// this server, and encodes the receipts in the response.
+func (s *HTTPServer) execute(r *http.Request, codec transport.InboundCodec[*http.Request, *http.Response], reqContainer ucan.Container) (*http.Response, error) {
+	err := s.emitRequestDecode(r.Context(), reqContainer)
 	if err != nil {
 		return nil, fmt.Errorf("emitting request decode event: %w", err)
 	}
 
-	var invocations []ucan.Invocation
-	var delegations []ucan.Delegation
-	var receipts []ucan.Receipt
+	// Codecs that carry the container outside of the body leave it for handlers.
+	// Handlers share the body, so they must not be executed concurrently.
+	var body io.Reader
+	concurrency := s.concurrency
+	if hrc, ok := reqContainer.(*transport.HTTPRequestContainer); ok {
+		body = hrc.Request.Body
+		concurrency = 1
+	}
+
+	var tasks []ucan.Invocation
 	for _, inv := range reqContainer.Invocations() {
 		aud := inv.Audience()
 		if aud == nil {
@@ -113,21 +197,132 @@ func (s *HTTPServer) RoundTrip(r *http.Request) (*http.Response, error) {
 		if aud.DID() != s.id.DID() {
 			continue
 		}
-		req := execution.NewRequest(
-			r.Context(),
+		tasks = append(tasks, inv)
+	}
+
+	newRequest := func(ctx context.Context, inv ucan.Invocation) execution.Request {
+		return execution.NewRequest(
+			ctx,
 			inv,
 			execution.WithInvocations(reqContainer.Invocations()...),
 			execution.WithDelegations(reqContainer.Delegations()...),
 			execution.WithReceipts(reqContainer.Receipts()...),
+			execution.WithBody(body),
 		)
+	}
 
-		res, err := s.executor.Execute(req)
-		if err != nil {
-			// This shouldn't really happen, executor only returns an error when
-			// result or metadata cannot be set, which is likely a developer error.
-			return nil, fmt.Errorf("executing task %s: %w", inv.Task().Link(), err)
+	if transport.AcceptsStream(r) {
+		return s.executeStream(r.Context(), tasks, concurrency, newRequest), nil
+	}
+
+	results := make([]execution.Response, len(tasks))
+	err = s.executeAll(r.Context(), tasks, concurrency, newRequest, func(i int, res execution.Response) error {
+		results[i] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	respContainer := responseContainer(results...)
+	err = s.emitResponseEncode(r.Context(), respContainer)
+	if err != nil {
+		return nil, fmt.Errorf("emitting response encode event: %w", err)
+	}
+	resp, err := codec.Encode(respContainer)
+	if err != nil {
+		return nil, fmt.Errorf("encoding response container: %w", err)
+	}
+
+	return resp, nil
+}
+
+// executeStream executes the invocations in the background, returning a
+// streamed response that receives a container for each task as it completes.
+// Response encode listeners are called for each container.
+func (s *HTTPServer) executeStream(ctx context.Context, invs []ucan.Invocation, concurrency int, newRequest func(context.Context, ucan.Invocation) execution.Request) *http.Response {
+	resp, w := transport.NewHTTPStreamResponse()
+	go func() {
+		err := s.executeAll(ctx, invs, concurrency, newRequest, func(_ int, res execution.Response) error {
+			ct := responseContainer(res)
+			if err := s.emitResponseEncode(ctx, ct); err != nil {
+				return fmt.Errorf("emitting response encode event: %w", err)
+			}
+			return w.Write(ct)
+		})
+		w.Close(err)
+	}()
+	return resp
+}
+
+// executeAll executes the invocations, at most concurrency at a time,
+// calling emit with the index of each invocation and its response as they
+// complete. Calls to emit are not concurrent. If an execution or emit fails,
+// or the context is canceled, executions in flight are canceled and no more
+// are started.
+func (s *HTTPServer) executeAll(
+	ctx context.Context,
+	invs []ucan.Invocation,
+	concurrency int,
+	newRequest func(context.Context, ucan.Invocation) execution.Request,
+	emit func(i int, res execution.Response) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(invs))
+	sem := make(chan struct{}, concurrency)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+loop:
+	for i, inv := range invs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
 		}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res, err := s.executor.Execute(newRequest(ctx, inv))
+			if err != nil {
+				// This shouldn't really happen, executor only returns an error when
+				// result or metadata cannot be set, which is likely a developer error.
+				errs[i] = fmt.Errorf("executing task %s: %w", inv.Task().Link(), err)
+				cancel()
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err := emit(i, res); err != nil {
+				errs[i] = err
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
 
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if err := context.Cause(ctx); err != nil {
+		return fmt.Errorf("executing tasks: %w", err)
+	}
+	return nil
+}
+
+// responseContainer creates a container with the receipts from the responses
+// and any additional tokens from their metadata.
+func responseContainer(results ...execution.Response) *container.Container {
+	var invocations []ucan.Invocation
+	var delegations []ucan.Delegation
+	var receipts []ucan.Receipt
+	for _, res := range results {
 		receipts = append(receipts, res.Receipt())
 		if res.Metadata() != nil {
 			invocations = append(invocations, res.Metadata().Invocations()...)
@@ -135,21 +330,45 @@ func (s *HTTPServer) RoundTrip(r *http.Request) (*http.Response, error) {
 			receipts = append(receipts, res.Metadata().Receipts()...)
 		}
 	}
-
-	respContainer := container.New(
+	return container.New(
 		container.WithInvocations(invocations...),
 		container.WithDelegations(delegations...),
 		container.WithReceipts(receipts...),
 	)
+}
 
-	err = s.emitResponseEncode(r.Context(), respContainer)
-	if err != nil {
-		return nil, fmt.Errorf("emitting response encode event: %w", err)
+// errorResponse creates a response for the error with the passed status code.
+func (s *HTTPServer) errorResponse(codec transport.InboundCodec[*http.Request, *http.Response], statusCode int, err error) (*http.Response, error) {
+	model := edm.ErrorModel{ErrorName: transport.InternalErrorName, Message: err.Error()}
+	var named errors.Named
+	if statusCode != http.StatusInternalServerError && errors.As(err, &named) {
+		model.ErrorName = named.Name()
 	}
-	resp, err := s.codec.Encode(respContainer)
-	if err != nil {
-		return nil, fmt.Errorf("encoding response container: %w", err)
+	if enc, ok := codec.(transport.HTTPErrorEncoder); ok {
+		return enc.EncodeError(statusCode, model)
 	}
-
+	resp := &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(strings.NewReader(model.Message)),
+		Header:     http.Header{},
+	}
+	resp.Header.Set("Content-Type", "text/plain; charset=utf-8")
 	return resp, nil
 }
+
+// clientErrorStatus returns the HTTP status code for an error negotiating a
+// codec or decoding a request.
+func clientErrorStatus(err error) int {
+	var named errors.Named
+	if errors.As(err, &named) {
+		switch named.Name() {
+		case transport.UnsupportedMediaTypeErrorName:
+			return http.StatusUnsupportedMediaType
+		case transport.NotAcceptableErrorName:
+			return http.StatusNotAcceptable
+		case limits.ExceededErrorName:
+			return http.StatusRequestEntityTooLarge
+		}
+	}
+	return http.StatusBadRequest
+}
@@ -1,18 +1,29 @@
 package server_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
 	"github.com/alanshaw/ucantone/execution"
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/codec/dagjson"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/result"
 	"github.com/alanshaw/ucantone/server"
 	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/container"
 	"github.com/alanshaw/ucantone/ucan/invocation"
 	"github.com/stretchr/testify/require"
@@ -48,10 +59,10 @@ func TestHTTPServer(t *testing.T) {
 		ct := container.New(container.WithInvocations(logInv))
 
 		r, w := io.Pipe()
-		go func() {
+		go func(ct *container.Container, w *io.PipeWriter) {
 			err := ct.MarshalCBOR(w)
 			w.CloseWithError(err)
-		}()
+		}(ct, w)
 
 		req := http.Request{Header: http.Header{}, Body: r}
 		req.Header.Set("Content-Type", dagcbor.ContentType)
@@ -82,10 +93,10 @@ func TestHTTPServer(t *testing.T) {
 		ct = container.New(container.WithInvocations(echoInv))
 
 		r, w = io.Pipe()
-		go func() {
+		go func(ct *container.Container, w *io.PipeWriter) {
 			err := ct.MarshalCBOR(w)
 			w.CloseWithError(err)
-		}()
+		}(ct, w)
 
 		req = http.Request{Header: http.Header{}, Body: r}
 		req.Header.Set("Content-Type", dagcbor.ContentType)
@@ -112,4 +123,358 @@ func TestHTTPServer(t *testing.T) {
 		require.Len(t, messages, 1) // should not have changed
 		require.Equal(t, "echo!", o.(ipld.Map)["message"])
 	})
+
+	t.Run("content negotiation", func(t *testing.T) {
+		server := server.NewHTTP(service)
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+
+		inv, err := testutil.TestEchoCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "echo!"},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		var body bytes.Buffer
+		require.NoError(t, container.New(container.WithInvocations(inv)).MarshalDagJSON(&body))
+
+		t.Run("DAG-JSON", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+			req.Header.Set("Content-Type", dagjson.ContentType)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, dagjson.ContentType, rec.Header().Get("Content-Type"))
+
+			ctResp := container.Container{}
+			require.NoError(t, ctResp.UnmarshalDagJSON(rec.Body))
+			require.Len(t, ctResp.Receipts(), 1)
+			o, x := result.Unwrap(ctResp.Receipts()[0].Out())
+			require.Nil(t, x)
+			require.Equal(t, "echo!", o.(ipld.Map)["message"])
+		})
+
+		t.Run("unsupported media type", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		})
+
+		t.Run("not acceptable", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+			req.Header.Set("Content-Type", dagjson.ContentType)
+			req.Header.Set("Accept", "text/html")
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusNotAcceptable, rec.Code)
+			// the error is encoded in the request media type
+			require.Equal(t, dagjson.ContentType, rec.Header().Get("Content-Type"))
+			var model edm.ErrorModel
+			require.NoError(t, model.UnmarshalDagJSON(rec.Body))
+			require.Equal(t, transport.NotAcceptableErrorName, model.Name())
+		})
+	})
+
+	t.Run("error responses", func(t *testing.T) {
+		inv, err := testutil.TestEchoCapability.Invoke(
+			alice,
+			alice,
+			datamodel.Map{"message": "echo!"},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		var body bytes.Buffer
+		require.NoError(t, container.New(container.WithInvocations(inv)).MarshalCBOR(&body))
+
+		serve := func(t *testing.T, server *server.HTTPServer, contentType string, body []byte) (int, edm.ErrorModel) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			var model edm.ErrorModel
+			switch rec.Header().Get("Content-Type") {
+			case dagcbor.ContentType:
+				require.NoError(t, model.UnmarshalCBOR(rec.Body))
+			case dagjson.ContentType:
+				require.NoError(t, model.UnmarshalDagJSON(rec.Body))
+			default:
+				t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+			}
+			return rec.Code, model
+		}
+
+		t.Run("malformed container", func(t *testing.T) {
+			code, model := serve(t, server.NewHTTP(service), dagcbor.ContentType, []byte{0xa1, 0x01})
+			require.Equal(t, http.StatusBadRequest, code)
+			require.Equal(t, transport.MalformedContainerErrorName, model.Name())
+		})
+
+		t.Run("malformed DAG-JSON container", func(t *testing.T) {
+			code, model := serve(t, server.NewHTTP(service), dagjson.ContentType, []byte("{"))
+			require.Equal(t, http.StatusBadRequest, code)
+			require.Equal(t, transport.MalformedContainerErrorName, model.Name())
+		})
+
+		t.Run("oversized body", func(t *testing.T) {
+			server := server.NewHTTP(service, server.WithLimits(limits.Limits{MaxBytes: 16}))
+			code, model := serve(t, server, dagcbor.ContentType, body.Bytes())
+			require.Equal(t, http.StatusRequestEntityTooLarge, code)
+			require.Equal(t, limits.ExceededErrorName, model.Name())
+		})
+
+		t.Run("unsupported media type", func(t *testing.T) {
+			code, model := serve(t, server.NewHTTP(service), "text/plain", body.Bytes())
+			require.Equal(t, http.StatusUnsupportedMediaType, code)
+			require.Equal(t, transport.UnsupportedMediaTypeErrorName, model.Name())
+		})
+
+		t.Run("internal error", func(t *testing.T) {
+			listener := requestDecodeListenerFunc(func(ctx context.Context, ct ucan.Container) error {
+				return errors.New("boom")
+			})
+			server := server.NewHTTP(service, server.WithEventListener(listener))
+			code, model := serve(t, server, dagcbor.ContentType, body.Bytes())
+			require.Equal(t, http.StatusInternalServerError, code)
+			require.Equal(t, transport.InternalErrorName, model.Name())
+			require.Contains(t, model.Message, "boom")
+		})
+	})
+
+	t.Run("concurrent execution", func(t *testing.T) {
+		const concurrency = 3
+		var order []ucan.Link
+		listener := responseEncodeListenerFunc(func(ctx context.Context, ct ucan.Container) error {
+			for _, rcpt := range ct.Receipts() {
+				order = append(order, rcpt.Ran())
+			}
+			return nil
+		})
+		server := server.NewHTTP(
+			service,
+			server.WithConcurrency(concurrency),
+			server.WithEventListener(listener),
+		)
+
+		var inFlight, maxInFlight atomic.Int64
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			// finish in a different order to the invocations
+			delay := req.Invocation().Arguments()["delay"].(int64)
+			time.Sleep(time.Duration(delay) * time.Millisecond)
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+
+		var invs []ucan.Invocation
+		for i := range 10 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"message": "echo!", "delay": int64(10 - i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		ct := container.New(container.WithInvocations(invs...))
+		var body bytes.Buffer
+		require.NoError(t, ct.MarshalCBOR(&body))
+
+		// the order of the invocations in the encoded container
+		decoded := container.Container{}
+		require.NoError(t, decoded.UnmarshalCBOR(bytes.NewReader(body.Bytes())))
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", dagcbor.ContentType)
+		resp, err := server.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		ctResp := container.Container{}
+		require.NoError(t, ctResp.UnmarshalCBOR(resp.Body))
+		require.Len(t, ctResp.Receipts(), len(invs))
+
+		// receipts are in the same order as the invocations in the request
+		require.Len(t, order, len(invs))
+		for i, inv := range decoded.Invocations() {
+			require.Equal(t, inv.Task().Link(), order[i])
+			rcpt, ok := ctResp.Receipt(inv.Task().Link())
+			require.True(t, ok)
+			_, x := result.Unwrap(rcpt.Out())
+			require.Nil(t, x)
+		}
+		require.LessOrEqual(t, maxInFlight.Load(), int64(concurrency))
+		require.Greater(t, maxInFlight.Load(), int64(1))
+	})
+
+	t.Run("header codec executes sequentially", func(t *testing.T) {
+		server := server.NewHTTP(
+			service,
+			server.WithConcurrency(3),
+			server.WithHTTPCodec(transport.NewHTTPHeaderInboundCodec()),
+		)
+
+		var inFlight, maxInFlight atomic.Int64
+		var read bytes.Buffer
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			if n > maxInFlight.Load() {
+				maxInFlight.Store(n)
+			}
+			// handlers share the request body
+			b := make([]byte, 4)
+			if _, err := io.ReadFull(req.Body(), b); err != nil {
+				return err
+			}
+			read.Write(b)
+			time.Sleep(5 * time.Millisecond)
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+
+		var invs []ucan.Invocation
+		for range 3 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"message": "echo!"},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		ct := container.New(container.WithInvocations(invs...))
+		req, err := transport.NewHTTPHeaderOutboundCodec().EncodeWithBody(ct, strings.NewReader("000011112222"))
+		require.NoError(t, err)
+
+		resp, err := server.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		ctResp := container.Container{}
+		require.NoError(t, ctResp.UnmarshalCBOR(resp.Body))
+		require.Len(t, ctResp.Receipts(), len(invs))
+		require.Equal(t, int64(1), maxInFlight.Load())
+		require.Equal(t, "000011112222", read.String())
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		server := server.NewHTTP(service, server.WithConcurrency(2))
+
+		started := make(chan struct{}, 2)
+		var canceled atomic.Int64
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			started <- struct{}{}
+			<-req.Context().Done()
+			canceled.Add(1)
+			return req.Context().Err()
+		})
+
+		var invs []ucan.Invocation
+		for i := range 4 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		var body bytes.Buffer
+		require.NoError(t, container.New(container.WithInvocations(invs...)).MarshalCBOR(&body))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", dagcbor.ContentType)
+
+		go func() {
+			<-started
+			<-started
+			cancel()
+		}()
+
+		resp, err := server.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		// only the in flight handlers were started, and both were canceled
+		require.Equal(t, int64(2), canceled.Load())
+	})
+
+	t.Run("streamed response", func(t *testing.T) {
+		server := server.NewHTTP(service, server.WithConcurrency(2))
+
+		release := make(chan struct{})
+		server.Handle(testutil.TestEchoCapability, func(req execution.Request, res execution.Response) error {
+			// the first invocation completes after the second
+			if req.Invocation().Arguments()["n"].(int64) == 0 {
+				<-release
+			}
+			return res.SetSuccess(req.Invocation().Arguments())
+		})
+
+		var invs []ucan.Invocation
+		for i := range 2 {
+			inv, err := testutil.TestEchoCapability.Invoke(
+				alice,
+				alice,
+				datamodel.Map{"n": int64(i)},
+				invocation.WithAudience(service),
+			)
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+
+		var body bytes.Buffer
+		require.NoError(t, container.New(container.WithInvocations(invs...)).MarshalCBOR(&body))
+
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body.Bytes()))
+		req.Header.Set("Content-Type", dagcbor.ContentType)
+		req.Header.Set("Accept", transport.StreamContentType)
+		resp, err := server.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, transport.StreamContentType, resp.Header.Get("Content-Type"))
+
+		var order []ucan.Link
+		for ct, err := range transport.DefaultHTTPOutboundCodec.DecodeStream(resp) {
+			require.NoError(t, err)
+			require.Len(t, ct.Receipts(), 1)
+			order = append(order, ct.Receipts()[0].Ran())
+			if len(order) == 1 {
+				close(release)
+			}
+		}
+		// receipts are streamed in the order the tasks complete
+		require.Equal(t, []ucan.Link{invs[1].Task().Link(), invs[0].Task().Link()}, order)
+	})
+}
+
+type requestDecodeListenerFunc func(ctx context.Context, ct ucan.Container) error
+
+func (f requestDecodeListenerFunc) OnRequestDecode(ctx context.Context, ct ucan.Container) error {
+	return f(ctx, ct)
+}
+
+type responseEncodeListenerFunc func(ctx context.Context, ct ucan.Container) error
+
+func (f responseEncodeListenerFunc) OnResponseEncode(ctx context.Context, ct ucan.Container) error {
+	return f(ctx, ct)
 }
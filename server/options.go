@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/transport"
 	"github.com/alanshaw/ucantone/validator"
 )
@@ -15,6 +16,8 @@ type httpServerConfig struct {
 	validationOpts    []validator.Option
 	receiptTimestamps bool
 	listeners         []EventListener
+	limits            *limits.Limits
+	concurrency       int
 }
 
 func WithHTTPCodec(codec transport.InboundCodec[*http.Request, *http.Response]) HTTPOption {
@@ -23,6 +26,28 @@ func WithHTTPCodec(codec transport.InboundCodec[*http.Request, *http.Response])
 	}
 }
 
+// WithLimits configures the resource limits enforced when decoding request
+// containers. Zero values in the limits are replaced by the defaults. It has
+// no effect if a custom codec is configured using [WithHTTPCodec].
+func WithLimits(l limits.Limits) HTTPOption {
+	return func(cfg *httpServerConfig) {
+		cfg.limits = &l
+	}
+}
+
+// WithConcurrency configures the maximum number of invocations from a single
+// request that are executed concurrently. The default is 1, meaning
+// invocations are executed one at a time. Receipts are added to the response
+// in the same order as the invocations in the request, regardless.
+//
+// Invocations from a request whose container is carried in a header share the
+// request body, so they are always executed one at a time.
+func WithConcurrency(n int) HTTPOption {
+	return func(cfg *httpServerConfig) {
+		cfg.concurrency = n
+	}
+}
+
 func WithValidationOptions(options ...validator.Option) HTTPOption {
 	return func(cfg *httpServerConfig) {
 		cfg.validationOpts = append(cfg.validationOpts, options...)
@@ -5,7 +5,9 @@ import (
 	"io"
 	"net/http"
 
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/container"
 )
@@ -15,30 +17,58 @@ var (
 	DefaultHTTPOutboundCodec = &HTTPOutboundCodec{}
 )
 
-type HTTPInboundCodec struct{}
+// HTTPInboundCodecOption is an option configuring an [HTTPInboundCodec],
+// [HTTPDagJSONInboundCodec] or [HTTPNegotiatingInboundCodec].
+type HTTPInboundCodecOption func(cfg *inboundCodecConfig)
+
+type inboundCodecConfig struct {
+	limits limits.Limits
+}
+
+func newInboundCodecConfig(options []HTTPInboundCodecOption) inboundCodecConfig {
+	cfg := inboundCodecConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLimits configures the resource limits enforced when decoding request
+// containers. Zero values in the limits are replaced by the defaults.
+func WithLimits(l limits.Limits) HTTPInboundCodecOption {
+	return func(cfg *inboundCodecConfig) {
+		cfg.limits = l
+	}
+}
+
+// HTTPInboundCodec decodes requests and encodes responses with a DAG-CBOR
+// encoded container in the body.
+type HTTPInboundCodec struct {
+	limits limits.Limits
+}
+
+// NewHTTPInboundCodec creates a new codec for decoding requests and encoding
+// responses sent over HTTP.
+func NewHTTPInboundCodec(options ...HTTPInboundCodecOption) *HTTPInboundCodec {
+	cfg := newInboundCodecConfig(options)
+	return &HTTPInboundCodec{limits: cfg.limits}
+}
 
 var _ InboundCodec[*http.Request, *http.Response] = (*HTTPInboundCodec)(nil)
 
 func (h *HTTPInboundCodec) Decode(r *http.Request) (ucan.Container, error) {
-	if r.Header.Get("Content-Type") != dagcbor.ContentType {
-		return nil, fmt.Errorf("invalid content type %q, expected %q", r.Header.Get("Content-Type"), dagcbor.ContentType)
+	if mediaType(r.Header.Get("Content-Type")) != dagcbor.ContentType {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), dagcbor.ContentType)
 	}
 	ct := container.Container{}
-	if err := ct.UnmarshalCBOR(r.Body); err != nil {
-		return nil, fmt.Errorf("unmarshaling request container: %w", err)
+	if err := ct.UnmarshalCBORWithLimits(r.Body, h.limits); err != nil {
+		return nil, newDecodeError("unmarshaling request container", err)
 	}
 	return &ct, nil
 }
 
 func (h *HTTPInboundCodec) Encode(c ucan.Container) (*http.Response, error) {
-	ct, ok := c.(*container.Container)
-	if !ok {
-		ct = container.New(
-			container.WithInvocations(c.Invocations()...),
-			container.WithDelegations(c.Delegations()...),
-			container.WithReceipts(c.Receipts()...),
-		)
-	}
+	ct := asContainer(c)
 	r, w := io.Pipe()
 	go func() {
 		err := ct.MarshalCBOR(w)
@@ -53,24 +83,67 @@ func (h *HTTPInboundCodec) Encode(c ucan.Container) (*http.Response, error) {
 	return resp, nil
 }
 
+var _ HTTPErrorEncoder = (*HTTPInboundCodec)(nil)
+
+// EncodeError creates an error response with the DAG-CBOR encoded error in
+// the body.
+func (h *HTTPInboundCodec) EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error) {
+	return encodeErrorResponse(statusCode, dagcbor.ContentType, err.MarshalCBOR)
+}
+
 type HTTPResponseContainer struct {
 	ucan.Container
 	Response *http.Response
 }
 
-type HTTPOutboundCodec struct{}
+// Close closes the body of the response the container was decoded from.
+func (c *HTTPResponseContainer) Close() error {
+	return c.Response.Body.Close()
+}
+
+// HTTPOutboundCodecOption is an option configuring an [HTTPOutboundCodec] or
+// [HTTPDagJSONOutboundCodec].
+type HTTPOutboundCodecOption func(cfg *outboundCodecConfig)
+
+type outboundCodecConfig struct {
+	limits limits.Limits
+}
+
+func newOutboundCodecConfig(options []HTTPOutboundCodecOption) outboundCodecConfig {
+	cfg := outboundCodecConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithResponseLimits configures the resource limits enforced when decoding
+// response containers. Zero values in the limits are replaced by the
+// defaults. Pass [limits.Unlimited] to decode responses from a trusted
+// service that may exceed the defaults.
+func WithResponseLimits(l limits.Limits) HTTPOutboundCodecOption {
+	return func(cfg *outboundCodecConfig) {
+		cfg.limits = l
+	}
+}
+
+// HTTPOutboundCodec encodes requests and decodes responses with a DAG-CBOR
+// encoded container in the body.
+type HTTPOutboundCodec struct {
+	limits limits.Limits
+}
+
+// NewHTTPOutboundCodec creates a new codec for encoding requests and decoding
+// responses sent over HTTP.
+func NewHTTPOutboundCodec(options ...HTTPOutboundCodecOption) *HTTPOutboundCodec {
+	cfg := newOutboundCodecConfig(options)
+	return &HTTPOutboundCodec{limits: cfg.limits}
+}
 
 var _ OutboundCodec[*http.Request, *http.Response] = (*HTTPOutboundCodec)(nil)
 
 func (h *HTTPOutboundCodec) Encode(c ucan.Container) (*http.Request, error) {
-	ct, ok := c.(*container.Container)
-	if !ok {
-		ct = container.New(
-			container.WithInvocations(c.Invocations()...),
-			container.WithDelegations(c.Delegations()...),
-			container.WithReceipts(c.Receipts()...),
-		)
-	}
+	ct := asContainer(c)
 	r, w := io.Pipe()
 	go func() {
 		err := ct.MarshalCBOR(w)
@@ -82,16 +155,38 @@ func (h *HTTPOutboundCodec) Encode(c ucan.Container) (*http.Request, error) {
 		Header: http.Header{},
 	}
 	req.Header.Set("Content-Type", dagcbor.ContentType)
+	req.Header.Set("Accept", dagcbor.ContentType)
 	return req, nil
 }
 
+// Decode decodes the response container. It returns an [*HTTPError] if the
+// response has an error status.
 func (h *HTTPOutboundCodec) Decode(r *http.Response) (ucan.Container, error) {
-	if r.Header.Get("Content-Type") != dagcbor.ContentType {
-		return nil, fmt.Errorf("invalid content type %q, expected %q", r.Header.Get("Content-Type"), dagcbor.ContentType)
+	if r.StatusCode >= http.StatusBadRequest {
+		return nil, decodeErrorResponse(r, dagcbor.ContentType, func(m *edm.ErrorModel, r io.Reader) error {
+			return m.UnmarshalCBOR(r)
+		})
+	}
+	if mediaType(r.Header.Get("Content-Type")) != dagcbor.ContentType {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), dagcbor.ContentType)
 	}
 	ct := container.Container{}
-	if err := ct.UnmarshalCBOR(r.Body); err != nil {
+	if err := ct.UnmarshalCBORWithLimits(r.Body, h.limits); err != nil {
 		return nil, fmt.Errorf("unmarshaling response container: %w", err)
 	}
 	return &HTTPResponseContainer{Container: &ct, Response: r}, nil
 }
+
+// asContainer converts a [ucan.Container] to a [*container.Container], so that
+// it may be encoded.
+func asContainer(c ucan.Container) *container.Container {
+	ct, ok := c.(*container.Container)
+	if !ok {
+		ct = container.New(
+			container.WithInvocations(c.Invocations()...),
+			container.WithDelegations(c.Delegations()...),
+			container.WithReceipts(c.Receipts()...),
+		)
+	}
+	return ct
+}
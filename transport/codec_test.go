@@ -11,6 +11,7 @@ import (
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/result"
 	"github.com/alanshaw/ucantone/testutil"
 	"github.com/alanshaw/ucantone/transport"
@@ -82,6 +83,24 @@ func TestHTTPInboundCodec(t *testing.T) {
 		require.ErrorContains(t, err, "invalid content type")
 	})
 
+	t.Run("decode exceeds limits", func(t *testing.T) {
+		ct := container.New(container.WithDelegations(del), container.WithInvocations(inv))
+
+		ctBytes, err := container.Encode(container.Raw, ct)
+		require.NoError(t, err)
+
+		r := http.Request{
+			Header: http.Header{},
+			Body:   io.NopCloser(bytes.NewReader(ctBytes[1:])),
+		}
+		r.Header.Set("Content-Type", dagcbor.ContentType)
+
+		codec := transport.NewHTTPInboundCodec(transport.WithLimits(limits.Limits{MaxTokens: 1}))
+		_, err = codec.Decode(&r)
+		require.Error(t, err)
+		require.ErrorContains(t, err, limits.TokensLimit)
+	})
+
 	t.Run("decode body read error", func(t *testing.T) {
 		r := http.Request{
 			Header: http.Header{},
@@ -178,6 +197,31 @@ func TestHTTPOutboundCodec(t *testing.T) {
 		require.Equal(t, rec.Link(), dct.Receipts()[0].Link())
 	})
 
+	t.Run("decode exceeds response limits", func(t *testing.T) {
+		ct := container.New(container.WithDelegations(del), container.WithReceipts(rec))
+
+		ctBytes, err := container.Encode(container.Raw, ct)
+		require.NoError(t, err)
+
+		newResponse := func() *http.Response {
+			r := http.Response{
+				Header: http.Header{},
+				Body:   io.NopCloser(bytes.NewReader(ctBytes[1:])),
+			}
+			r.Header.Set("Content-Type", dagcbor.ContentType)
+			return &r
+		}
+
+		codec := transport.NewHTTPOutboundCodec(transport.WithResponseLimits(limits.Limits{MaxTokens: 1}))
+		_, err = codec.Decode(newResponse())
+		require.ErrorContains(t, err, limits.TokensLimit)
+
+		codec = transport.NewHTTPOutboundCodec(transport.WithResponseLimits(limits.Unlimited))
+		dct, err := codec.Decode(newResponse())
+		require.NoError(t, err)
+		require.Len(t, dct.Receipts(), 1)
+	})
+
 	t.Run("decode invalid content type", func(t *testing.T) {
 		ct := container.New(container.WithReceipts(rec))
 
@@ -218,4 +262,33 @@ func TestHTTPOutboundCodec(t *testing.T) {
 		require.Error(t, err)
 		require.ErrorContains(t, err, "unmarshaling response")
 	})
+
+	t.Run("decode error response", func(t *testing.T) {
+		resp, err := transport.DefaultHTTPInboundCodec.EncodeError(
+			http.StatusBadRequest,
+			transport.NewMalformedContainerError("bad container"),
+		)
+		require.NoError(t, err)
+		_, err = transport.DefaultHTTPOutboundCodec.Decode(resp)
+		var herr *transport.HTTPError
+		require.True(t, errors.As(err, &herr))
+		require.Equal(t, http.StatusBadRequest, herr.StatusCode)
+		require.Equal(t, transport.MalformedContainerErrorName, herr.Name())
+		require.Equal(t, "bad container", herr.Message)
+	})
+
+	t.Run("decode plain text error response", func(t *testing.T) {
+		r := http.Response{
+			StatusCode: http.StatusBadGateway,
+			Header:     http.Header{},
+			Body:       io.NopCloser(bytes.NewReader([]byte("upstream unavailable\n"))),
+		}
+		r.Header.Set("Content-Type", "text/plain")
+		_, err := transport.DefaultHTTPOutboundCodec.Decode(&r)
+		var herr *transport.HTTPError
+		require.True(t, errors.As(err, &herr))
+		require.Equal(t, http.StatusBadGateway, herr.StatusCode)
+		require.Equal(t, transport.HTTPErrorName, herr.Name())
+		require.Equal(t, "upstream unavailable", herr.Message)
+	})
 }
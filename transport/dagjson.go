@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld/codec/dagjson"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+)
+
+var (
+	DefaultHTTPDagJSONInboundCodec  = &HTTPDagJSONInboundCodec{}
+	DefaultHTTPDagJSONOutboundCodec = &HTTPDagJSONOutboundCodec{}
+)
+
+// HTTPDagJSONInboundCodec decodes requests and encodes responses with a
+// DAG-JSON encoded container in the body.
+type HTTPDagJSONInboundCodec struct {
+	limits limits.Limits
+}
+
+// NewHTTPDagJSONInboundCodec creates a new codec for decoding DAG-JSON
+// requests and encoding DAG-JSON responses sent over HTTP.
+func NewHTTPDagJSONInboundCodec(options ...HTTPInboundCodecOption) *HTTPDagJSONInboundCodec {
+	cfg := newInboundCodecConfig(options)
+	return &HTTPDagJSONInboundCodec{limits: cfg.limits}
+}
+
+var _ InboundCodec[*http.Request, *http.Response] = (*HTTPDagJSONInboundCodec)(nil)
+
+func (h *HTTPDagJSONInboundCodec) Decode(r *http.Request) (ucan.Container, error) {
+	if mediaType(r.Header.Get("Content-Type")) != dagjson.ContentType {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), dagjson.ContentType)
+	}
+	ct := container.Container{}
+	if err := ct.UnmarshalDagJSONWithLimits(r.Body, h.limits); err != nil {
+		return nil, newDecodeError("unmarshaling request container", err)
+	}
+	return &ct, nil
+}
+
+func (h *HTTPDagJSONInboundCodec) Encode(c ucan.Container) (*http.Response, error) {
+	ct := asContainer(c)
+	r, w := io.Pipe()
+	go func() {
+		err := ct.MarshalDagJSON(w)
+		w.CloseWithError(err)
+	}()
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       r,
+		Header:     http.Header{},
+	}
+	resp.Header.Set("Content-Type", dagjson.ContentType)
+	return resp, nil
+}
+
+var _ HTTPErrorEncoder = (*HTTPDagJSONInboundCodec)(nil)
+
+// EncodeError creates an error response with the DAG-JSON encoded error in
+// the body.
+func (h *HTTPDagJSONInboundCodec) EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error) {
+	return encodeErrorResponse(statusCode, dagjson.ContentType, err.MarshalDagJSON)
+}
+
+// HTTPDagJSONOutboundCodec encodes requests and decodes responses with a
+// DAG-JSON encoded container in the body.
+type HTTPDagJSONOutboundCodec struct {
+	limits limits.Limits
+}
+
+// NewHTTPDagJSONOutboundCodec creates a new codec for encoding DAG-JSON
+// requests and decoding DAG-JSON responses sent over HTTP.
+func NewHTTPDagJSONOutboundCodec(options ...HTTPOutboundCodecOption) *HTTPDagJSONOutboundCodec {
+	cfg := newOutboundCodecConfig(options)
+	return &HTTPDagJSONOutboundCodec{limits: cfg.limits}
+}
+
+var _ OutboundCodec[*http.Request, *http.Response] = (*HTTPDagJSONOutboundCodec)(nil)
+
+func (h *HTTPDagJSONOutboundCodec) Encode(c ucan.Container) (*http.Request, error) {
+	ct := asContainer(c)
+	r, w := io.Pipe()
+	go func() {
+		err := ct.MarshalDagJSON(w)
+		w.CloseWithError(err)
+	}()
+	req := &http.Request{
+		Method: http.MethodPost,
+		Body:   r,
+		Header: http.Header{},
+	}
+	req.Header.Set("Content-Type", dagjson.ContentType)
+	req.Header.Set("Accept", dagjson.ContentType)
+	return req, nil
+}
+
+// Decode decodes the response container. It returns an [*HTTPError] if the
+// response has an error status.
+func (h *HTTPDagJSONOutboundCodec) Decode(r *http.Response) (ucan.Container, error) {
+	if r.StatusCode >= http.StatusBadRequest {
+		return nil, decodeErrorResponse(r, dagjson.ContentType, func(m *edm.ErrorModel, r io.Reader) error {
+			return m.UnmarshalDagJSON(r)
+		})
+	}
+	if mediaType(r.Header.Get("Content-Type")) != dagjson.ContentType {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), dagjson.ContentType)
+	}
+	ct := container.Container{}
+	if err := ct.UnmarshalDagJSONWithLimits(r.Body, h.limits); err != nil {
+		return nil, fmt.Errorf("unmarshaling response container: %w", err)
+	}
+	return &HTTPResponseContainer{Container: &ct, Response: r}, nil
+}
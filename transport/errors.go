@@ -0,0 +1,132 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+)
+
+const (
+	UnsupportedMediaTypeErrorName = "UnsupportedMediaType"
+	NotAcceptableErrorName        = "NotAcceptable"
+)
+
+// NewUnsupportedMediaTypeError creates an error indicating the content type of
+// a message cannot be decoded.
+func NewUnsupportedMediaTypeError(contentType string, supported ...string) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: UnsupportedMediaTypeErrorName,
+		Message:   fmt.Sprintf("invalid content type %q, expected %s", contentType, quoteAll(supported)),
+	}
+}
+
+// NewNotAcceptableError creates an error indicating none of the media types
+// accepted by a client can be encoded.
+func NewNotAcceptableError(accept string, supported ...string) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: NotAcceptableErrorName,
+		Message:   fmt.Sprintf("cannot encode acceptable media type %q, expected %s", accept, quoteAll(supported)),
+	}
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", v))
+	}
+	return strings.Join(quoted, " or ")
+}
+
+const (
+	MalformedContainerErrorName = "MalformedContainer"
+	// InternalErrorName is the name of errors without a name, encoded in error
+	// responses.
+	InternalErrorName = "InternalError"
+	// HTTPErrorName is the name of errors decoded from error responses that do
+	// not have an encoded error body.
+	HTTPErrorName = "HTTPError"
+)
+
+// NewMalformedContainerError creates an error indicating a container could not
+// be decoded.
+func NewMalformedContainerError(message string) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: MalformedContainerErrorName,
+		Message:   message,
+	}
+}
+
+// newDecodeError classifies an error decoding a request container. Limit
+// exceeded errors are wrapped, all others become malformed container errors.
+func newDecodeError(message string, err error) error {
+	var model edm.ErrorModel
+	if errors.As(err, &model) && model.Name() == limits.ExceededErrorName {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+	return NewMalformedContainerError(fmt.Sprintf("%s: %s", message, err))
+}
+
+// HTTPErrorEncoder is implemented by inbound HTTP codecs that can encode an
+// error response, so that clients may decode it with the same codec.
+type HTTPErrorEncoder interface {
+	EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error)
+}
+
+// HTTPError is an error response received from a UCAN HTTP server.
+type HTTPError struct {
+	StatusCode int
+	ErrorName  string
+	Message    string
+}
+
+func (e *HTTPError) Name() string {
+	return e.ErrorName
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.ErrorName, e.StatusCode, e.Message)
+}
+
+// maxErrorBodySize is the maximum number of bytes of an error response body
+// that are read.
+const maxErrorBodySize = 64 << 10
+
+// encodeErrorResponse creates an error response with the error encoded in the
+// body.
+func encodeErrorResponse(statusCode int, contentType string, marshal func(io.Writer) error) (*http.Response, error) {
+	var buf bytes.Buffer
+	if err := marshal(&buf); err != nil {
+		return nil, fmt.Errorf("encoding error response: %w", err)
+	}
+	resp := &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(&buf),
+		Header:     http.Header{},
+	}
+	resp.Header.Set("Content-Type", contentType)
+	return resp, nil
+}
+
+// decodeErrorResponse returns an [*HTTPError] for the error response. The
+// error is decoded from the body if it has the passed content type, otherwise
+// the body is used as the message. The body is closed.
+func decodeErrorResponse(r *http.Response, contentType string, unmarshal func(*edm.ErrorModel, io.Reader) error) error {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxErrorBodySize))
+	if err != nil {
+		return fmt.Errorf("reading error response (%d): %w", r.StatusCode, err)
+	}
+	if mediaType(r.Header.Get("Content-Type")) == contentType {
+		var model edm.ErrorModel
+		if err := unmarshal(&model, bytes.NewReader(body)); err == nil {
+			return &HTTPError{StatusCode: r.StatusCode, ErrorName: model.Name(), Message: model.Message}
+		}
+	}
+	return &HTTPError{StatusCode: r.StatusCode, ErrorName: HTTPErrorName, Message: strings.TrimSpace(string(body))}
+}
@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+)
+
+const (
+	// DefaultContainerHeader is the header used by the header codecs when none
+	// is configured.
+	DefaultContainerHeader = "Authorization"
+	// AuthorizationScheme is the scheme of the Authorization header value that
+	// precedes the encoded container e.g. "UCAN <container>".
+	AuthorizationScheme = "UCAN"
+)
+
+// BodyOutboundCodec is an [OutboundCodec] that carries the container outside
+// of the request body, leaving the body free for an application payload.
+type BodyOutboundCodec[Req Request, Res Response] interface {
+	OutboundCodec[Req, Res]
+	// EncodeWithBody encodes the container and sets the request body.
+	EncodeWithBody(ucan.Container, io.Reader) (Req, error)
+}
+
+// HTTPRequestContainer is the container decoded by a codec that does not
+// read the request body. The body is available to handlers via
+// [execution.Request].
+type HTTPRequestContainer struct {
+	ucan.Container
+	Request *http.Request
+}
+
+// HTTPHeaderCodecOption is an option configuring an [HTTPHeaderInboundCodec]
+// or an [HTTPHeaderOutboundCodec].
+type HTTPHeaderCodecOption func(cfg *headerCodecConfig)
+
+type headerCodecConfig struct {
+	header string
+	codec  byte
+	limits limits.Limits
+}
+
+// WithHeaderName configures the HTTP header the container is carried in. The
+// default is [DefaultContainerHeader], in which case the container is
+// preceded by the [AuthorizationScheme]. Other headers hold only the container.
+func WithHeaderName(name string) HTTPHeaderCodecOption {
+	return func(cfg *headerCodecConfig) {
+		cfg.header = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithHeaderEncoding configures the codec used to encode the container e.g.
+// [container.Base64url]. The default is [container.Base64Gzip]. Only base64
+// codecs may be used in a header. Containers in any codec are decoded.
+func WithHeaderEncoding(codec byte) HTTPHeaderCodecOption {
+	return func(cfg *headerCodecConfig) {
+		cfg.codec = codec
+	}
+}
+
+// WithHeaderLimits configures the resource limits enforced when decoding
+// containers: the request header container by an [HTTPHeaderInboundCodec], or
+// the response container by an [HTTPHeaderOutboundCodec]. Zero values in the
+// limits are replaced by the defaults.
+func WithHeaderLimits(l limits.Limits) HTTPHeaderCodecOption {
+	return func(cfg *headerCodecConfig) {
+		cfg.limits = l
+	}
+}
+
+func newHeaderCodecConfig(options []HTTPHeaderCodecOption) headerCodecConfig {
+	cfg := headerCodecConfig{header: DefaultContainerHeader, codec: container.Base64Gzip}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// encode returns the header value for the container.
+func (cfg headerCodecConfig) encode(c ucan.Container) (string, error) {
+	switch cfg.codec {
+	case container.Base64, container.Base64url, container.Base64Gzip, container.Base64urlGzip:
+	default:
+		return "", fmt.Errorf("codec %s cannot be used in a header", container.FormatCodec(cfg.codec))
+	}
+	b, err := container.Encode(cfg.codec, c)
+	if err != nil {
+		return "", err
+	}
+	if cfg.header == DefaultContainerHeader {
+		return AuthorizationScheme + " " + string(b), nil
+	}
+	return string(b), nil
+}
+
+// decode decodes the container in the header value.
+func (cfg headerCodecConfig) decode(header http.Header) (*container.Container, error) {
+	value := header.Get(cfg.header)
+	if value == "" {
+		return nil, fmt.Errorf("missing %s header", cfg.header)
+	}
+	if cfg.header == DefaultContainerHeader {
+		scheme, rest, ok := strings.Cut(value, " ")
+		if !ok || !strings.EqualFold(scheme, AuthorizationScheme) {
+			return nil, fmt.Errorf("invalid %s header, expected %q scheme", cfg.header, AuthorizationScheme)
+		}
+		value = strings.TrimSpace(rest)
+	}
+
+	r, err := container.NewReader(strings.NewReader(value), container.WithLimits(cfg.limits))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var invs []ucan.Invocation
+	var dlgs []ucan.Delegation
+	var rcpts []ucan.Receipt
+	for {
+		tkn, err := r.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		switch tkn := tkn.(type) {
+		case ucan.Delegation:
+			dlgs = append(dlgs, tkn)
+		case ucan.Receipt:
+			rcpts = append(rcpts, tkn)
+		case ucan.Invocation:
+			invs = append(invs, tkn)
+		}
+	}
+	return container.New(
+		container.WithInvocations(invs...),
+		container.WithDelegations(dlgs...),
+		container.WithReceipts(rcpts...),
+	), nil
+}
+
+// HTTPHeaderInboundCodec decodes requests that carry the container in a
+// header, for example "Authorization: UCAN <container>", leaving the body for
+// handlers. Responses are encoded as a DAG-CBOR body, the same as
+// [HTTPInboundCodec].
+type HTTPHeaderInboundCodec struct {
+	cfg headerCodecConfig
+}
+
+// NewHTTPHeaderInboundCodec creates a new codec for decoding requests that
+// carry the container in a header.
+func NewHTTPHeaderInboundCodec(options ...HTTPHeaderCodecOption) *HTTPHeaderInboundCodec {
+	return &HTTPHeaderInboundCodec{cfg: newHeaderCodecConfig(options)}
+}
+
+var _ InboundCodec[*http.Request, *http.Response] = (*HTTPHeaderInboundCodec)(nil)
+
+// Decode decodes the container in the request header. The request body is not
+// read, it is returned in an [HTTPRequestContainer].
+func (h *HTTPHeaderInboundCodec) Decode(r *http.Request) (ucan.Container, error) {
+	ct, err := h.cfg.decode(r.Header)
+	if err != nil {
+		return nil, newDecodeError("decoding request header container", err)
+	}
+	return &HTTPRequestContainer{Container: ct, Request: r}, nil
+}
+
+func (h *HTTPHeaderInboundCodec) Encode(c ucan.Container) (*http.Response, error) {
+	return DefaultHTTPInboundCodec.Encode(c)
+}
+
+var _ HTTPErrorEncoder = (*HTTPHeaderInboundCodec)(nil)
+
+func (h *HTTPHeaderInboundCodec) EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error) {
+	return DefaultHTTPInboundCodec.EncodeError(statusCode, err)
+}
+
+// HTTPHeaderOutboundCodec encodes requests that carry the container in a
+// header, for example "Authorization: UCAN <container>", so that the body may
+// be used for an application payload. Responses are decoded from a DAG-CBOR
+// body, the same as [HTTPOutboundCodec].
+type HTTPHeaderOutboundCodec struct {
+	cfg headerCodecConfig
+}
+
+// NewHTTPHeaderOutboundCodec creates a new codec for encoding requests that
+// carry the container in a header.
+func NewHTTPHeaderOutboundCodec(options ...HTTPHeaderCodecOption) *HTTPHeaderOutboundCodec {
+	return &HTTPHeaderOutboundCodec{cfg: newHeaderCodecConfig(options)}
+}
+
+var _ BodyOutboundCodec[*http.Request, *http.Response] = (*HTTPHeaderOutboundCodec)(nil)
+
+func (h *HTTPHeaderOutboundCodec) Encode(c ucan.Container) (*http.Request, error) {
+	return h.EncodeWithBody(c, nil)
+}
+
+func (h *HTTPHeaderOutboundCodec) EncodeWithBody(c ucan.Container, body io.Reader) (*http.Request, error) {
+	value, err := h.cfg.encode(c)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request header container: %w", err)
+	}
+	req := &http.Request{
+		Method: http.MethodPost,
+		Body:   http.NoBody,
+		Header: http.Header{},
+	}
+	if body != nil {
+		rc, ok := body.(io.ReadCloser)
+		if !ok {
+			rc = io.NopCloser(body)
+		}
+		req.Body = rc
+	}
+	req.Header.Set(h.cfg.header, value)
+	return req, nil
+}
+
+func (h *HTTPHeaderOutboundCodec) Decode(r *http.Response) (ucan.Container, error) {
+	return NewHTTPOutboundCodec(WithResponseLimits(h.cfg.limits)).Decode(r)
+}
@@ -0,0 +1,96 @@
+package transport_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHeaderCodec(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	cmd, err := command.Parse("/console/log")
+	require.NoError(t, err)
+
+	del, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+
+	inv, err := invocation.Invoke(bob, alice, cmd, datamodel.Map{"message": "test"}, invocation.WithAudience(service))
+	require.NoError(t, err)
+
+	ct := container.New(container.WithDelegations(del), container.WithInvocations(inv))
+
+	t.Run("authorization header", func(t *testing.T) {
+		req, err := transport.NewHTTPHeaderOutboundCodec().EncodeWithBody(ct, strings.NewReader("payload"))
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(req.Header.Get("Authorization"), "UCAN "))
+
+		dct, err := transport.NewHTTPHeaderInboundCodec().Decode(req)
+		require.NoError(t, err)
+		require.Len(t, dct.Invocations(), 1)
+		require.Len(t, dct.Delegations(), 1)
+		require.Equal(t, inv.Link(), dct.Invocations()[0].Link())
+		require.Equal(t, del.Link(), dct.Delegations()[0].Link())
+
+		hrc, ok := dct.(*transport.HTTPRequestContainer)
+		require.True(t, ok)
+		body, err := io.ReadAll(hrc.Request.Body)
+		require.NoError(t, err)
+		require.Equal(t, "payload", string(body))
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		opts := []transport.HTTPHeaderCodecOption{
+			transport.WithHeaderName("x-ucan-container"),
+			transport.WithHeaderEncoding(container.Base64url),
+		}
+		req, err := transport.NewHTTPHeaderOutboundCodec(opts...).Encode(ct)
+		require.NoError(t, err)
+		require.Empty(t, req.Header.Get("Authorization"))
+		require.Equal(t, container.Base64url, req.Header.Get("X-Ucan-Container")[0])
+
+		dct, err := transport.NewHTTPHeaderInboundCodec(opts...).Decode(req)
+		require.NoError(t, err)
+		require.Len(t, dct.Invocations(), 1)
+	})
+
+	t.Run("missing header", func(t *testing.T) {
+		req := &http.Request{Header: http.Header{}}
+		_, err := transport.NewHTTPHeaderInboundCodec().Decode(req)
+		require.ErrorContains(t, err, "missing Authorization header")
+	})
+
+	t.Run("invalid scheme", func(t *testing.T) {
+		req := &http.Request{Header: http.Header{}}
+		req.Header.Set("Authorization", "Bearer token")
+		_, err := transport.NewHTTPHeaderInboundCodec().Decode(req)
+		require.ErrorContains(t, err, "expected \"UCAN\" scheme")
+	})
+
+	t.Run("exceeds limits", func(t *testing.T) {
+		req, err := transport.NewHTTPHeaderOutboundCodec().Encode(ct)
+		require.NoError(t, err)
+		codec := transport.NewHTTPHeaderInboundCodec(transport.WithHeaderLimits(limits.Limits{MaxTokens: 1}))
+		_, err = codec.Decode(req)
+		require.ErrorContains(t, err, limits.TokensLimit)
+	})
+
+	t.Run("raw encoding", func(t *testing.T) {
+		codec := transport.NewHTTPHeaderOutboundCodec(transport.WithHeaderEncoding(container.Raw))
+		_, err := codec.Encode(ct)
+		require.Error(t, err)
+	})
+}
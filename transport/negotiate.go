@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/codec/dagjson"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// HTTPNegotiatingInboundCodec decodes requests in DAG-CBOR or DAG-JSON,
+// according to the Content-Type header, and encodes responses in the media
+// type preferred by the Accept header. When there is no Accept header, the
+// response is encoded in the same media type as the request.
+type HTTPNegotiatingInboundCodec struct {
+	codecs map[string]InboundCodec[*http.Request, *http.Response]
+}
+
+// NewHTTPNegotiatingInboundCodec creates a new codec that negotiates the
+// encoding of requests and responses sent over HTTP.
+func NewHTTPNegotiatingInboundCodec(options ...HTTPInboundCodecOption) *HTTPNegotiatingInboundCodec {
+	return &HTTPNegotiatingInboundCodec{
+		codecs: map[string]InboundCodec[*http.Request, *http.Response]{
+			dagcbor.ContentType: NewHTTPInboundCodec(options...),
+			dagjson.ContentType: NewHTTPDagJSONInboundCodec(options...),
+		},
+	}
+}
+
+// supportedMediaTypes are the media types that can be negotiated, in order of
+// preference.
+var supportedMediaTypes = []string{dagcbor.ContentType, dagjson.ContentType}
+
+var _ NegotiatingInboundCodec[*http.Request, *http.Response] = (*HTTPNegotiatingInboundCodec)(nil)
+
+// Negotiate chooses the codecs for the request and the response. It returns
+// an unsupported media type error if the request content type is not
+// supported, or a not acceptable error if none of the media types in the Accept
+// header are supported. A not acceptable error is returned along with the codec
+// for the request media type, so that the error may be encoded in it.
+func (h *HTTPNegotiatingInboundCodec) Negotiate(r *http.Request) (InboundCodec[*http.Request, *http.Response], error) {
+	reqType := mediaType(r.Header.Get("Content-Type"))
+	decoder, ok := h.codecs[reqType]
+	if !ok {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), supportedMediaTypes...)
+	}
+
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if accept == "" {
+		return decoder, nil
+	}
+	// prefer the request media type when the client has no preference
+	preference := []string{reqType}
+	for _, t := range supportedMediaTypes {
+		if t != reqType {
+			preference = append(preference, t)
+		}
+	}
+	resType := negotiate(accept, preference)
+	if resType == "" {
+		// streamed responses are not encoded by the codec, but errors are, in the
+		// request media type
+		if AcceptsStream(r) {
+			return decoder, nil
+		}
+		return decoder, NewNotAcceptableError(accept, supportedMediaTypes...)
+	}
+	return &negotiatedCodec{decoder: decoder, encoder: h.codecs[resType]}, nil
+}
+
+// Decode decodes the request using the codec for its content type.
+func (h *HTTPNegotiatingInboundCodec) Decode(r *http.Request) (ucan.Container, error) {
+	codec, ok := h.codecs[mediaType(r.Header.Get("Content-Type"))]
+	if !ok {
+		return nil, NewUnsupportedMediaTypeError(r.Header.Get("Content-Type"), supportedMediaTypes...)
+	}
+	return codec.Decode(r)
+}
+
+// Encode encodes the response as DAG-CBOR. Use [HTTPNegotiatingInboundCodec.Negotiate] to choose the
+// response encoding from the request.
+func (h *HTTPNegotiatingInboundCodec) Encode(c ucan.Container) (*http.Response, error) {
+	return h.codecs[dagcbor.ContentType].Encode(c)
+}
+
+var _ HTTPErrorEncoder = (*HTTPNegotiatingInboundCodec)(nil)
+
+// EncodeError creates an error response with the DAG-CBOR encoded error in
+// the body. It is used only when the request media type is not supported,
+// otherwise errors are encoded by the codec returned from
+// [HTTPNegotiatingInboundCodec.Negotiate], in a media type of the request.
+func (h *HTTPNegotiatingInboundCodec) EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error) {
+	return DefaultHTTPInboundCodec.EncodeError(statusCode, err)
+}
+
+type negotiatedCodec struct {
+	decoder InboundCodec[*http.Request, *http.Response]
+	encoder InboundCodec[*http.Request, *http.Response]
+}
+
+func (n *negotiatedCodec) Decode(r *http.Request) (ucan.Container, error) {
+	return n.decoder.Decode(r)
+}
+
+func (n *negotiatedCodec) Encode(c ucan.Container) (*http.Response, error) {
+	return n.encoder.Encode(c)
+}
+
+func (n *negotiatedCodec) EncodeError(statusCode int, err edm.ErrorModel) (*http.Response, error) {
+	if enc, ok := n.encoder.(HTTPErrorEncoder); ok {
+		return enc.EncodeError(statusCode, err)
+	}
+	return DefaultHTTPInboundCodec.EncodeError(statusCode, err)
+}
+
+// negotiate returns the media type from the list of preferences with the
+// highest quality in the Accept header value, or an empty string if none are
+// acceptable. When qualities are equal, earlier preferences are chosen.
+func negotiate(accept string, preference []string) string {
+	type mediaRange struct {
+		typ     string
+		quality float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		typ, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ, q})
+	}
+
+	best, bestQuality := "", 0.0
+	for _, t := range preference {
+		// the quality of a media type is that of the most specific matching range
+		quality, specificity := 0.0, -1
+		for _, r := range ranges {
+			s := matchMediaRange(r.typ, t)
+			if s > specificity {
+				quality, specificity = r.quality, s
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = t, quality
+		}
+	}
+	return best
+}
+
+// matchMediaRange returns the specificity of the media range if it matches the
+// media type: 2 for an exact match, 1 for "type/*" and 0 for "*/*". It returns
+// -1 if the range does not match.
+func matchMediaRange(mediaRange, mediaType string) int {
+	if mediaRange == mediaType {
+		return 2
+	}
+	if mediaRange == "*/*" {
+		return 0
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	if ok && strings.HasPrefix(mediaType, prefix+"/") {
+		return 1
+	}
+	return -1
+}
+
+// mediaType returns the media type of a Content-Type header value, without
+// parameters. It returns the value unchanged if it cannot be parsed.
+func mediaType(contentType string) string {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return typ
+}
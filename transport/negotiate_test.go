@@ -0,0 +1,137 @@
+package transport_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/codec/dagjson"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDagJSONCodec(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/console/log"))(t)
+
+	inv, err := invocation.Invoke(alice, alice, cmd, datamodel.Map{"message": "test"})
+	require.NoError(t, err)
+
+	req, err := transport.DefaultHTTPDagJSONOutboundCodec.Encode(container.New(container.WithInvocations(inv)))
+	require.NoError(t, err)
+	require.Equal(t, dagjson.ContentType, req.Header.Get("Content-Type"))
+
+	ct, err := transport.DefaultHTTPDagJSONInboundCodec.Decode(req)
+	require.NoError(t, err)
+	require.Len(t, ct.Invocations(), 1)
+	require.Equal(t, inv.Link(), ct.Invocations()[0].Link())
+
+	resp, err := transport.DefaultHTTPDagJSONInboundCodec.Encode(ct)
+	require.NoError(t, err)
+	require.Equal(t, dagjson.ContentType, resp.Header.Get("Content-Type"))
+
+	ct, err = transport.DefaultHTTPDagJSONOutboundCodec.Decode(resp)
+	require.NoError(t, err)
+	require.Len(t, ct.Invocations(), 1)
+	require.Equal(t, inv.Link(), ct.Invocations()[0].Link())
+}
+
+func TestHTTPNegotiatingInboundCodec(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/console/log"))(t)
+
+	inv, err := invocation.Invoke(alice, alice, cmd, datamodel.Map{"message": "test"})
+	require.NoError(t, err)
+	ct := container.New(container.WithInvocations(inv))
+
+	var cborBody, jsonBody bytes.Buffer
+	require.NoError(t, ct.MarshalCBOR(&cborBody))
+	require.NoError(t, ct.MarshalDagJSON(&jsonBody))
+
+	newRequest := func(contentType, accept string) *http.Request {
+		body := cborBody.Bytes()
+		if contentType != dagcbor.ContentType {
+			body = jsonBody.Bytes()
+		}
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return req
+	}
+
+	codec := transport.NewHTTPNegotiatingInboundCodec()
+
+	negotiated := []struct {
+		name        string
+		contentType string
+		accept      string
+		response    string
+	}{
+		{"cbor without accept", dagcbor.ContentType, "", dagcbor.ContentType},
+		{"json without accept", dagjson.ContentType, "", dagjson.ContentType},
+		{"json with parameters", dagjson.ContentType + "; charset=utf-8", "", dagjson.ContentType},
+		{"accept other codec", dagcbor.ContentType, dagjson.ContentType, dagjson.ContentType},
+		{"accept any", dagjson.ContentType, "*/*", dagjson.ContentType},
+		{"accept by quality", dagcbor.ContentType, dagcbor.ContentType + ";q=0.5, " + dagjson.ContentType, dagjson.ContentType},
+		{"accept type wildcard", dagjson.ContentType, "text/html, application/*;q=0.8", dagjson.ContentType},
+	}
+	for _, tc := range negotiated {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := codec.Negotiate(newRequest(tc.contentType, tc.accept))
+			require.NoError(t, err)
+
+			dct, err := c.Decode(newRequest(tc.contentType, tc.accept))
+			require.NoError(t, err)
+			require.Len(t, dct.Invocations(), 1)
+
+			resp, err := c.Encode(dct)
+			require.NoError(t, err)
+			require.Equal(t, tc.response, resp.Header.Get("Content-Type"))
+		})
+	}
+
+	requireErrorName := func(t *testing.T, err error, name string) {
+		t.Helper()
+		var named edm.ErrorModel
+		require.True(t, errors.As(err, &named))
+		require.Equal(t, name, named.Name())
+	}
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		_, err := codec.Negotiate(newRequest("text/plain", ""))
+		requireErrorName(t, err, transport.UnsupportedMediaTypeErrorName)
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		_, err := codec.Negotiate(newRequest(dagcbor.ContentType, "text/html"))
+		requireErrorName(t, err, transport.NotAcceptableErrorName)
+	})
+
+	t.Run("not acceptable error in request media type", func(t *testing.T) {
+		c, err := codec.Negotiate(newRequest(dagjson.ContentType, "text/html"))
+		requireErrorName(t, err, transport.NotAcceptableErrorName)
+		require.NotNil(t, c)
+
+		enc, ok := c.(transport.HTTPErrorEncoder)
+		require.True(t, ok)
+		resp, err := enc.EncodeError(http.StatusNotAcceptable, transport.NewNotAcceptableError("text/html"))
+		require.NoError(t, err)
+		require.Equal(t, dagjson.ContentType, resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("not acceptable with zero quality", func(t *testing.T) {
+		_, err := codec.Negotiate(newRequest(dagcbor.ContentType, "*/*;q=0"))
+		requireErrorName(t, err, transport.NotAcceptableErrorName)
+	})
+}
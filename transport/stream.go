@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/container"
+)
+
+// StreamContentType is the media type of a streamed response. The body is a
+// sequence of DAG-CBOR encoded containers, each holding the receipt for a task
+// and any additional tokens from its execution, sent as the task completes.
+const StreamContentType = "application/vnd.ucan.container-stream"
+
+// AcceptsStream reports whether the Accept header of the request explicitly
+// includes [StreamContentType].
+func AcceptsStream(r *http.Request) bool {
+	accept := strings.Join(r.Header.Values("Accept"), ",")
+	if accept == "" {
+		return false
+	}
+	// wildcards match any type, so check the stream type is listed explicitly
+	for _, part := range strings.Split(accept, ",") {
+		if mediaType(strings.TrimSpace(part)) == StreamContentType {
+			return negotiate(part, []string{StreamContentType}) != ""
+		}
+	}
+	return false
+}
+
+// HTTPStreamWriter writes containers to the body of a streamed response.
+type HTTPStreamWriter struct {
+	pw *io.PipeWriter
+}
+
+// NewHTTPStreamResponse creates a streamed response, and a writer for the
+// containers in its body. The writer must be closed once all the containers
+// have been written.
+func NewHTTPStreamResponse() (*http.Response, *HTTPStreamWriter) {
+	r, w := io.Pipe()
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       r,
+		Header:     http.Header{},
+	}
+	resp.Header.Set("Content-Type", StreamContentType)
+	return resp, &HTTPStreamWriter{pw: w}
+}
+
+// Write encodes the container to the response body. It blocks until the
+// container has been read, and returns an error if the body has been closed.
+func (w *HTTPStreamWriter) Write(c ucan.Container) error {
+	var buf bytes.Buffer
+	if err := asContainer(c).MarshalCBOR(&buf); err != nil {
+		return fmt.Errorf("encoding stream container: %w", err)
+	}
+	if _, err := w.pw.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing stream container: %w", err)
+	}
+	return nil
+}
+
+// Close ends the response body. If err is not nil, the reader of the body
+// receives the error instead of io.EOF.
+func (w *HTTPStreamWriter) Close(err error) error {
+	return w.pw.CloseWithError(err)
+}
+
+var _ StreamingOutboundCodec[*http.Request, *http.Response] = (*HTTPOutboundCodec)(nil)
+
+// EncodeStream encodes a request for a streamed response. If the server does
+// not support streaming, it may respond with a single container.
+func (h *HTTPOutboundCodec) EncodeStream(c ucan.Container) (*http.Request, error) {
+	req, err := h.Encode(c)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", StreamContentType+", "+dagcbor.ContentType+";q=0.9")
+	return req, nil
+}
+
+// DecodeStream returns an iterator over the containers in the response. A
+// response that is not streamed yields a single container. The response limits
+// apply to each container in the stream. The response body is closed when
+// iteration ends.
+func (h *HTTPOutboundCodec) DecodeStream(r *http.Response) iter.Seq2[ucan.Container, error] {
+	return func(yield func(ucan.Container, error) bool) {
+		if mediaType(r.Header.Get("Content-Type")) != StreamContentType || r.StatusCode >= http.StatusBadRequest {
+			ct, err := h.Decode(r)
+			if err == nil {
+				err = r.Body.Close()
+			}
+			yield(ct, err)
+			return
+		}
+
+		defer r.Body.Close()
+		br := bufio.NewReader(r.Body)
+		for {
+			if _, err := br.Peek(1); err != nil {
+				if !errors.Is(err, io.EOF) {
+					yield(nil, fmt.Errorf("reading stream container: %w", err))
+				}
+				return
+			}
+			ct := container.Container{}
+			if err := ct.UnmarshalCBORWithLimits(br, h.limits); err != nil {
+				yield(nil, fmt.Errorf("unmarshaling stream container: %w", err))
+				return
+			}
+			if !yield(&HTTPResponseContainer{Container: &ct, Response: r}, nil) {
+				return
+			}
+		}
+	}
+}
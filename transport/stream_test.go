@@ -0,0 +1,109 @@
+package transport_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/transport"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPStream(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/console/log"))(t)
+
+	var invs []ucan.Invocation
+	for range 3 {
+		inv, err := invocation.Invoke(alice, alice, cmd, datamodel.Map{"message": "test"})
+		require.NoError(t, err)
+		invs = append(invs, inv)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		resp, w := transport.NewHTTPStreamResponse()
+		require.Equal(t, transport.StreamContentType, resp.Header.Get("Content-Type"))
+		go func() {
+			for _, inv := range invs {
+				if err := w.Write(container.New(container.WithInvocations(inv))); err != nil {
+					w.Close(err)
+					return
+				}
+			}
+			w.Close(nil)
+		}()
+
+		var links []ucan.Link
+		for ct, err := range transport.DefaultHTTPOutboundCodec.DecodeStream(resp) {
+			require.NoError(t, err)
+			require.Len(t, ct.Invocations(), 1)
+			links = append(links, ct.Invocations()[0].Link())
+		}
+		require.Len(t, links, len(invs))
+		for i, inv := range invs {
+			require.Equal(t, inv.Link(), links[i])
+		}
+	})
+
+	t.Run("writer error", func(t *testing.T) {
+		resp, w := transport.NewHTTPStreamResponse()
+		go func() {
+			w.Write(container.New(container.WithInvocations(invs[0])))
+			w.Close(transport.NewMalformedContainerError("boom"))
+		}()
+
+		var n int
+		var last error
+		for _, err := range transport.DefaultHTTPOutboundCodec.DecodeStream(resp) {
+			if err != nil {
+				last = err
+				continue
+			}
+			n++
+		}
+		require.Equal(t, 1, n)
+		require.ErrorContains(t, last, "boom")
+	})
+
+	t.Run("response is not streamed", func(t *testing.T) {
+		resp, err := transport.DefaultHTTPInboundCodec.Encode(container.New(container.WithInvocations(invs...)))
+		require.NoError(t, err)
+
+		var n int
+		for ct, err := range transport.DefaultHTTPOutboundCodec.DecodeStream(resp) {
+			require.NoError(t, err)
+			require.Len(t, ct.Invocations(), len(invs))
+			n++
+		}
+		require.Equal(t, 1, n)
+	})
+
+	t.Run("accepts stream", func(t *testing.T) {
+		req, err := transport.DefaultHTTPOutboundCodec.EncodeStream(container.New(container.WithInvocations(invs[0])))
+		require.NoError(t, err)
+		require.True(t, transport.AcceptsStream(req))
+
+		testCases := map[string]bool{
+			"":                          false,
+			"*/*":                       false,
+			dagcbor.ContentType:         false,
+			transport.StreamContentType: true,
+			"application/*, " + transport.StreamContentType + ";q=0.5": true,
+			transport.StreamContentType + ";q=0":                       false,
+		}
+		for accept, expected := range testCases {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if accept != "" {
+				r.Header.Set("Accept", accept)
+			}
+			require.Equal(t, expected, transport.AcceptsStream(r), accept)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"iter"
 
 	"github.com/alanshaw/ucantone/ucan"
 )
@@ -18,11 +19,33 @@ type InboundCodec[Req Request, Res Response] interface {
 	Encode(ucan.Container) (Res, error)
 }
 
+// NegotiatingInboundCodec is an [InboundCodec] that chooses the codec used to
+// decode each request and encode its response, for example by HTTP content
+// negotiation.
+type NegotiatingInboundCodec[Req Request, Res Response] interface {
+	InboundCodec[Req, Res]
+	// Negotiate returns the codec to use for the request. If negotiation fails,
+	// a codec may be returned along with the error, which should be used to
+	// encode the error response.
+	Negotiate(Req) (InboundCodec[Req, Res], error)
+}
+
 type OutboundCodec[Req Request, Res Response] interface {
 	Encode(ucan.Container) (Req, error)
 	Decode(Res) (ucan.Container, error)
 }
 
+// StreamingOutboundCodec is an [OutboundCodec] that can request a streamed
+// response, where a container is received for each task as it is executed.
+type StreamingOutboundCodec[Req Request, Res Response] interface {
+	OutboundCodec[Req, Res]
+	// EncodeStream encodes a request for a streamed response.
+	EncodeStream(ucan.Container) (Req, error)
+	// DecodeStream returns an iterator over the containers in the response, in
+	// the order they are received.
+	DecodeStream(Res) iter.Seq2[ucan.Container, error]
+}
+
 type RoundTripper[Req Request, Res Response] interface {
 	RoundTrip(Req) (Res, error)
 }
@@ -0,0 +1,381 @@
+// Package car encodes UCAN tokens in the Content Addressable aRchive (CAR)
+// format, so they may be archived or shared with other IPFS tooling.
+//
+// The CAR has a single root, a block that indexes the delegations, invocations
+// and receipts it contains. Each token is stored as a block using its exact
+// encoded bytes, so CIDs are preserved. Since blocks are verified against
+// their CIDs, tokens are decoded leniently, and tokens with non-canonical
+// encodings are preserved too.
+//
+// https://ipld.io/specs/transport/car/
+package car
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	cdm "github.com/alanshaw/ucantone/ucan/car/datamodel"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	cid "github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/multiformats/go-varint"
+)
+
+const ContentType = "application/vnd.ipld.car"
+
+// v2Pragma identifies a CARv2. It is a CARv1 style header, {"version": 2}
+// prefixed by its varint length.
+var v2Pragma = []byte{0x0a, 0xa1, 0x67, 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x02}
+
+const v2PragmaSize = 11
+
+// v2HeaderSize is the size of the CARv2 header that follows the pragma:
+// characteristics (16 bytes), data offset, data size and index offset.
+const v2HeaderSize = 40
+
+// Option is an option configuring how a CAR is written.
+type Option func(cfg *writeConfig) error
+
+type writeConfig struct {
+	version uint64
+}
+
+// WithVersion configures the CAR format version to write, 1 or 2. The default
+// is version 1. Version 2 CARs are written without an index.
+func WithVersion(version uint64) Option {
+	return func(cfg *writeConfig) error {
+		if version != 1 && version != 2 {
+			return fmt.Errorf("unsupported CAR version: %d", version)
+		}
+		cfg.version = version
+		return nil
+	}
+}
+
+// Write encodes the tokens in the container as a CAR.
+func Write(w io.Writer, ct ucan.Container, options ...Option) error {
+	cfg := writeConfig{version: 1}
+	for _, opt := range options {
+		if err := opt(&cfg); err != nil {
+			return err
+		}
+	}
+	if cfg.version == 1 {
+		return writeV1(w, ct)
+	}
+
+	var data bytes.Buffer
+	if err := writeV1(&data, ct); err != nil {
+		return err
+	}
+	header := make([]byte, v2HeaderSize)
+	binary.LittleEndian.PutUint64(header[16:], v2PragmaSize+v2HeaderSize)
+	binary.LittleEndian.PutUint64(header[24:], uint64(data.Len()))
+	// header[32:] is the index offset, which is zero since there is no index
+	for _, b := range [][]byte{v2Pragma, header, data.Bytes()} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode the tokens in the container as a CAR.
+func Encode(ct ucan.Container, options ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Write(&buf, ct, options...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeV1(w io.Writer, ct ucan.Container) error {
+	var blocks []ucan.Token
+	index := cdm.IndexModel{}
+	for _, dlg := range ct.Delegations() {
+		index.Dlg = append(index.Dlg, dlg.Link())
+		blocks = append(blocks, dlg)
+	}
+	for _, inv := range ct.Invocations() {
+		index.Inv = append(index.Inv, inv.Link())
+		blocks = append(blocks, inv)
+	}
+	for _, rcpt := range ct.Receipts() {
+		index.Rcpt = append(index.Rcpt, rcpt.Link())
+		blocks = append(blocks, rcpt)
+	}
+
+	var root bytes.Buffer
+	if err := (&cdm.RootModel{Index1: index}).MarshalCBOR(&root); err != nil {
+		return fmt.Errorf("marshaling root block: %w", err)
+	}
+	rootLink, err := cid.V1Builder{
+		Codec:  dagcbor.Code,
+		MhType: multihash.SHA2_256,
+	}.Sum(root.Bytes())
+	if err != nil {
+		return fmt.Errorf("hashing root block: %w", err)
+	}
+
+	if err := writeHeader(w, &cdm.HeaderModel{Roots: []cid.Cid{rootLink}, Version: 1}); err != nil {
+		return err
+	}
+	if err := writeSection(w, rootLink, root.Bytes()); err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if err := writeSection(w, b.Link(), b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeader(w io.Writer, header *cdm.HeaderModel) error {
+	var buf bytes.Buffer
+	if err := header.MarshalCBOR(&buf); err != nil {
+		return fmt.Errorf("marshaling CAR header: %w", err)
+	}
+	if _, err := w.Write(varint.ToUvarint(uint64(buf.Len()))); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeSection(w io.Writer, link cid.Cid, data []byte) error {
+	cb := link.Bytes()
+	if _, err := w.Write(varint.ToUvarint(uint64(len(cb) + len(data)))); err != nil {
+		return err
+	}
+	if _, err := w.Write(cb); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// ReadOption is an option configuring how a CAR is read.
+type ReadOption func(cfg *readConfig)
+
+type readConfig struct {
+	limits limits.Limits
+}
+
+// WithLimits configures the resource limits enforced when reading a CAR. The
+// byte limit applies to the whole archive and the token limit to the number of
+// blocks it contains. Zero values in the limits are replaced by the defaults.
+// Pass [limits.Unlimited] to read a CAR from a trusted source.
+func WithLimits(l limits.Limits) ReadOption {
+	return func(cfg *readConfig) {
+		cfg.limits = l
+	}
+}
+
+// Read decodes a CARv1 or CARv2 of UCAN tokens, returning a container of the
+// tokens listed in the root block. An error is returned if a listed token is
+// missing or any block does not match its CID. The default limits are
+// enforced unless configured with [WithLimits].
+func Read(r io.Reader, options ...ReadOption) (*container.Container, error) {
+	cfg := readConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	l := cfg.limits.OrDefault()
+
+	br := bufio.NewReader(limits.NewReader(r, l.MaxBytes))
+	header, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	switch header.Version {
+	case 1:
+	case 2:
+		header, br, err = readV2Header(br)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported CAR version: %d", header.Version)
+	}
+	if len(header.Roots) != 1 {
+		return nil, fmt.Errorf("expected 1 CAR root, found %d", len(header.Roots))
+	}
+
+	blocks := map[cid.Cid][]byte{}
+	for {
+		link, data, err := readSection(br)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		// every block but the root is a token
+		if len(blocks) > l.MaxTokens {
+			return nil, limits.NewExceededError(limits.TokensLimit, int64(l.MaxTokens))
+		}
+		blocks[link] = data
+	}
+
+	rootBytes, ok := blocks[header.Roots[0]]
+	if !ok {
+		return nil, fmt.Errorf("missing root block: %s", header.Roots[0])
+	}
+	root := cdm.RootModel{}
+	if err := root.UnmarshalCBOR(bytes.NewReader(rootBytes)); err != nil {
+		return nil, fmt.Errorf("unmarshaling root block: %w", err)
+	}
+
+	var dlgs []ucan.Delegation
+	for _, link := range root.Index1.Dlg {
+		b, ok := blocks[link]
+		if !ok {
+			return nil, fmt.Errorf("missing delegation block: %s", link)
+		}
+		dlg, err := delegation.Decode(b, delegation.WithStrict(false), delegation.WithLimits(l))
+		if err != nil {
+			return nil, fmt.Errorf("decoding delegation %s: %w", link, err)
+		}
+		dlgs = append(dlgs, dlg)
+	}
+	var invs []ucan.Invocation
+	for _, link := range root.Index1.Inv {
+		b, ok := blocks[link]
+		if !ok {
+			return nil, fmt.Errorf("missing invocation block: %s", link)
+		}
+		inv, err := invocation.Decode(b, invocation.WithStrict(false), invocation.WithLimits(l))
+		if err != nil {
+			return nil, fmt.Errorf("decoding invocation %s: %w", link, err)
+		}
+		invs = append(invs, inv)
+	}
+	var rcpts []ucan.Receipt
+	for _, link := range root.Index1.Rcpt {
+		b, ok := blocks[link]
+		if !ok {
+			return nil, fmt.Errorf("missing receipt block: %s", link)
+		}
+		rcpt, err := receipt.Decode(b, invocation.WithStrict(false), invocation.WithLimits(l))
+		if err != nil {
+			return nil, fmt.Errorf("decoding receipt %s: %w", link, err)
+		}
+		rcpts = append(rcpts, rcpt)
+	}
+
+	return container.New(
+		container.WithDelegations(dlgs...),
+		container.WithInvocations(invs...),
+		container.WithReceipts(rcpts...),
+	), nil
+}
+
+// Decode a CARv1 or CARv2 of UCAN tokens. See [Read].
+func Decode(b []byte, options ...ReadOption) (*container.Container, error) {
+	return Read(bytes.NewReader(b), options...)
+}
+
+func readHeader(r *bufio.Reader) (*cdm.HeaderModel, error) {
+	size, err := varint.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading CAR header length: %w", err)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("invalid CAR header length: %d", size)
+	}
+	buf, err := readFull(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading CAR header: %w", err)
+	}
+	header := cdm.HeaderModel{}
+	if err := header.UnmarshalCBOR(bytes.NewReader(buf)); err != nil {
+		return nil, fmt.Errorf("unmarshaling CAR header: %w", err)
+	}
+	return &header, nil
+}
+
+// readV2Header reads the CARv2 header that follows the pragma and returns the
+// header of the inner CARv1 along with a reader limited to its sections.
+func readV2Header(r *bufio.Reader) (*cdm.HeaderModel, *bufio.Reader, error) {
+	buf := make([]byte, v2HeaderSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, nil, fmt.Errorf("reading CARv2 header: %w", err)
+	}
+	offset := binary.LittleEndian.Uint64(buf[16:])
+	size := binary.LittleEndian.Uint64(buf[24:])
+	if offset < v2PragmaSize+v2HeaderSize {
+		return nil, nil, fmt.Errorf("invalid CARv2 data offset: %d", offset)
+	}
+	if _, err := r.Discard(int(offset - v2PragmaSize - v2HeaderSize)); err != nil {
+		return nil, nil, fmt.Errorf("seeking CARv2 data: %w", err)
+	}
+	data := bufio.NewReader(io.LimitReader(r, int64(size)))
+	header, err := readHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Version != 1 {
+		return nil, nil, fmt.Errorf("unsupported CARv2 data version: %d", header.Version)
+	}
+	return header, data, nil
+}
+
+// readSection reads a block from the CAR, verifying the data matches the CID.
+// It returns [io.EOF] if there are no more sections.
+func readSection(r *bufio.Reader) (cid.Cid, []byte, error) {
+	size, err := varint.ReadUvarint(r)
+	if err != nil {
+		if err == io.EOF {
+			return cid.Undef, nil, io.EOF
+		}
+		return cid.Undef, nil, fmt.Errorf("reading section length: %w", err)
+	}
+	if size == 0 {
+		return cid.Undef, nil, fmt.Errorf("invalid section length: %d", size)
+	}
+	buf, err := readFull(r, size)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("reading section: %w", err)
+	}
+	n, link, err := cid.CidFromBytes(buf)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("decoding section CID: %w", err)
+	}
+	data := buf[n:]
+	sum, err := link.Prefix().Sum(data)
+	if err != nil {
+		return cid.Undef, nil, fmt.Errorf("hashing block %s: %w", link, err)
+	}
+	if !sum.Equals(link) {
+		return cid.Undef, nil, fmt.Errorf("block data does not match CID %s", link)
+	}
+	return link, data, nil
+}
+
+// readFull reads exactly size bytes. The buffer grows as the bytes are read,
+// so a length prefix larger than the data that follows it does not cause a
+// large allocation.
+func readFull(r io.Reader, size uint64) ([]byte, error) {
+	if size > math.MaxInt64 {
+		return nil, fmt.Errorf("length too large: %d", size)
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(size)); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
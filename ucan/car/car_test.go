@@ -0,0 +1,138 @@
+package car_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan/car"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	"github.com/multiformats/go-varint"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCAR(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+
+	inv, err := invocation.Invoke(bob, alice, cmd, testutil.RandomArgs(t), invocation.WithProofs(dlg.Link()))
+	require.NoError(t, err)
+
+	rcpt, err := receipt.Issue(service, inv.Task().Link(), result.OK[ipld.Map, ipld.Any](datamodel.Map{}))
+	require.NoError(t, err)
+
+	ct := container.New(
+		container.WithDelegations(dlg),
+		container.WithInvocations(inv),
+		container.WithReceipts(rcpt),
+	)
+
+	for _, version := range []uint64{1, 2} {
+		t.Run(fmt.Sprintf("roundtrip v%d", version), func(t *testing.T) {
+			b, err := car.Encode(ct, car.WithVersion(version))
+			require.NoError(t, err)
+
+			decoded, err := car.Decode(b)
+			require.NoError(t, err)
+
+			require.Len(t, decoded.Delegations(), 1)
+			require.Len(t, decoded.Invocations(), 1)
+			require.Len(t, decoded.Receipts(), 1)
+			require.Equal(t, dlg.Link(), decoded.Delegations()[0].Link())
+			require.Equal(t, inv.Link(), decoded.Invocations()[0].Link())
+			require.Equal(t, rcpt.Link(), decoded.Receipts()[0].Link())
+			require.Equal(t, dlg.Bytes(), decoded.Delegations()[0].Bytes())
+		})
+	}
+
+	t.Run("roundtrip non-canonical", func(t *testing.T) {
+		// the same delegation, with a non-minimal envelope array header
+		nonCanonical := append([]byte{0x98, 0x02}, dlg.Bytes()[1:]...)
+		decoded, err := delegation.Decode(nonCanonical, delegation.WithStrict(false))
+		require.NoError(t, err)
+
+		b, err := car.Encode(container.New(container.WithDelegations(decoded)))
+		require.NoError(t, err)
+
+		roundtrip, err := car.Decode(b)
+		require.NoError(t, err)
+		require.Len(t, roundtrip.Delegations(), 1)
+		require.Equal(t, decoded.Link(), roundtrip.Delegations()[0].Link())
+		require.Equal(t, nonCanonical, roundtrip.Delegations()[0].Bytes())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		b, err := car.Encode(container.New())
+		require.NoError(t, err)
+
+		decoded, err := car.Decode(b)
+		require.NoError(t, err)
+		require.Empty(t, decoded.Delegations())
+		require.Empty(t, decoded.Invocations())
+		require.Empty(t, decoded.Receipts())
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := car.Encode(ct, car.WithVersion(3))
+		require.ErrorContains(t, err, "unsupported CAR version")
+	})
+
+	t.Run("corrupt block", func(t *testing.T) {
+		b, err := car.Encode(ct)
+		require.NoError(t, err)
+
+		i := bytes.Index(b, inv.Bytes())
+		require.Greater(t, i, 0)
+		b[i+len(inv.Bytes())-1] ^= 0xff
+
+		_, err = car.Decode(b)
+		require.ErrorContains(t, err, "does not match CID")
+	})
+
+	t.Run("missing block", func(t *testing.T) {
+		b, err := car.Encode(ct)
+		require.NoError(t, err)
+
+		// remove the final section, which is the receipt
+		size := len(rcpt.Link().Bytes()) + len(rcpt.Bytes())
+		_, err = car.Decode(b[:len(b)-size-varint.UvarintSize(uint64(size))])
+		require.ErrorContains(t, err, "missing receipt block")
+	})
+
+	t.Run("exceeds limits", func(t *testing.T) {
+		b, err := car.Encode(ct)
+		require.NoError(t, err)
+
+		_, err = car.Decode(b, car.WithLimits(limits.Limits{MaxBytes: int64(len(b) - 1)}))
+		require.ErrorContains(t, err, limits.BytesLimit)
+
+		_, err = car.Decode(b, car.WithLimits(limits.Limits{MaxTokens: 2}))
+		require.ErrorContains(t, err, limits.TokensLimit)
+
+		decoded, err := car.Decode(b, car.WithLimits(limits.Limits{MaxBytes: int64(len(b)), MaxTokens: 3}))
+		require.NoError(t, err)
+		require.Len(t, decoded.Receipts(), 1)
+	})
+
+	t.Run("truncated section", func(t *testing.T) {
+		b, err := car.Encode(ct)
+		require.NoError(t, err)
+
+		_, err = car.Decode(b[:len(b)-1])
+		require.ErrorContains(t, err, "reading section")
+	})
+}
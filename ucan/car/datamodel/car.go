@@ -0,0 +1,26 @@
+package datamodel
+
+import (
+	cid "github.com/ipfs/go-cid"
+)
+
+const Tag = "ucan-car-v1"
+
+// HeaderModel is the CAR header. The CARv2 pragma is also a header, with a
+// version of 2 and no roots.
+type HeaderModel struct {
+	Roots   []cid.Cid `cborgen:"roots"`
+	Version uint64    `cborgen:"version"`
+}
+
+// RootModel is the root block of a CAR of UCAN tokens.
+type RootModel struct {
+	Index1 IndexModel `cborgen:"ucan-car-v1"`
+}
+
+// IndexModel lists the CIDs of the tokens in the CAR, by type.
+type IndexModel struct {
+	Dlg  []cid.Cid `cborgen:"dlg"`
+	Inv  []cid.Cid `cborgen:"inv"`
+	Rcpt []cid.Cid `cborgen:"rcpt"`
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	cdm "github.com/alanshaw/ucantone/ucan/car/datamodel"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func main() {
+	if err := cbg.WriteMapEncodersToFile("../cbor_gen.go", "datamodel",
+		cdm.HeaderModel{},
+		cdm.RootModel{},
+		cdm.IndexModel{},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -2,16 +2,17 @@ package container
 
 import (
 	"bytes"
-	"compress/gzip"
-	"encoding/base64"
-	"errors"
 	"fmt"
 	"io"
 	"slices"
 
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	ipldmodel "github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/container/datamodel"
 	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	"github.com/alanshaw/ucantone/ucan/invocation"
 	"github.com/alanshaw/ucantone/ucan/receipt"
 	"github.com/ipfs/go-cid"
@@ -46,10 +47,19 @@ func FormatCodec(codec byte) string {
 	}
 }
 
+// Container is a [ucan.Container] that indexes tokens by CID, and receipts by
+// the CID of the task they are for. Tokens are deduplicated when they are
+// added.
 type Container struct {
 	invs  []ucan.Invocation
 	rcpts []ucan.Receipt
 	dlgs  []ucan.Delegation
+
+	invIdx  map[cid.Cid]ucan.Invocation
+	rcptIdx map[cid.Cid]ucan.Receipt
+	dlgIdx  map[cid.Cid]ucan.Delegation
+	// ranIdx indexes receipts by the task they are for
+	ranIdx map[cid.Cid]ucan.Receipt
 }
 
 func (c *Container) Delegations() []ucan.Delegation {
@@ -57,138 +67,316 @@ func (c *Container) Delegations() []ucan.Delegation {
 }
 
 func (c *Container) Delegation(root cid.Cid) (ucan.Delegation, bool) {
-	for _, dlg := range c.dlgs {
-		if dlg.Link() == root {
-			return dlg, true
-		}
-	}
-	return nil, false
+	dlg, ok := c.dlgIdx[root]
+	return dlg, ok
 }
 
 func (c *Container) Invocations() []ucan.Invocation {
 	return c.invs
 }
 
+// Invocation retrieves an invocation from the container by its CID.
+func (c *Container) Invocation(root cid.Cid) (ucan.Invocation, bool) {
+	inv, ok := c.invIdx[root]
+	return inv, ok
+}
+
 func (c *Container) Receipts() []ucan.Receipt {
 	return c.rcpts
 }
 
 func (c *Container) Receipt(task cid.Cid) (ucan.Receipt, bool) {
+	rcpt, ok := c.ranIdx[task]
+	return rcpt, ok
+}
+
+func (c *Container) addInvocation(inv ucan.Invocation) {
+	if c.invIdx == nil {
+		c.invIdx = map[cid.Cid]ucan.Invocation{}
+	}
+	if _, ok := c.invIdx[inv.Link()]; ok {
+		return
+	}
+	c.invIdx[inv.Link()] = inv
+	c.invs = append(c.invs, inv)
+}
+
+func (c *Container) addDelegation(dlg ucan.Delegation) {
+	if c.dlgIdx == nil {
+		c.dlgIdx = map[cid.Cid]ucan.Delegation{}
+	}
+	if _, ok := c.dlgIdx[dlg.Link()]; ok {
+		return
+	}
+	c.dlgIdx[dlg.Link()] = dlg
+	c.dlgs = append(c.dlgs, dlg)
+}
+
+func (c *Container) addReceipt(rcpt ucan.Receipt) {
+	if c.rcptIdx == nil {
+		c.rcptIdx = map[cid.Cid]ucan.Receipt{}
+		c.ranIdx = map[cid.Cid]ucan.Receipt{}
+	}
+	if _, ok := c.rcptIdx[rcpt.Link()]; ok {
+		return
+	}
+	c.rcptIdx[rcpt.Link()] = rcpt
+	// the first receipt added for a task is the one returned by Receipt
+	if _, ok := c.ranIdx[rcpt.Ran()]; !ok {
+		c.ranIdx[rcpt.Ran()] = rcpt
+	}
+	c.rcpts = append(c.rcpts, rcpt)
+}
+
+// Merge creates a new container with the tokens from this container and the
+// passed containers.
+func (c *Container) Merge(others ...ucan.Container) *Container {
+	merged := c.Filter(func(ucan.Token) bool { return true })
+	for _, o := range others {
+		for _, inv := range o.Invocations() {
+			merged.addInvocation(inv)
+		}
+		for _, dlg := range o.Delegations() {
+			merged.addDelegation(dlg)
+		}
+		for _, rcpt := range o.Receipts() {
+			merged.addReceipt(rcpt)
+		}
+	}
+	return merged
+}
+
+// Filter creates a new container with the tokens from this container for
+// which keep returns true.
+func (c *Container) Filter(keep func(ucan.Token) bool) *Container {
+	filtered := &Container{}
+	for _, inv := range c.invs {
+		if keep(inv) {
+			filtered.addInvocation(inv)
+		}
+	}
+	for _, dlg := range c.dlgs {
+		if keep(dlg) {
+			filtered.addDelegation(dlg)
+		}
+	}
 	for _, rcpt := range c.rcpts {
-		if rcpt.Ran() == task {
-			return rcpt, true
+		if keep(rcpt) {
+			filtered.addReceipt(rcpt)
 		}
 	}
-	return nil, false
+	return filtered
 }
 
-func (c *Container) MarshalCBOR(w io.Writer) error {
+// Without creates a new container with the tokens from this container, except
+// those with the passed CIDs.
+func (c *Container) Without(links ...ucan.Link) *Container {
+	exclude := make(map[cid.Cid]struct{}, len(links))
+	for _, l := range links {
+		exclude[l] = struct{}{}
+	}
+	return c.Filter(func(t ucan.Token) bool {
+		_, ok := exclude[t.Link()]
+		return !ok
+	})
+}
+
+// model creates the data model of the container, with the tokens sorted by
+// their encoded bytes.
+func (c *Container) model() (*datamodel.ContainerModel, error) {
 	var tokens [][]byte
 	for _, inv := range c.invs {
 		b, err := invocation.Encode(inv)
 		if err != nil {
-			return fmt.Errorf("encoding invocation: %w", err)
+			return nil, fmt.Errorf("encoding invocation: %w", err)
 		}
 		tokens = append(tokens, b)
 	}
 	for _, dlg := range c.dlgs {
 		b, err := delegation.Encode(dlg)
 		if err != nil {
-			return fmt.Errorf("encoding delegation: %w", err)
+			return nil, fmt.Errorf("encoding delegation: %w", err)
 		}
 		tokens = append(tokens, b)
 	}
 	for _, rcpt := range c.rcpts {
 		b, err := receipt.Encode(rcpt)
 		if err != nil {
-			return fmt.Errorf("encoding receipt: %w", err)
+			return nil, fmt.Errorf("encoding receipt: %w", err)
 		}
 		tokens = append(tokens, b)
 	}
 	slices.SortFunc(tokens, bytes.Compare)
+	return &datamodel.ContainerModel{Ctn1: tokens}, nil
+}
 
-	model := datamodel.ContainerModel{Ctn1: tokens}
+func (c *Container) MarshalCBOR(w io.Writer) error {
+	model, err := c.model()
+	if err != nil {
+		return err
+	}
 	return model.MarshalCBOR(w)
 }
 
+// UnmarshalCBOR decodes a container, enforcing the default limits. See
+// [Container.UnmarshalCBORWithLimits].
 func (c *Container) UnmarshalCBOR(r io.Reader) error {
+	return c.UnmarshalCBORWithLimits(r, limits.Default)
+}
+
+// UnmarshalCBORWithLimits decodes a container, returning a limit exceeded
+// error if the encoded container or any of the tokens within it exceed the
+// passed limits. Zero values in the limits are replaced by the defaults. Pass
+// [limits.Unlimited] to decode a container from a trusted source.
+func (c *Container) UnmarshalCBORWithLimits(r io.Reader, l limits.Limits) error {
+	l = l.OrDefault()
 	model := datamodel.ContainerModel{}
-	if err := model.UnmarshalCBOR(r); err != nil {
+	if err := model.UnmarshalCBOR(limits.NewReader(r, l.MaxBytes)); err != nil {
 		return fmt.Errorf("unmarshalling container model CBOR: %w", err)
 	}
+	return c.decodeTokens(&model, l)
+}
 
-	var dlgs []ucan.Delegation
-	var invs []ucan.Invocation
-	var rcpts []ucan.Receipt
-	for _, b := range model.Ctn1 {
-		if dlg, err := delegation.Decode(b); err == nil {
-			dlgs = append(dlgs, dlg)
-			continue
-		}
-		if rcpt, err := receipt.Decode(b); err == nil {
-			rcpts = append(rcpts, rcpt)
-			continue
+// decodeTokens decodes the tokens in the container data model, replacing the
+// contents of the container. Tokens that cannot be decoded are skipped, but an
+// error is returned if a token exceeds the limits.
+func (c *Container) decodeTokens(model *datamodel.ContainerModel, l limits.Limits) error {
+	if len(model.Ctn1) > l.MaxTokens {
+		return limits.NewExceededError(limits.TokensLimit, int64(l.MaxTokens))
+	}
+
+	ct := Container{}
+	for i, b := range model.Ctn1 {
+		tkn, err := decodeToken(b, l)
+		if err != nil {
+			return fmt.Errorf("checking token %d: %w", i, err)
 		}
-		if inv, err := invocation.Decode(b); err == nil {
-			invs = append(invs, inv)
-			continue
+		switch tkn := tkn.(type) {
+		case ucan.Delegation:
+			ct.addDelegation(tkn)
+		case ucan.Receipt:
+			ct.addReceipt(tkn)
+		case ucan.Invocation:
+			ct.addInvocation(tkn)
 		}
 	}
 
-	*c = Container{
-		invs:  invs,
-		dlgs:  dlgs,
-		rcpts: rcpts,
-	}
+	*c = ct
 	return nil
 }
 
-func (c *Container) MarshalDagJSON(w io.Writer) error {
-	var tokens [][]byte
-	for _, inv := range c.invs {
-		b, err := invocation.Encode(inv)
-		if err != nil {
-			return fmt.Errorf("encoding invocation: %w", err)
-		}
-		tokens = append(tokens, b)
+// decodeToken decodes a delegation, receipt or invocation, returning an error
+// if the token exceeds the limits. It returns nil if the bytes are not a token
+// that can be decoded.
+//
+// The nesting depth and policy limits are checked here, rather than only by
+// the token decoders, so that exceeding them is reported instead of the token
+// being skipped.
+func decodeToken(b []byte, l limits.Limits) (ucan.Token, error) {
+	if err := dagcbor.CheckNesting(b, l.MaxNesting); err != nil {
+		return nil, err
 	}
-	for _, dlg := range c.dlgs {
-		b, err := delegation.Encode(dlg)
+	if dlg, err := delegation.Decode(b, delegation.WithLimits(limits.Limits{
+		MaxNesting:          l.MaxNesting,
+		MaxPolicyDepth:      limits.Unlimited.MaxPolicyDepth,
+		MaxPolicyStatements: limits.Unlimited.MaxPolicyStatements,
+	})); err == nil {
+		err := policy.CheckLimits(dlg.Policy(), l.MaxPolicyDepth, l.MaxPolicyStatements)
 		if err != nil {
-			return fmt.Errorf("encoding delegation: %w", err)
+			return nil, fmt.Errorf("checking delegation %s policy: %w", dlg.Link(), err)
 		}
-		tokens = append(tokens, b)
+		return dlg, nil
 	}
-	for _, rcpt := range c.rcpts {
-		b, err := receipt.Encode(rcpt)
-		if err != nil {
-			return fmt.Errorf("encoding receipt: %w", err)
+	if rcpt, err := receipt.Decode(b, invocation.WithLimits(l)); err == nil {
+		return rcpt, nil
+	}
+	if inv, err := invocation.Decode(b, invocation.WithLimits(l)); err == nil {
+		// arguments can be no larger than the token they are in
+		if int64(len(b)) > l.MaxArgumentBytes {
+			if err := checkArgumentBytes(inv, l.MaxArgumentBytes); err != nil {
+				return nil, fmt.Errorf("checking invocation %s arguments: %w", inv.Link(), err)
+			}
 		}
-		tokens = append(tokens, b)
+		return inv, nil
 	}
-	slices.SortFunc(tokens, bytes.Compare)
+	return nil, nil
+}
+
+// checkArgumentBytes returns a limit exceeded error if the encoded invocation
+// arguments are larger than max bytes.
+func checkArgumentBytes(inv ucan.Invocation, max int64) error {
+	var cw countingWriter
+	if err := ipldmodel.Map(inv.Arguments()).MarshalCBOR(&cw); err != nil {
+		return err
+	}
+	if cw.n > max {
+		return limits.NewExceededError(limits.ArgumentBytesLimit, max)
+	}
+	return nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
 
-	model := datamodel.ContainerModel{Ctn1: tokens}
+func (c *Container) MarshalDagJSON(w io.Writer) error {
+	model, err := c.model()
+	if err != nil {
+		return err
+	}
 	return model.MarshalDagJSON(w)
 }
 
+// UnmarshalDagJSON decodes a container, enforcing the default limits. See
+// [Container.UnmarshalDagJSONWithLimits].
+func (c *Container) UnmarshalDagJSON(r io.Reader) error {
+	return c.UnmarshalDagJSONWithLimits(r, limits.Default)
+}
+
+// UnmarshalDagJSONWithLimits decodes a container, returning a limit exceeded
+// error if the encoded container or any of the tokens within it exceed the
+// passed limits. Zero values in the limits are replaced by the defaults. Pass
+// [limits.Unlimited] to decode a container from a trusted source.
+func (c *Container) UnmarshalDagJSONWithLimits(r io.Reader, l limits.Limits) error {
+	l = l.OrDefault()
+	model := datamodel.ContainerModel{}
+	if err := model.UnmarshalDagJSON(limits.NewReader(r, l.MaxBytes)); err != nil {
+		return fmt.Errorf("unmarshalling container model DAG-JSON: %w", err)
+	}
+	return c.decodeTokens(&model, l)
+}
+
 type Option func(c *Container)
 
+// WithInvocations adds invocations to the container. Duplicates are ignored.
 func WithInvocations(invocations ...ucan.Invocation) Option {
 	return func(c *Container) {
-		c.invs = append(c.invs, invocations...)
+		for _, inv := range invocations {
+			c.addInvocation(inv)
+		}
 	}
 }
 
+// WithDelegations adds delegations to the container. Duplicates are ignored.
 func WithDelegations(delegations ...ucan.Delegation) Option {
 	return func(c *Container) {
-		c.dlgs = append(c.dlgs, delegations...)
+		for _, dlg := range delegations {
+			c.addDelegation(dlg)
+		}
 	}
 }
 
+// WithReceipts adds receipts to the container. Duplicates are ignored.
 func WithReceipts(receipts ...ucan.Receipt) Option {
 	return func(c *Container) {
-		c.rcpts = append(c.rcpts, receipts...)
+		for _, rcpt := range receipts {
+			c.addReceipt(rcpt)
+		}
 	}
 }
 
@@ -203,93 +391,56 @@ func New(options ...Option) *Container {
 func Encode(codec byte, container ucan.Container) ([]byte, error) {
 	c, ok := container.(*Container)
 	if !ok {
-		c = &Container{
-			invs:  container.Invocations(),
-			dlgs:  container.Delegations(),
-			rcpts: container.Receipts(),
-		}
+		c = New(
+			WithInvocations(container.Invocations()...),
+			WithDelegations(container.Delegations()...),
+			WithReceipts(container.Receipts()...),
+		)
 	}
 
-	var buf bytes.Buffer
-	err := c.MarshalCBOR(&buf)
+	model, err := c.model()
 	if err != nil {
 		return nil, fmt.Errorf("marshaling container to CBOR: %w", err)
 	}
 
-	var out []byte
-	if codec == RawGzip || codec == Base64Gzip || codec == Base64urlGzip {
-		var gzbuf bytes.Buffer
-		gz := gzip.NewWriter(&gzbuf)
-		_, err := io.Copy(gz, &buf)
-		if err != nil {
-			gz.Close()
-			return nil, fmt.Errorf("compressing container data: %w", err)
-		}
-		if err := gz.Close(); err != nil {
-			return nil, fmt.Errorf("closing gzip writer: %w", err)
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, codec, len(model.Ctn1))
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range model.Ctn1 {
+		if err := w.writeBytes(b); err != nil {
+			return nil, err
 		}
-		out = gzbuf.Bytes()
-	} else {
-		out = buf.Bytes()
 	}
-
-	switch codec {
-	case Raw, RawGzip:
-		// nothing to do
-		break
-	case Base64, Base64Gzip:
-		out = []byte(base64.StdEncoding.EncodeToString(out))
-	case Base64url, Base64urlGzip:
-		out = []byte(base64.RawURLEncoding.EncodeToString(out))
-	default:
-		return nil, fmt.Errorf("unknown codec: 0x%02x", codec)
+	if err := w.Close(); err != nil {
+		return nil, err
 	}
-
-	return append([]byte{codec}, out...), nil
+	return buf.Bytes(), nil
 }
 
+// Decode decodes a container in any of the supported codecs, enforcing the
+// default limits. See [DecodeWithLimits].
 func Decode(input []byte) (*Container, error) {
-	if len(input) == 0 {
-		return nil, errors.New("empty container bytes")
-	}
-	codec := input[0]
-	var compressed []byte
-	switch codec {
-	case Raw, RawGzip:
-		compressed = input[1:]
-	case Base64, Base64Gzip:
-		r, err := base64.StdEncoding.DecodeString(string(input[1:]))
-		if err != nil {
-			return nil, fmt.Errorf("decoding base64: %w", err)
-		}
-		compressed = r
-	case Base64url, Base64urlGzip:
-		r, err := base64.RawURLEncoding.DecodeString(string(input[1:]))
-		if err != nil {
-			return nil, fmt.Errorf("decoding base64: %w", err)
-		}
-		compressed = r
-	default:
-		return nil, fmt.Errorf("unknown codec: 0x%02x", codec)
-	}
+	return DecodeWithLimits(input, limits.Default)
+}
 
-	var raw []byte
-	if codec == RawGzip || codec == Base64Gzip || codec == Base64urlGzip {
-		gz, err := gzip.NewReader(bytes.NewReader(compressed))
-		if err != nil {
-			return nil, fmt.Errorf("creating gzip reader: %w", err)
-		}
-		defer gz.Close()
-		if raw, err = io.ReadAll(gz); err != nil {
-			return nil, fmt.Errorf("reading gzipped data: %w", err)
-		}
-	} else {
-		raw = compressed // not compressed
+// DecodeWithLimits decodes a container in any of the supported codecs,
+// returning a limit exceeded error if the container or any of the tokens
+// within it exceed the passed limits. Zero values in the limits are replaced
+// by the defaults. Pass [limits.Unlimited] to decode a container from a trusted
+// source.
+func DecodeWithLimits(input []byte, l limits.Limits) (*Container, error) {
+	raw, closer, err := newDecoder(bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		defer closer.Close()
 	}
 
 	ct := Container{}
-	err := ct.UnmarshalCBOR(bytes.NewReader(raw))
-	if err != nil {
+	if err := ct.UnmarshalCBORWithLimits(raw, l); err != nil {
 		return nil, err
 	}
 	return &ct, nil
@@ -1,12 +1,23 @@
 package container_test
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/result"
 	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/ucan/receipt"
 	"github.com/stretchr/testify/require"
 )
 
@@ -40,3 +51,301 @@ func TestContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestContainerLimits(t *testing.T) {
+	issuer := testutil.RandomSigner(t)
+	subject := testutil.RandomDID(t)
+	command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	encode := func(t *testing.T, ct *container.Container) []byte {
+		var b bytes.Buffer
+		require.NoError(t, ct.MarshalCBOR(&b))
+		return b.Bytes()
+	}
+
+	decode := func(t *testing.T, b []byte, l limits.Limits) error {
+		var ct container.Container
+		return ct.UnmarshalCBORWithLimits(bytes.NewReader(b), l)
+	}
+
+	requireExceeded := func(t *testing.T, err error, limit string) {
+		t.Helper()
+		var lerr edm.ErrorModel
+		require.True(t, errors.As(err, &lerr))
+		require.Equal(t, limits.ExceededErrorName, lerr.Name())
+		require.Contains(t, lerr.Error(), limit)
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		inv, err := invocation.Invoke(issuer, subject, command, testutil.RandomArgs(t))
+		require.NoError(t, err)
+		b := encode(t, container.New(container.WithInvocations(inv)))
+		require.NoError(t, decode(t, b, limits.Limits{MaxTokens: 1, MaxBytes: int64(len(b))}))
+	})
+
+	t.Run("too many bytes", func(t *testing.T) {
+		inv, err := invocation.Invoke(issuer, subject, command, testutil.RandomArgs(t))
+		require.NoError(t, err)
+		b := encode(t, container.New(container.WithInvocations(inv)))
+		err = decode(t, b, limits.Limits{MaxBytes: int64(len(b) - 1)})
+		requireExceeded(t, err, limits.BytesLimit)
+	})
+
+	t.Run("too many tokens", func(t *testing.T) {
+		var invs []ucan.Invocation
+		for range 3 {
+			inv, err := invocation.Invoke(issuer, subject, command, testutil.RandomArgs(t))
+			require.NoError(t, err)
+			invs = append(invs, inv)
+		}
+		b := encode(t, container.New(container.WithInvocations(invs...)))
+		err := decode(t, b, limits.Limits{MaxTokens: 2})
+		requireExceeded(t, err, limits.TokensLimit)
+	})
+
+	t.Run("nesting too deep", func(t *testing.T) {
+		var value any = "deep"
+		for range 10 {
+			value = []any{value}
+		}
+		inv, err := invocation.Invoke(issuer, subject, command, datamodel.Map{"value": value})
+		require.NoError(t, err)
+		b := encode(t, container.New(container.WithInvocations(inv)))
+		err = decode(t, b, limits.Limits{MaxNesting: 8})
+		requireExceeded(t, err, limits.NestingLimit)
+	})
+
+	t.Run("arguments too large", func(t *testing.T) {
+		args := datamodel.Map{"data": testutil.RandomBytes(t, 2048)}
+		inv, err := invocation.Invoke(issuer, subject, command, args)
+		require.NoError(t, err)
+		b := encode(t, container.New(container.WithInvocations(inv)))
+		err = decode(t, b, limits.Limits{MaxArgumentBytes: 1024})
+		requireExceeded(t, err, limits.ArgumentBytesLimit)
+	})
+
+	t.Run("policy too deep", func(t *testing.T) {
+		stmt := policy.Equal(".foo", "bar")
+		for range 3 {
+			stmt = policy.Not(stmt)
+		}
+		dlg, err := delegation.Delegate(issuer, subject, issuer, command, delegation.WithPolicyBuilder(stmt))
+		require.NoError(t, err)
+		b := encode(t, container.New(container.WithDelegations(dlg)))
+		err = decode(t, b, limits.Limits{MaxPolicyDepth: 3})
+		requireExceeded(t, err, limits.PolicyDepthLimit)
+	})
+
+	t.Run("limits above the defaults", func(t *testing.T) {
+		var value any = "deep"
+		for range limits.Default.MaxNesting + 10 {
+			value = []any{value}
+		}
+		inv, err := invocation.Invoke(issuer, subject, command, datamodel.Map{"value": value})
+		require.NoError(t, err)
+
+		stmt := policy.Equal(".foo", "bar")
+		for range limits.Default.MaxPolicyDepth + 10 {
+			stmt = policy.Not(stmt)
+		}
+		dlg, err := delegation.Delegate(issuer, subject, issuer, command, delegation.WithPolicyBuilder(stmt))
+		require.NoError(t, err)
+
+		b := encode(t, container.New(container.WithInvocations(inv), container.WithDelegations(dlg)))
+		// both the nesting and policy depth exceed the defaults
+		requireExceeded(t, decode(t, b, limits.Default), "depth")
+		err = decode(t, b, limits.Limits{
+			MaxNesting:     limits.Default.MaxNesting + 20,
+			MaxPolicyDepth: limits.Default.MaxPolicyDepth + 20,
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("enforced by default", func(t *testing.T) {
+		var dlgs []ucan.Delegation
+		for range limits.Default.MaxTokens + 1 {
+			dlg, err := delegation.Delegate(issuer, subject, issuer, command, delegation.WithNoExpiration())
+			require.NoError(t, err)
+			dlgs = append(dlgs, dlg)
+		}
+		ct := container.New(container.WithDelegations(dlgs...))
+		b, err := container.Encode(container.RawGzip, ct)
+		require.NoError(t, err)
+
+		_, err = container.Decode(b)
+		requireExceeded(t, err, limits.TokensLimit)
+
+		var buf bytes.Buffer
+		require.NoError(t, ct.MarshalDagJSON(&buf))
+		var decoded container.Container
+		err = decoded.UnmarshalDagJSON(bytes.NewReader(buf.Bytes()))
+		requireExceeded(t, err, limits.TokensLimit)
+
+		t.Run("unlimited", func(t *testing.T) {
+			decoded, err := container.DecodeWithLimits(b, limits.Unlimited)
+			require.NoError(t, err)
+			require.Len(t, decoded.Delegations(), len(dlgs))
+
+			err = decoded.UnmarshalDagJSONWithLimits(bytes.NewReader(buf.Bytes()), limits.Unlimited)
+			require.NoError(t, err)
+			require.Len(t, decoded.Delegations(), len(dlgs))
+		})
+	})
+}
+
+func TestContainerDagJSON(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+
+	inv, err := invocation.Invoke(bob, alice, cmd, testutil.RandomArgs(t), invocation.WithProofs(dlg.Link()))
+	require.NoError(t, err)
+
+	rcpt, err := receipt.Issue(service, inv.Task().Link(), result.OK[ipld.Map, ipld.Any](datamodel.Map{}))
+	require.NoError(t, err)
+
+	initial := container.New(
+		container.WithDelegations(dlg),
+		container.WithInvocations(inv),
+		container.WithReceipts(rcpt),
+	)
+
+	var b bytes.Buffer
+	require.NoError(t, initial.MarshalDagJSON(&b))
+
+	var decoded container.Container
+	require.NoError(t, decoded.UnmarshalDagJSON(bytes.NewReader(b.Bytes())))
+	require.Len(t, decoded.Delegations(), 1)
+	require.Equal(t, dlg.Link(), decoded.Delegations()[0].Link())
+	require.Len(t, decoded.Invocations(), 1)
+	require.Equal(t, inv.Link(), decoded.Invocations()[0].Link())
+	require.Len(t, decoded.Receipts(), 1)
+	require.Equal(t, rcpt.Link(), decoded.Receipts()[0].Link())
+
+	var reencoded bytes.Buffer
+	require.NoError(t, decoded.MarshalDagJSON(&reencoded))
+	require.Equal(t, b.Bytes(), reencoded.Bytes())
+
+	t.Run("exceeds limits", func(t *testing.T) {
+		var ct container.Container
+		err := ct.UnmarshalDagJSONWithLimits(bytes.NewReader(b.Bytes()), limits.Limits{MaxTokens: 2})
+		var lerr edm.ErrorModel
+		require.True(t, errors.As(err, &lerr))
+		require.Equal(t, limits.ExceededErrorName, lerr.Name())
+	})
+}
+
+func TestContainerIndex(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg0, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+	dlg1, err := delegation.Delegate(alice, bob, alice, cmd, delegation.WithNonce([]byte{1}))
+	require.NoError(t, err)
+
+	inv, err := invocation.Invoke(bob, alice, cmd, testutil.RandomArgs(t))
+	require.NoError(t, err)
+
+	rcpt, err := receipt.Issue(service, inv.Task().Link(), result.OK[ipld.Map, ipld.Any](datamodel.Map{}))
+	require.NoError(t, err)
+
+	t.Run("deduplicates", func(t *testing.T) {
+		ct := container.New(
+			container.WithDelegations(dlg0, dlg0, dlg1),
+			container.WithInvocations(inv, inv),
+			container.WithReceipts(rcpt),
+			container.WithReceipts(rcpt),
+		)
+		require.Len(t, ct.Delegations(), 2)
+		require.Len(t, ct.Invocations(), 1)
+		require.Len(t, ct.Receipts(), 1)
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		ct := container.New(
+			container.WithDelegations(dlg0, dlg1),
+			container.WithInvocations(inv),
+			container.WithReceipts(rcpt),
+		)
+
+		d, ok := ct.Delegation(dlg1.Link())
+		require.True(t, ok)
+		require.Equal(t, dlg1.Link(), d.Link())
+
+		i, ok := ct.Invocation(inv.Link())
+		require.True(t, ok)
+		require.Equal(t, inv.Link(), i.Link())
+
+		r, ok := ct.Receipt(inv.Task().Link())
+		require.True(t, ok)
+		require.Equal(t, rcpt.Link(), r.Link())
+
+		_, ok = ct.Delegation(inv.Link())
+		require.False(t, ok)
+		_, ok = ct.Receipt(testutil.RandomCID(t))
+		require.False(t, ok)
+	})
+
+	t.Run("lookup in zero value", func(t *testing.T) {
+		var ct container.Container
+		_, ok := ct.Delegation(dlg0.Link())
+		require.False(t, ok)
+		_, ok = ct.Receipt(inv.Task().Link())
+		require.False(t, ok)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		a := container.New(container.WithDelegations(dlg0), container.WithInvocations(inv))
+		b := container.New(container.WithDelegations(dlg0, dlg1), container.WithReceipts(rcpt))
+
+		merged := a.Merge(b)
+		require.Len(t, merged.Delegations(), 2)
+		require.Len(t, merged.Invocations(), 1)
+		require.Len(t, merged.Receipts(), 1)
+		_, ok := merged.Receipt(inv.Task().Link())
+		require.True(t, ok)
+
+		// original is not modified
+		require.Len(t, a.Delegations(), 1)
+		require.Len(t, a.Receipts(), 0)
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		ct := container.New(
+			container.WithDelegations(dlg0, dlg1),
+			container.WithInvocations(inv),
+			container.WithReceipts(rcpt),
+		)
+		filtered := ct.Filter(func(tkn ucan.Token) bool {
+			_, ok := tkn.(ucan.Delegation)
+			return ok
+		})
+		require.Len(t, filtered.Delegations(), 2)
+		require.Len(t, filtered.Invocations(), 0)
+		require.Len(t, filtered.Receipts(), 0)
+	})
+
+	t.Run("without", func(t *testing.T) {
+		ct := container.New(
+			container.WithDelegations(dlg0, dlg1),
+			container.WithInvocations(inv),
+			container.WithReceipts(rcpt),
+		)
+		without := ct.Without(dlg0.Link(), rcpt.Link())
+		require.Len(t, without.Delegations(), 1)
+		require.Equal(t, dlg1.Link(), without.Delegations()[0].Link())
+		require.Len(t, without.Invocations(), 1)
+		require.Len(t, without.Receipts(), 0)
+		_, ok := without.Delegation(dlg0.Link())
+		require.False(t, ok)
+		_, ok = without.Receipt(inv.Task().Link())
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,330 @@
+package container
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// containerKey is the key of the token list in an encoded container.
+const containerKey = "ctn-v1"
+
+// Writer writes an encoded container one token at a time, so that the tokens
+// do not need to be held in memory.
+//
+// The number of tokens must be known in advance, since it is encoded before
+// the tokens. Tokens are written in the order they are passed, not sorted, and
+// duplicates are not removed.
+type Writer struct {
+	cw        *cbg.CborWriter
+	closers   []io.Closer
+	remaining int
+}
+
+// NewWriter creates a writer that encodes a container of count tokens to w,
+// using the passed codec e.g. [Base64Gzip]. The writer must be closed after
+// all the tokens have been written. Closing the writer does not close w.
+func NewWriter(w io.Writer, codec byte, count int) (*Writer, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("invalid token count: %d", count)
+	}
+
+	var enc io.WriteCloser
+	switch codec {
+	case Raw, RawGzip:
+		// nothing to do
+	case Base64, Base64Gzip:
+		enc = base64.NewEncoder(base64.StdEncoding, w)
+	case Base64url, Base64urlGzip:
+		enc = base64.NewEncoder(base64.RawURLEncoding, w)
+	default:
+		return nil, fmt.Errorf("unknown codec: 0x%02x", codec)
+	}
+
+	if _, err := w.Write([]byte{codec}); err != nil {
+		return nil, fmt.Errorf("writing codec: %w", err)
+	}
+
+	var closers []io.Closer
+	if enc != nil {
+		closers = append(closers, enc)
+		w = enc
+	}
+	if codec == RawGzip || codec == Base64Gzip || codec == Base64urlGzip {
+		gz := gzip.NewWriter(w)
+		// the gzip writer must be closed before the base64 encoder
+		closers = append([]io.Closer{gz}, closers...)
+		w = gz
+	}
+
+	cw := cbg.NewCborWriter(w)
+	if err := cw.WriteMajorTypeHeader(cbg.MajMap, 1); err != nil {
+		return nil, fmt.Errorf("writing container header: %w", err)
+	}
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(containerKey))); err != nil {
+		return nil, fmt.Errorf("writing container header: %w", err)
+	}
+	if _, err := cw.WriteString(containerKey); err != nil {
+		return nil, fmt.Errorf("writing container header: %w", err)
+	}
+	if err := cw.WriteMajorTypeHeader(cbg.MajArray, uint64(count)); err != nil {
+		return nil, fmt.Errorf("writing container header: %w", err)
+	}
+	return &Writer{cw: cw, closers: closers, remaining: count}, nil
+}
+
+// Write appends a token to the container. It returns an error if more tokens
+// are written than the count passed to [NewWriter].
+func (w *Writer) Write(tkn ucan.Token) error {
+	return w.writeBytes(tkn.Bytes())
+}
+
+func (w *Writer) writeBytes(b []byte) error {
+	if w.remaining == 0 {
+		return errors.New("container token count exceeded")
+	}
+	if err := w.cw.WriteMajorTypeHeader(cbg.MajByteString, uint64(len(b))); err != nil {
+		return fmt.Errorf("writing token: %w", err)
+	}
+	if _, err := w.cw.Write(b); err != nil {
+		return fmt.Errorf("writing token: %w", err)
+	}
+	w.remaining--
+	return nil
+}
+
+// Close flushes any buffered data to the underlying writer. It returns an
+// error if fewer tokens were written than the count passed to [NewWriter].
+func (w *Writer) Close() error {
+	for _, c := range w.closers {
+		if err := c.Close(); err != nil {
+			return fmt.Errorf("closing container writer: %w", err)
+		}
+	}
+	w.closers = nil
+	if w.remaining > 0 {
+		return fmt.Errorf("container is missing %d tokens", w.remaining)
+	}
+	return nil
+}
+
+// ReaderOption is an option configuring a [Reader].
+type ReaderOption func(cfg *readerConfig)
+
+type readerConfig struct {
+	limits *limits.Limits
+}
+
+// WithLimits configures a [Reader] to enforce the passed limits. The byte and
+// token limits apply to the whole container, so they may need to be increased
+// when reading large batches from a trusted source.
+func WithLimits(l limits.Limits) ReaderOption {
+	return func(cfg *readerConfig) {
+		cfg.limits = &l
+	}
+}
+
+// Reader reads the tokens in an encoded container one at a time, so that they
+// do not need to be held in memory.
+type Reader struct {
+	cr        *cbg.CborReader
+	closer    io.Closer
+	limits    limits.Limits
+	count     int
+	remaining int
+}
+
+// NewReader creates a reader for the container encoded in r, in any of the
+// supported codecs. The container header is read immediately, so an error is
+// returned if r does not contain a container.
+//
+// By default, only the [limits.Default] that apply to each token, such as the
+// nesting depth, are enforced. The stream may be of any length and contain any
+// number of tokens. Use [WithLimits] to also limit the whole container.
+func NewReader(r io.Reader, options ...ReaderOption) (*Reader, error) {
+	cfg := readerConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	l := limits.Default
+	if cfg.limits != nil {
+		l = cfg.limits.OrDefault()
+	}
+
+	raw, closer, err := newDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.limits != nil {
+		raw = limits.NewReader(raw, l.MaxBytes)
+	}
+	cr := cbg.NewCborReader(raw)
+
+	count, err := readHeader(cr)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+	if count > math.MaxInt {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("reading container header: too many tokens (%d)", count)
+	}
+	if cfg.limits != nil && count > uint64(l.MaxTokens) {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, limits.NewExceededError(limits.TokensLimit, int64(l.MaxTokens))
+	}
+	rd := &Reader{cr: cr, closer: closer, limits: l, count: int(count), remaining: int(count)}
+	if count == 0 {
+		if err := rd.checkEnd(); err != nil {
+			rd.Close()
+			return nil, err
+		}
+	}
+	return rd, nil
+}
+
+// Len returns the total number of tokens in the container, including those
+// that have already been read.
+func (r *Reader) Len() int {
+	return r.count
+}
+
+// Next reads the next delegation, invocation or receipt from the container.
+// Tokens that cannot be decoded are skipped. It returns [io.EOF] when there are
+// no more tokens. The stream is read to the end along with the last token, so
+// that trailing data or a corrupt compressed stream is reported.
+func (r *Reader) Next() (ucan.Token, error) {
+	for r.remaining > 0 {
+		i := r.count - r.remaining
+		maj, extra, err := r.cr.ReadHeader()
+		if err != nil {
+			return nil, fmt.Errorf("reading token %d: %w", i, err)
+		}
+		if maj != cbg.MajByteString {
+			return nil, fmt.Errorf("reading token %d: expected byte array", i)
+		}
+		if extra > cbg.ByteArrayMaxLen {
+			return nil, fmt.Errorf("reading token %d: byte array too large (%d)", i, extra)
+		}
+		b := make([]byte, extra)
+		if _, err := io.ReadFull(r.cr, b); err != nil {
+			return nil, fmt.Errorf("reading token %d: %w", i, err)
+		}
+		r.remaining--
+		if r.remaining == 0 {
+			if err := r.checkEnd(); err != nil {
+				return nil, err
+			}
+		}
+
+		tkn, err := decodeToken(b, r.limits)
+		if err != nil {
+			return nil, fmt.Errorf("checking token %d: %w", i, err)
+		}
+		if tkn != nil {
+			return tkn, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// checkEnd checks there is no data after the container. Reading to the end of
+// a compressed stream also verifies its checksum.
+func (r *Reader) checkEnd() error {
+	var b [1]byte
+	n, err := io.ReadFull(r.cr, b[:])
+	if n > 0 {
+		return errors.New("reading container: unexpected data after token list")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading container: %w", err)
+	}
+	return nil
+}
+
+// Close releases the resources used by the reader. It does not close the
+// underlying reader.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
+
+// newDecoder reads the codec from r and returns a reader for the raw CBOR
+// encoded container that follows. The returned closer is non-nil if the data
+// is compressed, and must be closed after reading.
+func newDecoder(r io.Reader) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(r)
+	codec, err := br.ReadByte()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("empty container bytes")
+		}
+		return nil, nil, fmt.Errorf("reading codec: %w", err)
+	}
+
+	var compressed io.Reader
+	switch codec {
+	case Raw, RawGzip:
+		compressed = br
+	case Base64, Base64Gzip:
+		compressed = base64.NewDecoder(base64.StdEncoding, br)
+	case Base64url, Base64urlGzip:
+		compressed = base64.NewDecoder(base64.RawURLEncoding, br)
+	default:
+		return nil, nil, fmt.Errorf("unknown codec: 0x%02x", codec)
+	}
+
+	if codec == RawGzip || codec == Base64Gzip || codec == Base64urlGzip {
+		gz, err := gzip.NewReader(compressed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		return gz, gz, nil
+	}
+	return compressed, nil, nil // not compressed
+}
+
+// readHeader reads the container map and the header of the token list,
+// returning the number of tokens in the list.
+func readHeader(cr *cbg.CborReader) (uint64, error) {
+	maj, extra, err := cr.ReadHeader()
+	if err != nil {
+		return 0, fmt.Errorf("reading container header: %w", err)
+	}
+	if maj != cbg.MajMap || extra != 1 {
+		return 0, errors.New("reading container header: expected map with a single key")
+	}
+	key, err := cbg.ReadStringWithMax(cr, uint64(len(containerKey)))
+	if err != nil {
+		return 0, fmt.Errorf("reading container header: %w", err)
+	}
+	if key != containerKey {
+		return 0, fmt.Errorf("reading container header: unknown key %q", key)
+	}
+	maj, extra, err = cr.ReadHeader()
+	if err != nil {
+		return 0, fmt.Errorf("reading container header: %w", err)
+	}
+	if maj != cbg.MajArray {
+		return 0, errors.New("reading container header: expected token list")
+	}
+	return extra, nil
+}
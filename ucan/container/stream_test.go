@@ -0,0 +1,212 @@
+package container_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/container"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+
+	var tokens []ucan.Token
+	tokens = append(tokens, dlg)
+	for range 10 {
+		inv, err := invocation.Invoke(bob, alice, cmd, testutil.RandomArgs(t), invocation.WithProofs(dlg.Link()))
+		require.NoError(t, err)
+		rcpt, err := receipt.Issue(service, inv.Task().Link(), result.OK[ipld.Map, ipld.Any](datamodel.Map{}))
+		require.NoError(t, err)
+		tokens = append(tokens, inv, rcpt)
+	}
+
+	codecs := []byte{
+		container.Raw,
+		container.Base64,
+		container.Base64url,
+		container.RawGzip,
+		container.Base64Gzip,
+		container.Base64urlGzip,
+	}
+	for _, code := range codecs {
+		t.Run(container.FormatCodec(code), func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := container.NewWriter(&buf, code, len(tokens))
+			require.NoError(t, err)
+			for _, tkn := range tokens {
+				require.NoError(t, w.Write(tkn))
+			}
+			require.NoError(t, w.Close())
+
+			r, err := container.NewReader(bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+			defer r.Close()
+			require.Equal(t, len(tokens), r.Len())
+
+			for _, tkn := range tokens {
+				next, err := r.Next()
+				require.NoError(t, err)
+				require.Equal(t, tkn.Link(), next.Link())
+				require.IsType(t, tkn, next)
+			}
+			_, err = r.Next()
+			require.ErrorIs(t, err, io.EOF)
+
+			// streamed containers can also be decoded in full
+			ct, err := container.Decode(buf.Bytes())
+			require.NoError(t, err)
+			require.Len(t, ct.Delegations(), 1)
+			require.Len(t, ct.Invocations(), 10)
+			require.Len(t, ct.Receipts(), 10)
+		})
+	}
+
+	t.Run("reads encoded container", func(t *testing.T) {
+		ct := container.New(container.WithDelegations(dlg))
+		b, err := container.Encode(container.Base64Gzip, ct)
+		require.NoError(t, err)
+
+		r, err := container.NewReader(bytes.NewReader(b))
+		require.NoError(t, err)
+		defer r.Close()
+
+		next, err := r.Next()
+		require.NoError(t, err)
+		require.Equal(t, dlg.Link(), next.Link())
+	})
+
+	t.Run("too many tokens written", func(t *testing.T) {
+		w, err := container.NewWriter(io.Discard, container.Raw, 1)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(tokens[0]))
+		require.Error(t, w.Write(tokens[1]))
+	})
+
+	t.Run("too few tokens written", func(t *testing.T) {
+		w, err := container.NewWriter(io.Discard, container.RawGzip, 2)
+		require.NoError(t, err)
+		require.NoError(t, w.Write(tokens[0]))
+		require.Error(t, w.Close())
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		_, err := container.NewWriter(io.Discard, 0x41, 1)
+		require.Error(t, err)
+		_, err = container.NewReader(bytes.NewReader([]byte{0x41}))
+		require.Error(t, err)
+	})
+
+	t.Run("no container limits by default", func(t *testing.T) {
+		n := limits.Default.MaxTokens + 76
+		var buf bytes.Buffer
+		w, err := container.NewWriter(&buf, container.RawGzip, n)
+		require.NoError(t, err)
+		for i := range n {
+			require.NoError(t, w.Write(tokens[i%len(tokens)]))
+		}
+		require.NoError(t, w.Close())
+
+		r, err := container.NewReader(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		defer r.Close()
+
+		var read int
+		for {
+			_, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			read++
+		}
+		require.Equal(t, n, read)
+
+		// the default limits apply to the whole container when opted in
+		_, err = container.NewReader(bytes.NewReader(buf.Bytes()), container.WithLimits(limits.Default))
+		var lerr edm.ErrorModel
+		require.True(t, errors.As(err, &lerr))
+		require.Equal(t, limits.ExceededErrorName, lerr.Name())
+	})
+
+	t.Run("exceeds token limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, err := container.NewWriter(&buf, container.Raw, len(tokens))
+		require.NoError(t, err)
+		for _, tkn := range tokens {
+			require.NoError(t, w.Write(tkn))
+		}
+		require.NoError(t, w.Close())
+
+		_, err = container.NewReader(bytes.NewReader(buf.Bytes()), container.WithLimits(limits.Limits{MaxTokens: 2}))
+		var lerr edm.ErrorModel
+		require.True(t, errors.As(err, &lerr))
+		require.Equal(t, limits.ExceededErrorName, lerr.Name())
+	})
+
+	readAll := func(b []byte) error {
+		r, err := container.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for {
+			if _, err := r.Next(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	encode := func(t *testing.T, codec byte) []byte {
+		var buf bytes.Buffer
+		w, err := container.NewWriter(&buf, codec, len(tokens))
+		require.NoError(t, err)
+		for _, tkn := range tokens {
+			require.NoError(t, w.Write(tkn))
+		}
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	t.Run("token count too large", func(t *testing.T) {
+		b := []byte{container.Raw, 0xa1, 0x66, 'c', 't', 'n', '-', 'v', '1', 0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+		_, err := container.NewReader(bytes.NewReader(b))
+		require.ErrorContains(t, err, "too many tokens")
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		b := encode(t, container.Raw)
+		require.NoError(t, readAll(b))
+		require.ErrorContains(t, readAll(append(b, 0x00)), "unexpected data after token list")
+	})
+
+	t.Run("corrupt gzip checksum", func(t *testing.T) {
+		b := encode(t, container.RawGzip)
+		require.NoError(t, readAll(b))
+		// the gzip trailer is the CRC-32 followed by the uncompressed size
+		b[len(b)-8] ^= 0xff
+		require.ErrorContains(t, readAll(b), "checksum")
+	})
+}
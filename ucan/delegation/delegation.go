@@ -10,6 +10,7 @@ import (
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	cmd "github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/crypto/signature"
@@ -129,7 +130,13 @@ func (d *Delegation) MarshalCBOR(w io.Writer) error {
 	return err
 }
 
+// UnmarshalCBOR decodes the delegation, returning an error if it is not encoded
+// in the canonical DAG-CBOR form.
 func (d *Delegation) UnmarshalCBOR(r io.Reader) error {
+	return d.unmarshalCBOR(r, true)
+}
+
+func (d *Delegation) unmarshalCBOR(r io.Reader, strict bool) error {
 	*d = Delegation{}
 	var w bytes.Buffer
 	model := ddm.EnvelopeModel{}
@@ -137,6 +144,11 @@ func (d *Delegation) UnmarshalCBOR(r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("unmarshaling delegation envelope CBOR: %w", err)
 	}
+	if strict {
+		if err := dagcbor.CheckCanonical(w.Bytes()); err != nil {
+			return fmt.Errorf("checking delegation CBOR: %w", err)
+		}
+	}
 	if model.SigPayload.TokenPayload1_0_0_rc1 == nil {
 		return errors.New("invalid or unsupported delegation token payload")
 	}
@@ -200,16 +212,61 @@ func (d *Delegation) UnmarshalDagJSON(r io.Reader) error {
 
 var _ ucan.Delegation = (*Delegation)(nil)
 
+// IsPowerline returns true if the delegation is a "powerline" delegation i.e.
+// it has a null subject.
+//
+// https://github.com/ucan-wg/delegation/blob/main/README.md#powerline
+func IsPowerline(dlg ucan.Delegation) bool {
+	return dlg.Subject() == nil
+}
+
 // Encode delegation to CBOR.
 func Encode(dlg ucan.Delegation) ([]byte, error) {
 	return dlg.Bytes(), nil
 }
 
 // Decode delegation from CBOR.
-func Decode(b []byte) (*Delegation, error) {
+func Decode(b []byte, options ...DecodeOption) (*Delegation, error) {
+	cfg := decodeConfig{strict: true, limits: limits.Default}
+	for _, opt := range options {
+		opt(&cfg)
+	}
 	d := Delegation{}
-	err := d.UnmarshalCBOR(bytes.NewReader(b))
-	return &d, err
+	// the nesting depth is checked before decoding, which bounds the recursion
+	// of the decoders
+	if err := dagcbor.CheckNesting(b, cfg.limits.MaxNesting); err != nil {
+		return &d, fmt.Errorf("checking delegation CBOR: %w", err)
+	}
+	if err := d.decode(b, cfg.strict); err != nil {
+		return &d, err
+	}
+	err := policy.CheckLimits(d.Policy(), cfg.limits.MaxPolicyDepth, cfg.limits.MaxPolicyStatements)
+	if err != nil {
+		return &d, fmt.Errorf("checking delegation policy: %w", err)
+	}
+	return &d, nil
+}
+
+func (d *Delegation) decode(b []byte, strict bool) error {
+	if strict {
+		// check the whole input so that trailing bytes are also rejected
+		if err := dagcbor.CheckCanonical(b); err != nil {
+			return fmt.Errorf("checking delegation CBOR: %w", err)
+		}
+		return d.unmarshalCBOR(bytes.NewReader(b), false)
+	}
+	// maps within the delegation are always decoded strictly, so decode the
+	// canonical form but keep the bytes as they were received
+	canonical, raw, link, err := dagcbor.DecodeLenient(b)
+	if err != nil {
+		return fmt.Errorf("checking delegation CBOR: %w", err)
+	}
+	if err := d.unmarshalCBOR(bytes.NewReader(canonical), false); err != nil {
+		return err
+	}
+	d.link = link
+	d.bytes = raw
+	return nil
 }
 
 func Delegate(
@@ -237,8 +294,12 @@ func Delegate(
 		return nil, fmt.Errorf("encoding varsig header: %w", err)
 	}
 
+	// a nil subject is a powerline delegation
 	var sub did.DID
 	if subject != nil {
+		if cfg.powerline {
+			return nil, errors.New("powerline delegation must not specify a subject")
+		}
 		sub = subject.DID()
 	}
 
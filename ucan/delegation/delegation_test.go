@@ -1,19 +1,29 @@
 package delegation_test
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"maps"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/principal/ed25519"
 	"github.com/alanshaw/ucantone/principal/secp256k1"
 	"github.com/alanshaw/ucantone/testutil"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
 )
 
 func TestDelegation(t *testing.T) {
@@ -58,6 +68,67 @@ func TestDelegation(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, ok)
 	})
+
+	t.Run("powerline", func(t *testing.T) {
+		issuer := testutil.RandomSigner(t)
+		audience := testutil.RandomDID(t)
+		command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+		dlg, err := delegation.Delegate(issuer, audience, nil, command, delegation.WithPowerline())
+		require.NoError(t, err)
+		require.Nil(t, dlg.Subject())
+		require.True(t, delegation.IsPowerline(dlg))
+
+		t.Run("CBOR", func(t *testing.T) {
+			encoded, err := delegation.Encode(dlg)
+			require.NoError(t, err)
+
+			decoded, err := delegation.Decode(encoded)
+			require.NoError(t, err)
+			require.Equal(t, dlg.Link(), decoded.Link())
+			require.Nil(t, decoded.Subject())
+			require.True(t, delegation.IsPowerline(decoded))
+
+			ok, err := delegation.VerifySignature(decoded, issuer.Verifier())
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+
+		t.Run("DAG-JSON", func(t *testing.T) {
+			var buf bytes.Buffer
+			err := dlg.MarshalDagJSON(&buf)
+			require.NoError(t, err)
+			require.Contains(t, buf.String(), `"sub":null`)
+
+			var decoded delegation.Delegation
+			err = decoded.UnmarshalDagJSON(&buf)
+			require.NoError(t, err)
+			require.Equal(t, dlg.Link(), decoded.Link())
+			require.Nil(t, decoded.Subject())
+
+			ok, err := delegation.VerifySignature(&decoded, issuer.Verifier())
+			require.NoError(t, err)
+			require.True(t, ok)
+		})
+	})
+
+	t.Run("powerline with subject", func(t *testing.T) {
+		issuer := testutil.RandomSigner(t)
+		audience := testutil.RandomDID(t)
+		command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+		_, err := delegation.Delegate(issuer, audience, issuer, command, delegation.WithPowerline())
+		require.Error(t, err)
+	})
+	t.Run("nil subject without powerline option", func(t *testing.T) {
+		issuer := testutil.RandomSigner(t)
+		audience := testutil.RandomDID(t)
+		command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+		dlg, err := delegation.Delegate(issuer, audience, nil, command)
+		require.NoError(t, err)
+		require.Nil(t, dlg.Subject())
+	})
 }
 
 type PayloadModel struct {
@@ -140,3 +211,91 @@ func TestFixtures(t *testing.T) {
 		})
 	}
 }
+
+func TestStrictDecode(t *testing.T) {
+	issuer := testutil.RandomSigner(t)
+	audience := testutil.RandomDID(t)
+	command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg, err := delegation.Delegate(issuer, audience, nil, command)
+	require.NoError(t, err)
+
+	// re-encode the envelope with the signature payload keys in reverse order
+	var envelope datamodel.Any
+	require.NoError(t, envelope.UnmarshalCBOR(bytes.NewReader(dlg.Bytes())))
+	parts := envelope.Value.([]any)
+	sigPayload := parts[1].(map[string]ipld.Any)
+
+	var buf bytes.Buffer
+	cw := cbg.NewCborWriter(&buf)
+	require.NoError(t, cw.WriteMajorTypeHeader(cbg.MajArray, 2))
+	require.NoError(t, datamodel.NewAny(parts[0]).MarshalCBOR(&buf))
+	require.NoError(t, cw.WriteMajorTypeHeader(cbg.MajMap, uint64(len(sigPayload))))
+	keys := slices.Sorted(maps.Keys(sigPayload))
+	slices.Reverse(keys)
+	for _, k := range keys {
+		require.NoError(t, cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len(k))))
+		_, err := cw.WriteString(k)
+		require.NoError(t, err)
+		require.NoError(t, datamodel.NewAny(sigPayload[k]).MarshalCBOR(&buf))
+	}
+	nonCanonical := buf.Bytes()
+
+	t.Run("rejects non-canonical", func(t *testing.T) {
+		_, err := delegation.Decode(nonCanonical)
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+
+		var decoded delegation.Delegation
+		err = decoded.UnmarshalCBOR(bytes.NewReader(nonCanonical))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("rejects trailing bytes", func(t *testing.T) {
+		_, err := delegation.Decode(append(slices.Clone(dlg.Bytes()), 0x00))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("non-strict", func(t *testing.T) {
+		decoded, err := delegation.Decode(nonCanonical, delegation.WithStrict(false))
+		require.NoError(t, err)
+		require.Equal(t, dlg.Issuer().DID(), decoded.Issuer().DID())
+		// the same token, but a different CID
+		require.NotEqual(t, dlg.Link(), decoded.Link())
+	})
+
+	t.Run("non-strict rejects trailing bytes", func(t *testing.T) {
+		_, err := delegation.Decode(append(slices.Clone(nonCanonical), 0x00), delegation.WithStrict(false))
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+	})
+
+	t.Run("non-strict copies bytes", func(t *testing.T) {
+		input := slices.Clone(nonCanonical)
+		decoded, err := delegation.Decode(input, delegation.WithStrict(false))
+		require.NoError(t, err)
+		input[0] = 0x00
+		require.Equal(t, nonCanonical, decoded.Bytes())
+	})
+}
+
+func TestDecodeLimits(t *testing.T) {
+	issuer := testutil.RandomSigner(t)
+	subject := testutil.RandomDID(t)
+	command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	stmt := policy.Equal(".foo", "bar")
+	for range limits.Default.MaxPolicyDepth + 10 {
+		stmt = policy.Not(stmt)
+	}
+	dlg, err := delegation.Delegate(issuer, subject, issuer, command, delegation.WithPolicyBuilder(stmt))
+	require.NoError(t, err)
+
+	_, err = delegation.Decode(dlg.Bytes())
+	require.ErrorContains(t, err, limits.PolicyDepthLimit)
+
+	_, err = delegation.Decode(dlg.Bytes(), delegation.WithLimits(limits.Limits{MaxNesting: 8}))
+	require.ErrorContains(t, err, limits.NestingLimit)
+
+	decoded, err := delegation.Decode(dlg.Bytes(), delegation.WithLimits(limits.Unlimited))
+	require.NoError(t, err)
+	require.Equal(t, dlg.Link(), decoded.Link())
+}
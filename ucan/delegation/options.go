@@ -2,6 +2,7 @@ package delegation
 
 import (
 	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 )
@@ -10,13 +11,14 @@ import (
 type Option func(cfg *delegationConfig) error
 
 type delegationConfig struct {
-	exp   *ucan.UTCUnixTimestamp
-	nbf   *ucan.UTCUnixTimestamp
-	noexp bool
-	nnc   []byte
-	nonnc bool
-	meta  ipld.Map
-	pol   policy.Policy
+	exp       *ucan.UTCUnixTimestamp
+	nbf       *ucan.UTCUnixTimestamp
+	noexp     bool
+	nnc       []byte
+	nonnc     bool
+	meta      ipld.Map
+	pol       policy.Policy
+	powerline bool
 }
 
 // WithExpiration configures the expiration time in UTC seconds since Unix
@@ -65,6 +67,21 @@ func WithNotBefore(nbf ucan.UTCUnixTimestamp) Option {
 	}
 }
 
+// WithPowerline configures the delegation to be a "powerline" delegation, which
+// has a null subject. A powerline delegation automatically delegates any
+// capability for the command that the issuer has, or later receives, to the
+// audience, regardless of the subject. The subject passed to [Delegate] must be
+// nil. A nil subject creates a powerline delegation even without this option;
+// passing it makes the intent explicit.
+//
+// https://github.com/ucan-wg/delegation/blob/main/README.md#powerline
+func WithPowerline() Option {
+	return func(cfg *delegationConfig) error {
+		cfg.powerline = true
+		return nil
+	}
+}
+
 // WithMetadata configures the arbitrary metadata for the UCAN.
 func WithMetadata(meta ipld.Map) Option {
 	return func(cfg *delegationConfig) error {
@@ -96,3 +113,33 @@ func WithPolicyBuilder(statements ...policy.StatementBuilderFunc) Option {
 		return nil
 	}
 }
+
+// DecodeOption is an option configuring how a UCAN delegation is decoded.
+type DecodeOption func(cfg *decodeConfig)
+
+type decodeConfig struct {
+	strict bool
+	limits limits.Limits
+}
+
+// WithStrict configures whether the encoded delegation must be in the canonical
+// DAG-CBOR form. This applies to the whole token, including arguments and
+// metadata. Strict decoding is enabled by default. When disabled, the token is
+// decoded from its canonical form, but the bytes and link are those of the
+// data as it was received.
+func WithStrict(enabled bool) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.strict = enabled
+	}
+}
+
+// WithLimits configures the limits enforced when decoding the delegation: the
+// nesting depth of lists and maps, and the depth and number of statements in
+// the policy. Zero values in the limits are replaced by the defaults, and
+// [limits.Default] is enforced when this option is not used. Pass
+// [limits.Unlimited] to decode a delegation from a trusted source.
+func WithLimits(l limits.Limits) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.limits = l.OrDefault()
+	}
+}
@@ -0,0 +1,297 @@
+// Code generated by github.com/alanshaw/dag-json-gen. DO NOT EDIT.
+
+package datamodel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	jsg "github.com/alanshaw/dag-json-gen"
+	cid "github.com/ipfs/go-cid"
+)
+
+var _ = cid.Undef
+var _ = math.E
+var _ = sort.Sort
+var _ = errors.Is
+
+func (t *ParseErrorModel) MarshalDagJSON(w io.Writer) error {
+	jw := jsg.NewDagJsonWriter(w)
+	if t == nil {
+		err := jw.WriteNull()
+		return err
+	}
+	if err := jw.WriteObjectOpen(); err != nil {
+		return err
+	}
+	written := 0
+
+	// t.Column (int64) (int64)
+	if len("column") > 8192 {
+		return fmt.Errorf("String in field \"column\" was too long")
+	}
+	if err := jw.WriteString(string("column")); err != nil {
+		return fmt.Errorf("\"column\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+
+	if err := jw.WriteInt64(int64(t.Column)); err != nil {
+		return fmt.Errorf("t.Column: %w", err)
+	}
+
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Line (int64) (int64)
+	if len("line") > 8192 {
+		return fmt.Errorf("String in field \"line\" was too long")
+	}
+	if err := jw.WriteString(string("line")); err != nil {
+		return fmt.Errorf("\"line\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+
+	if err := jw.WriteInt64(int64(t.Line)); err != nil {
+		return fmt.Errorf("t.Line: %w", err)
+	}
+
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Message (string) (string)
+	if len("message") > 8192 {
+		return fmt.Errorf("String in field \"message\" was too long")
+	}
+	if err := jw.WriteString(string("message")); err != nil {
+		return fmt.Errorf("\"message\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Message) > 8192 {
+		return fmt.Errorf("String in field t.Message was too long")
+	}
+	if err := jw.WriteString(string(t.Message)); err != nil {
+		return fmt.Errorf("t.Message: %w", err)
+	}
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Name (string) (string)
+	if len("name") > 8192 {
+		return fmt.Errorf("String in field \"name\" was too long")
+	}
+	if err := jw.WriteString(string("name")); err != nil {
+		return fmt.Errorf("\"name\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Name) > 8192 {
+		return fmt.Errorf("String in field t.Name was too long")
+	}
+	if err := jw.WriteString(string(t.Name)); err != nil {
+		return fmt.Errorf("t.Name: %w", err)
+	}
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Source (string) (string)
+	if len("source") > 8192 {
+		return fmt.Errorf("String in field \"source\" was too long")
+	}
+	if err := jw.WriteString(string("source")); err != nil {
+		return fmt.Errorf("\"source\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Source) > 8192 {
+		return fmt.Errorf("String in field t.Source was too long")
+	}
+	if err := jw.WriteString(string(t.Source)); err != nil {
+		return fmt.Errorf("t.Source: %w", err)
+	}
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Token (string) (string)
+	if len("token") > 8192 {
+		return fmt.Errorf("String in field \"token\" was too long")
+	}
+	if err := jw.WriteString(string("token")); err != nil {
+		return fmt.Errorf("\"token\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Token) > 8192 {
+		return fmt.Errorf("String in field t.Token was too long")
+	}
+	if err := jw.WriteString(string(t.Token)); err != nil {
+		return fmt.Errorf("t.Token: %w", err)
+	}
+	written++
+	if err := jw.WriteObjectClose(); err != nil {
+		return err
+	}
+	return nil
+}
+func (t *ParseErrorModel) UnmarshalDagJSON(r io.Reader) (err error) {
+	*t = ParseErrorModel{}
+
+	jr := jsg.NewDagJsonReader(r)
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	if err := jr.ReadObjectOpen(); err != nil {
+		return fmt.Errorf("ParseErrorModel: %w", err)
+	}
+	close, err := jr.PeekObjectClose()
+	if err != nil {
+		return fmt.Errorf("ParseErrorModel: %w", err)
+	}
+	if close {
+		if err := jr.ReadObjectClose(); err != nil {
+			return fmt.Errorf("ParseErrorModel: %w", err)
+		}
+	} else {
+		for i := uint64(0); i < 8192; i++ {
+			name, err := jr.ReadString(8192)
+			if err != nil {
+				if errors.Is(err, jsg.ErrLimitExceeded) {
+					return fmt.Errorf("ParseErrorModel: string too large")
+				}
+				return fmt.Errorf("ParseErrorModel: %w", err)
+			}
+			if err := jr.ReadObjectColon(); err != nil {
+				return fmt.Errorf("ParseErrorModel: %w", err)
+			}
+			switch name {
+
+			// t.Column (int64) (int64)
+			case "column":
+				{
+
+					nval, err := jr.ReadNumberAsInt64()
+					if err != nil {
+						return fmt.Errorf("t.Column: %w", err)
+					}
+					t.Column = int64(nval)
+
+				}
+
+				// t.Line (int64) (int64)
+			case "line":
+				{
+
+					nval, err := jr.ReadNumberAsInt64()
+					if err != nil {
+						return fmt.Errorf("t.Line: %w", err)
+					}
+					t.Line = int64(nval)
+
+				}
+
+				// t.Message (string) (string)
+			case "message":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Message: string too long")
+						}
+						return fmt.Errorf("t.Message: %w", err)
+					}
+					t.Message = string(sval)
+				}
+
+				// t.Name (string) (string)
+			case "name":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Name: string too long")
+						}
+						return fmt.Errorf("t.Name: %w", err)
+					}
+					t.Name = string(sval)
+				}
+
+				// t.Source (string) (string)
+			case "source":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Source: string too long")
+						}
+						return fmt.Errorf("t.Source: %w", err)
+					}
+					t.Source = string(sval)
+				}
+
+				// t.Token (string) (string)
+			case "token":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Token: string too long")
+						}
+						return fmt.Errorf("t.Token: %w", err)
+					}
+					t.Token = string(sval)
+				}
+			default:
+				// Field doesn't exist on this type, so ignore it
+				if err := jr.DiscardType(); err != nil {
+					return fmt.Errorf("ParseErrorModel: ignoring field %s: %w", name, err)
+				}
+			}
+
+			close, err := jr.ReadObjectCloseOrComma()
+			if err != nil {
+				return fmt.Errorf("ParseErrorModel: %w", err)
+			}
+			if close {
+				break
+			}
+			if i == 8192-1 {
+				return fmt.Errorf("ParseErrorModel: map too large")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package datamodel
+
+type ParseErrorModel struct {
+	Name    string `cborgen:"name" dagjsongen:"name"`
+	Message string `cborgen:"message" dagjsongen:"message"`
+	Source  string `cborgen:"source" dagjsongen:"source"`
+	Line    int64  `cborgen:"line" dagjsongen:"line"`
+	Column  int64  `cborgen:"column" dagjsongen:"column"`
+	Token   string `cborgen:"token" dagjsongen:"token"`
+}
+
+func (pe ParseErrorModel) Error() string {
+	return pe.Message
+}
+
+var _ error = (*ParseErrorModel)(nil)
@@ -0,0 +1,20 @@
+package main
+
+import (
+	jsg "github.com/alanshaw/dag-json-gen"
+	ddm "github.com/alanshaw/ucantone/ucan/delegation/policy/dsl/datamodel"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func main() {
+	if err := cbg.WriteMapEncodersToFile("../cbor_gen.go", "datamodel",
+		ddm.ParseErrorModel{},
+	); err != nil {
+		panic(err)
+	}
+	if err := jsg.WriteMapEncodersToFile("../dag_json_gen.go", "datamodel",
+		ddm.ParseErrorModel{},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,480 @@
+package dsl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy/selector"
+	sdm "github.com/alanshaw/ucantone/ucan/delegation/policy/selector/datamodel"
+)
+
+// node is a parsed policy statement.
+type node struct {
+	op       string
+	selector string
+	value    any
+	pattern  string
+	child    *node
+	children []*node
+	// infix flags that a conjunction or disjunction was written without
+	// parentheses e.g. `a and b`.
+	infix bool
+}
+
+type parser struct {
+	lexer
+	toks []token
+	pos  int
+}
+
+// Parse parses a policy written in the policy language. The language is a
+// textual representation of the UCAN policy language, for example:
+//
+//	all .to like "*@example.com" and .size <= 1024
+//
+// Statements are written as follows:
+//
+//   - Comparison: `.size <= 1024`, where the operator is one of `==`, `!=`,
+//     `>`, `>=`, `<` or `<=` and the value is DAG-JSON (or `null`, `true` or
+//     `false`).
+//   - Wildcard: `.to like "*@example.com"`.
+//   - Negation: `not .draft == true`.
+//   - Conjunction: `a and b`, or in prefix form `and(a, b)`.
+//   - Disjunction: `a or b`, or in prefix form `or(a, b)`.
+//   - Quantification: `all .to like "*@example.com"` or `any .tags == "x"`.
+//
+// The selector of a comparison or wildcard may be omitted, in which case it is
+// the identity selector `.`, for example `all .to like "*@example.com"`.
+//
+// `and` binds tighter than `or` and parentheses may be used for grouping. A
+// conjunction at the top level is a policy of multiple statements. A
+// parenthesized conjunction at the top level is a single "and" statement.
+//
+// Syntax errors are named [ParseErrorName] and report the 1-based line and
+// column of the offending token.
+func Parse(input string) (policy.Policy, error) {
+	p := parser{lexer: lexer{src: input}}
+	toks, err := p.tokenize()
+	if err != nil {
+		return policy.Policy{}, err
+	}
+	p.toks = toks
+
+	var nodes []*node
+	if p.peek().kind != tokenEOF {
+		n, err := p.parseOr()
+		if err != nil {
+			return policy.Policy{}, err
+		}
+		if tok := p.peek(); tok.kind != tokenEOF {
+			return policy.Policy{}, p.unexpected(tok)
+		}
+		if n.op == policy.OpAnd && n.infix {
+			nodes = n.children
+		} else {
+			nodes = []*node{n}
+		}
+	}
+
+	builders := make([]policy.StatementBuilderFunc, 0, len(nodes))
+	for _, n := range nodes {
+		builders = append(builders, build(n))
+	}
+	return policy.Build(builders...)
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.toks[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return p.errorf(tok.offset, "", "unexpected end of input")
+	}
+	return p.errorf(tok.offset, tok.text, "unexpected token %q", tok.text)
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	tok := p.advance()
+	if tok.kind != kind {
+		return token{}, p.unexpected(tok)
+	}
+	return tok, nil
+}
+
+func (p *parser) isKeyword(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokenKeyword && tok.text == text
+}
+
+func (p *parser) parseOr() (*node, error) {
+	return p.parseInfix(policy.OpOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	return p.parseInfix(policy.OpAnd, p.parseUnary)
+}
+
+func (p *parser) parseInfix(op string, operand func() (*node, error)) (*node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isKeyword(op) {
+		return left, nil
+	}
+	n := &node{op: op, children: []*node{left}, infix: true}
+	for p.isKeyword(op) {
+		p.advance()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, right)
+	}
+	return n, nil
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	if p.isKeyword(policy.OpNot) {
+		p.advance()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{op: policy.OpNot, child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenLParen:
+		p.advance()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen); err != nil {
+			return nil, err
+		}
+		n.infix = false
+		return n, nil
+	case tokenSelector, tokenOperator:
+		return p.parseComparison()
+	case tokenKeyword:
+		switch tok.text {
+		case policy.OpAnd, policy.OpOr:
+			return p.parsePrefix()
+		case policy.OpAll, policy.OpAny:
+			p.advance()
+			sel, err := p.parseSelector()
+			if err != nil {
+				return nil, err
+			}
+			child, err := p.parseUnary()
+			if err != nil {
+				return nil, err
+			}
+			return &node{op: tok.text, selector: sel, child: child}, nil
+		case policy.OpLike:
+			return p.parseComparison()
+		}
+	}
+	return nil, p.unexpected(tok)
+}
+
+// parsePrefix parses a conjunction or disjunction in prefix form e.g.
+// `and(a, b)`.
+func (p *parser) parsePrefix() (*node, error) {
+	op := p.advance().text
+	if _, err := p.expect(tokenLParen); err != nil {
+		return nil, err
+	}
+	n := &node{op: op, children: []*node{}}
+	if p.peek().kind == tokenRParen {
+		p.advance()
+		return n, nil
+	}
+	for {
+		child, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		n.children = append(n.children, child)
+		tok := p.advance()
+		if tok.kind == tokenRParen {
+			return n, nil
+		}
+		if tok.kind != tokenComma {
+			return nil, p.unexpected(tok)
+		}
+	}
+}
+
+func (p *parser) parseSelector() (string, error) {
+	tok, err := p.expect(tokenSelector)
+	if err != nil {
+		return "", err
+	}
+	if _, err := selector.Parse(tok.text); err != nil {
+		var perr sdm.ParseErrorModel
+		if errors.As(err, &perr) {
+			offset := tok.offset + min(int(perr.Column), len(tok.text))
+			return "", p.errorf(offset, perr.Token, "invalid selector: %s", perr.Message)
+		}
+		return "", p.errorf(tok.offset, tok.text, "invalid selector: %s", err.Error())
+	}
+	return tok.text, nil
+}
+
+func (p *parser) parseComparison() (*node, error) {
+	sel := "."
+	if p.peek().kind == tokenSelector {
+		var err error
+		sel, err = p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.isKeyword(policy.OpLike) {
+		p.advance()
+		tok, err := p.expect(tokenValue)
+		if err != nil {
+			return nil, err
+		}
+		v, err := p.parseValue(tok)
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := v.(string)
+		if !ok {
+			return nil, p.errorf(tok.offset, tok.text, "pattern is not a string")
+		}
+		return &node{op: policy.OpLike, selector: sel, pattern: pattern}, nil
+	}
+	op, err := p.expect(tokenOperator)
+	if err != nil {
+		return nil, err
+	}
+	tok := p.advance()
+	var value any
+	switch {
+	case tok.kind == tokenValue:
+		value, err = p.parseValue(tok)
+		if err != nil {
+			return nil, err
+		}
+	case tok.kind == tokenKeyword && tok.text == "null":
+		value = nil
+	case tok.kind == tokenKeyword && tok.text == "true":
+		value = true
+	case tok.kind == tokenKeyword && tok.text == "false":
+		value = false
+	default:
+		return nil, p.unexpected(tok)
+	}
+	return &node{op: op.text, selector: sel, value: value}, nil
+}
+
+func (p *parser) parseValue(tok token) (any, error) {
+	var a datamodel.Any
+	if err := a.UnmarshalDagJSON(strings.NewReader(tok.text)); err != nil {
+		return nil, p.errorf(tok.offset, tok.text, "invalid value: %s", err.Error())
+	}
+	return a.Value, nil
+}
+
+func build(n *node) policy.StatementBuilderFunc {
+	switch n.op {
+	case policy.OpEqual:
+		return policy.Equal(n.selector, n.value)
+	case policy.OpNotEqual:
+		return policy.NotEqual(n.selector, n.value)
+	case policy.OpGreaterThan:
+		return policy.GreaterThan(n.selector, n.value)
+	case policy.OpGreaterThanOrEqual:
+		return policy.GreaterThanOrEqual(n.selector, n.value)
+	case policy.OpLessThan:
+		return policy.LessThan(n.selector, n.value)
+	case policy.OpLessThanOrEqual:
+		return policy.LessThanOrEqual(n.selector, n.value)
+	case policy.OpLike:
+		return policy.Like(n.selector, n.pattern)
+	case policy.OpNot:
+		return policy.Not(build(n.child))
+	case policy.OpAll:
+		return policy.All(n.selector, build(n.child))
+	case policy.OpAny:
+		return policy.Any(n.selector, build(n.child))
+	case policy.OpAnd:
+		return policy.And(buildAll(n.children)...)
+	case policy.OpOr:
+		return policy.Or(buildAll(n.children)...)
+	}
+	panic(fmt.Errorf("unknown statement operator: %s", n.op))
+}
+
+func buildAll(nodes []*node) []policy.StatementBuilderFunc {
+	builders := make([]policy.StatementBuilderFunc, 0, len(nodes))
+	for _, n := range nodes {
+		builders = append(builders, build(n))
+	}
+	return builders
+}
+
+// placement of a statement being formatted, which determines whether it needs
+// to be parenthesized.
+type placement int
+
+const (
+	// the only statement of the policy
+	placementRoot placement = iota
+	// an operand of an infix "and"
+	placementAnd
+	// an operand of an infix "or"
+	placementOr
+	// an operand of "not" or the body of a quantifier
+	placementUnary
+	// the body of a quantifier (or the operand of "not" within it), where the
+	// identity selector may be omitted
+	placementBody
+	// an item in a prefix "and" or "or"
+	placementList
+)
+
+// Format formats the policy in the policy language. The result can be parsed
+// by [Parse] to produce an identical policy.
+func Format(pol ucan.Policy) (string, error) {
+	p, err := policy.New(pol.Statements()...)
+	if err != nil {
+		return "", err
+	}
+	stmts := p.Statements()
+	pos := placementRoot
+	if len(stmts) > 1 {
+		pos = placementAnd
+	}
+	parts := make([]string, 0, len(stmts))
+	for _, s := range stmts {
+		str, err := format(s.(policy.Statement), pos)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, str)
+	}
+	return strings.Join(parts, " and "), nil
+}
+
+func format(s policy.Statement, pos placement) (string, error) {
+	switch s.Operator() {
+	case policy.OpAnd, policy.OpOr:
+		children := s.Argument().([]*policy.Statement)
+		if len(children) < 2 {
+			parts := make([]string, 0, len(children))
+			for _, c := range children {
+				str, err := format(*c, placementList)
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, str)
+			}
+			return fmt.Sprintf("%s(%s)", s.Operator(), strings.Join(parts, ", ")), nil
+		}
+		childPos := placementAnd
+		if s.Operator() == policy.OpOr {
+			childPos = placementOr
+		}
+		parts := make([]string, 0, len(children))
+		for _, c := range children {
+			str, err := format(*c, childPos)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, str)
+		}
+		str := strings.Join(parts, " "+s.Operator()+" ")
+		// "and" binds tighter than "or", so only an "or" needs parentheses
+		// when it is an item of an infix "or". A top level "and" is always
+		// enclosed, since otherwise it would be parsed as separate statements.
+		parens := pos == placementAnd || pos == placementUnary || pos == placementBody
+		switch s.Operator() {
+		case policy.OpAnd:
+			parens = parens || pos == placementRoot
+		case policy.OpOr:
+			parens = parens || pos == placementOr
+		}
+		if parens {
+			str = "(" + str + ")"
+		}
+		return str, nil
+	case policy.OpNot:
+		childPos := placementUnary
+		if pos == placementBody {
+			childPos = placementBody
+		}
+		str, err := format(*s.Argument().(*policy.Statement), childPos)
+		if err != nil {
+			return "", err
+		}
+		return "not " + str, nil
+	case policy.OpAll, policy.OpAny:
+		str, err := format(*s.Argument().(*policy.Statement), placementBody)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", s.Operator(), s.Selector(), str), nil
+	case policy.OpLike:
+		pattern, err := formatValue(s.Argument())
+		if err != nil {
+			return "", err
+		}
+		return formatComparison(s.Selector(), policy.OpLike, pattern, pos), nil
+	case policy.OpEqual, policy.OpNotEqual, policy.OpGreaterThan, policy.OpGreaterThanOrEqual, policy.OpLessThan, policy.OpLessThanOrEqual:
+		value, err := formatValue(s.Argument())
+		if err != nil {
+			return "", err
+		}
+		return formatComparison(s.Selector(), s.Operator(), value, pos), nil
+	}
+	return "", fmt.Errorf("unknown statement operator: %s", s.Operator())
+}
+
+func formatComparison(sel string, op string, value string, pos placement) string {
+	if pos == placementBody && sel == "." {
+		return op + " " + value
+	}
+	return sel + " " + op + " " + value
+}
+
+func formatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	}
+	var buf bytes.Buffer
+	a := datamodel.Any{Value: value}
+	if err := a.MarshalDagJSON(&buf); err != nil {
+		return "", fmt.Errorf("formatting value: %w", err)
+	}
+	return buf.String(), nil
+}
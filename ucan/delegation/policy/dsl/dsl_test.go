@@ -0,0 +1,165 @@
+package dsl_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy/dsl"
+	ddm "github.com/alanshaw/ucantone/ucan/delegation/policy/dsl/datamodel"
+	fdm "github.com/alanshaw/ucantone/ucan/delegation/policy/internal/fixtures/datamodel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{``, `[]`},
+		{`.size <= 1024`, `[["<=",".size",1024]]`},
+		{`.amount > 0.5 and .amount <= 1e3`, `[[">",".amount",0.5],["<=",".amount",1000.0]]`},
+		{
+			`all .to like "*@example.com" and .size <= 1024`,
+			`[["all",".to",["like",".","*@example.com"]],["<=",".size",1024]]`,
+		},
+		{`.a == 1 or .b == 2 and .c == 3`, `[["or",[["==",".a",1],["and",[["==",".b",2],["==",".c",3]]]]]]`},
+		{`(.a == 1 or .b == 2) and .c == 3`, `[["or",[["==",".a",1],["==",".b",2]]],["==",".c",3]]`},
+		{`(.a == 1 and .b == 2)`, `[["and",[["==",".a",1],["==",".b",2]]]]`},
+		{`.a == 1 and (.b == 2 and .c == 3)`, `[["==",".a",1],["and",[["==",".b",2],["==",".c",3]]]]`},
+		{`and()`, `[["and",[]]]`},
+		{`or(.a == 1)`, `[["or",[["==",".a",1]]]]`},
+		{`not .draft == true`, `[["not",["==",".draft",true]]]`},
+		{`not not .a != null`, `[["not",["not",["!=",".a",null]]]]`},
+		{`any .tags (== "a" or == "b")`, `[["any",".tags",["or",[["==",".","a"],["==",".","b"]]]]]`},
+		{`all .a any . > -1`, `[["all",".a",["any",".",[">",".",-1]]]]`},
+		{`.a == [1, 2, {"b": 3}]`, `[["==",".a",[1,2,{"b":3}]]]`},
+		{`.["a b"][0]? >= 5`, `[[">=",".[\"a b\"][0]?",5]]`},
+		{".a==1\nand\n.b<2", `[["==",".a",1],["<",".b",2]]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			pol, err := dsl.Parse(tc.input)
+			require.NoError(t, err)
+
+			expected, err := policy.Parse(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, encode(t, expected), encode(t, pol))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		input  string
+		line   int64
+		column int64
+		token  string
+	}{
+		{`.a ==`, 1, 6, ""},
+		{`.a = 1`, 1, 4, "="},
+		{`.a == 1 and`, 1, 12, ""},
+		{`.a == 1 .b == 2`, 1, 9, ".b"},
+		{".a == 1 and\n  .b ~ 2", 2, 6, "~"},
+		{".a == 1 and\n  (.b == 2", 2, 11, ""},
+		{`.a like 5`, 1, 9, "5"},
+		{`.a == "oops`, 1, 7, `"oops`},
+		{`all .a[x] == 1`, 1, 7, "[x]"},
+		{`and(.a == 1 .b == 2)`, 1, 13, ".b"},
+		{`.a == nope`, 1, 7, "nope"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := dsl.Parse(tc.input)
+			require.Error(t, err)
+			t.Log(err)
+
+			var perr ddm.ParseErrorModel
+			require.True(t, errors.As(err, &perr))
+			require.Equal(t, dsl.ParseErrorName, perr.Name)
+			require.Equal(t, tc.input, perr.Source)
+			require.Equal(t, tc.line, perr.Line)
+			require.Equal(t, tc.column, perr.Column)
+			require.Equal(t, tc.token, perr.Token)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		expected string
+	}{
+		{`[]`, ``},
+		{
+			`[["all",".to",["like",".","*@example.com"]],["<=",".size",1024]]`,
+			`all .to like "*@example.com" and .size <= 1024`,
+		},
+		{`[["or",[["==",".a",1],["==",".b",2]]]]`, `.a == 1 or .b == 2`},
+		{`[["or",[["==",".a",1],["==",".b",2]]],["==",".c",3]]`, `(.a == 1 or .b == 2) and .c == 3`},
+		{`[["and",[["==",".a",1],["==",".b",2]]]]`, `(.a == 1 and .b == 2)`},
+		{`[["or",[["and",[["==",".a",1],["==",".b",2]]],["==",".c",3]]]]`, `.a == 1 and .b == 2 or .c == 3`},
+		{`[["or",[]]]`, `or()`},
+		{`[["<",".amount",2.0]]`, `.amount < 2.0`},
+		{`[["not",["==",".draft",null]]]`, `not .draft == null`},
+		{`[["any",".tags",["not",["==",".","a"]]]]`, `any .tags not == "a"`},
+		{`[["any",".tags",["or",[["==",".","a"],["==",".","b"]]]]]`, `any .tags (. == "a" or . == "b")`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			pol, err := policy.Parse(tc.policy)
+			require.NoError(t, err)
+
+			str, err := dsl.Format(pol)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, str)
+		})
+	}
+}
+
+func TestRoundtripFixtures(t *testing.T) {
+	fixturesFile, err := os.Open("../internal/fixtures/policy.json")
+	require.NoError(t, err)
+
+	var fixtures fdm.FixturesModel
+	err = fixtures.UnmarshalDagJSON(fixturesFile)
+	require.NoError(t, err)
+
+	var policies []policy.Policy
+	for _, vector := range fixtures.Valid {
+		policies = append(policies, vector.Policies...)
+	}
+	for _, vector := range fixtures.Invalid {
+		policies = append(policies, vector.Policies...)
+	}
+
+	for i, pol := range policies {
+		t.Run(fmt.Sprintf("policy %d", i), func(t *testing.T) {
+			str, err := dsl.Format(pol)
+			require.NoError(t, err)
+			t.Log(str)
+
+			parsed, err := dsl.Parse(str)
+			require.NoError(t, err)
+			require.Equal(t, encode(t, pol), encode(t, parsed))
+		})
+	}
+}
+
+func encode(t *testing.T, pol policy.Policy) string {
+	var buf bytes.Buffer
+	require.NoError(t, pol.MarshalDagJSON(&buf))
+	// decode and re-encode so that values have consistent types
+	var m datamodel.Any
+	require.NoError(t, m.UnmarshalDagJSON(bytes.NewReader(buf.Bytes())))
+	buf.Reset()
+	require.NoError(t, m.MarshalDagJSON(&buf))
+	return buf.String()
+}
@@ -0,0 +1,20 @@
+package dsl
+
+import (
+	"fmt"
+
+	ddm "github.com/alanshaw/ucantone/ucan/delegation/policy/dsl/datamodel"
+)
+
+const ParseErrorName = "ParseError"
+
+func NewParseError(message string, source string, line int, column int, token string) ddm.ParseErrorModel {
+	return ddm.ParseErrorModel{
+		Name:    ParseErrorName,
+		Message: fmt.Sprintf("%s at line %d, column %d", message, line, column),
+		Source:  source,
+		Line:    int64(line),
+		Column:  int64(column),
+		Token:   token,
+	}
+}
@@ -0,0 +1,201 @@
+package dsl
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenKeyword
+	tokenOperator
+	tokenSelector
+	tokenValue
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// offset is the byte offset of the token in the source.
+	offset int
+}
+
+// lexer splits the policy source into tokens.
+type lexer struct {
+	src    string
+	offset int
+}
+
+// position returns the 1-based line and column of the byte offset in the
+// source. Columns are counted in runes.
+func position(src string, offset int) (int, int) {
+	line, col := 1, 1
+	for _, r := range src[:offset] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return line, col
+}
+
+func (l *lexer) errorf(offset int, tok string, format string, args ...any) error {
+	line, col := position(l.src, offset)
+	return NewParseError(fmt.Sprintf(format, args...), l.src, line, col, tok)
+}
+
+func (l *lexer) tokenize() ([]token, error) {
+	var toks []token
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, tok)
+		if tok.kind == tokenEOF {
+			return toks, nil
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	for l.offset < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.offset:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.offset += size
+	}
+	start := l.offset
+	if start >= len(l.src) {
+		return token{kind: tokenEOF, offset: start}, nil
+	}
+
+	c := l.src[start]
+	switch {
+	case c == '(':
+		l.offset++
+		return token{tokenLParen, "(", start}, nil
+	case c == ')':
+		l.offset++
+		return token{tokenRParen, ")", start}, nil
+	case c == ',':
+		l.offset++
+		return token{tokenComma, ",", start}, nil
+	case c == '=' || c == '!' || c == '<' || c == '>':
+		for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+			if strings.HasPrefix(l.src[start:], op) {
+				l.offset += len(op)
+				return token{tokenOperator, op, start}, nil
+			}
+		}
+		return token{}, l.errorf(start, string(c), "invalid operator")
+	case c == '.':
+		return l.selector()
+	case c == '"':
+		if err := l.skipString(); err != nil {
+			return token{}, err
+		}
+		return token{tokenValue, l.src[start:l.offset], start}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		for l.offset < len(l.src) && strings.IndexByte("-+.eE0123456789", l.src[l.offset]) >= 0 {
+			l.offset++
+		}
+		return token{tokenValue, l.src[start:l.offset], start}, nil
+	case c == '[' || c == '{':
+		if err := l.skipComposite(); err != nil {
+			return token{}, err
+		}
+		return token{tokenValue, l.src[start:l.offset], start}, nil
+	case c >= 'a' && c <= 'z':
+		for l.offset < len(l.src) && l.src[l.offset] >= 'a' && l.src[l.offset] <= 'z' {
+			l.offset++
+		}
+		return token{tokenKeyword, l.src[start:l.offset], start}, nil
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[start:])
+	return token{}, l.errorf(start, string(r), "unexpected character %q", r)
+}
+
+// selector scans a selector, which ends at whitespace, a parenthesis, a comma,
+// an operator or a string, unless it is within square brackets.
+func (l *lexer) selector() (token, error) {
+	start := l.offset
+	depth := 0
+	for l.offset < len(l.src) {
+		c := l.src[l.offset]
+		if depth == 0 && (strings.IndexByte("()=!<>,\"", c) >= 0 || c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			break
+		}
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '"':
+			if err := l.skipString(); err != nil {
+				return token{}, err
+			}
+			continue
+		}
+		l.offset++
+	}
+	if depth != 0 {
+		return token{}, l.errorf(start, l.src[start:l.offset], "unterminated selector")
+	}
+	return token{tokenSelector, l.src[start:l.offset], start}, nil
+}
+
+// skipString advances past a JSON string, starting at the opening quote.
+func (l *lexer) skipString() error {
+	start := l.offset
+	l.offset++
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case '\\':
+			l.offset += 2
+			continue
+		case '"':
+			l.offset++
+			return nil
+		}
+		l.offset++
+	}
+	l.offset = len(l.src)
+	return l.errorf(start, l.src[start:], "unterminated string")
+}
+
+// skipComposite advances past a JSON list or map, starting at the opening
+// bracket.
+func (l *lexer) skipComposite() error {
+	start := l.offset
+	depth := 0
+	for l.offset < len(l.src) {
+		switch l.src[l.offset] {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				l.offset++
+				return nil
+			}
+		case '"':
+			if err := l.skipString(); err != nil {
+				return err
+			}
+			continue
+		}
+		l.offset++
+	}
+	return l.errorf(start, l.src[start:], "unterminated value")
+}
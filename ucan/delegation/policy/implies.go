@@ -0,0 +1,727 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy/selector"
+)
+
+// Implication is the result of statically checking whether one policy implies
+// another.
+type Implication int
+
+const (
+	// Unknown indicates that it could not be decided whether the policy implies
+	// the other.
+	Unknown Implication = iota
+	// Implied indicates that every value that matches the policy also matches
+	// the other policy.
+	Implied
+	// NotImplied indicates that there is at least one value that matches the
+	// policy but does not match the other policy.
+	NotImplied
+)
+
+func (i Implication) String() string {
+	switch i {
+	case Implied:
+		return "implied"
+	case NotImplied:
+		return "not implied"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// maximum depth of case splitting on disjunctions
+	maxSplitDepth = 4
+	// maximum number of candidate values tried when searching for a
+	// counterexample
+	maxWitnesses = 4096
+	// maximum number of candidate items tried for quantifier elements
+	maxElementWitnesses = 16
+	// maximum number of proof steps taken before giving up
+	maxProofSteps = 4096
+)
+
+// Implies statically determines whether policy a implies policy b i.e. that
+// every invocation arguments that match a also match b. This is the case when
+// a is an attenuation of b, so can be used to check that a child delegation's
+// policy is at least as strict as it's parent's:
+//
+//	policy.Implies(child.Policy(), parent.Policy())
+//
+// Implication is proved using the structure of the policies, taking into
+// account equality, ordering, "like" globs, quantifiers ("all" and "any") and
+// connectives ("and", "or" and "not"). If it cannot be proved, a search is made
+// for arguments that match a but not b, in which case [NotImplied] is
+// returned. Otherwise the result is [Unknown].
+func Implies(a ucan.Policy, b ucan.Policy) (Implication, error) {
+	as, err := toStatements(a.Statements())
+	if err != nil {
+		return Unknown, err
+	}
+	bs, err := toStatements(b.Statements())
+	if err != nil {
+		return Unknown, err
+	}
+
+	p := newProver(maxProofSteps)
+	implied := true
+	for _, s := range bs {
+		if !p.implies(as, s, 0) {
+			implied = false
+			break
+		}
+	}
+	if implied {
+		return Implied, nil
+	}
+	if p.exhausted() {
+		return Unknown, nil
+	}
+
+	pa := Policy{as}
+	pb := Policy{bs}
+	for _, w := range witnesses(append(as, bs...), maxWitnesses) {
+		if _, ok := w.(ipld.Map); !ok {
+			continue
+		}
+		if ok, _ := Match(pa, w); !ok {
+			continue
+		}
+		if ok, _ := Match(pb, w); !ok {
+			return NotImplied, nil
+		}
+	}
+	return Unknown, nil
+}
+
+// toStatements converts the statements to [Statement]s, encoding and decoding
+// them so that values have the same types they would when the policy is
+// received in a delegation.
+func toStatements(stmts []ucan.Statement) ([]Statement, error) {
+	out := make([]Statement, 0, len(stmts))
+	for _, stmt := range stmts {
+		s, err := toStatement(stmt)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := s.MarshalCBOR(&buf); err != nil {
+			return nil, fmt.Errorf("encoding statement: %w", err)
+		}
+		var ns Statement
+		if err := ns.UnmarshalCBOR(&buf); err != nil {
+			return nil, fmt.Errorf("decoding statement: %w", err)
+		}
+		out = append(out, ns)
+	}
+	return out, nil
+}
+
+// flatten expands conjunctions into their statements.
+func flatten(stmts []Statement) []Statement {
+	var out []Statement
+	for _, s := range stmts {
+		if s.Operator() == OpAnd {
+			for _, ss := range s.statements {
+				out = append(out, flatten([]Statement{*ss})...)
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// prover searches for proofs of implication between statements. The number of
+// steps taken is limited, and the results for each set of premises and
+// statement are remembered, since the same proofs are attempted many times when
+// searching for contradictions between premises.
+type prover struct {
+	steps int
+	memo  map[string]bool
+}
+
+func newProver(steps int) *prover {
+	return &prover{steps: steps, memo: map[string]bool{}}
+}
+
+// exhausted reports whether the search gave up because it ran out of steps, in
+// which case a failed proof does not mean that there is no proof.
+func (p *prover) exhausted() bool {
+	return p.steps <= 0
+}
+
+// step takes a proof step, returning false if there are none left.
+func (p *prover) step() bool {
+	if p.steps <= 0 {
+		return false
+	}
+	p.steps--
+	return true
+}
+
+// memoKey returns the key of a proof attempt, which is the same for the same
+// set of premises regardless of their order.
+func memoKey(kind string, as []Statement, s Statement, depth int) string {
+	premises := make([]string, 0, len(as))
+	for _, a := range as {
+		premises = append(premises, statementKey(a))
+	}
+	slices.Sort(premises)
+	premises = slices.Compact(premises)
+	return fmt.Sprintf("%s/%d/%s/%s", kind, depth, strings.Join(premises, ","), statementKey(s))
+}
+
+// statementKey returns a string that is the same for equal statements.
+func statementKey(s Statement) string {
+	var buf bytes.Buffer
+	// statements are validated when created, so always encode
+	_ = s.MarshalCBOR(&buf)
+	return buf.String()
+}
+
+// implies returns true if the conjunction of the statements as is proved to
+// imply the statement s.
+func (p *prover) implies(as []Statement, s Statement, depth int) bool {
+	as = flatten(as)
+	key := memoKey("implies", as, s, depth)
+	if proved, ok := p.memo[key]; ok {
+		return proved
+	}
+	if !p.step() {
+		return false
+	}
+	proved := p.prove(as, s, depth)
+	p.memo[key] = proved
+	return proved
+}
+
+func (p *prover) prove(as []Statement, s Statement, depth int) bool {
+	for _, a := range as {
+		if equalStatements(a, s) {
+			return true
+		}
+	}
+
+	switch s.Operator() {
+	case OpAnd:
+		for _, ss := range s.statements {
+			if !p.implies(as, *ss, depth) {
+				return false
+			}
+		}
+		return true
+	case OpOr:
+		if len(s.statements) == 0 {
+			return true
+		}
+		for _, ss := range s.statements {
+			if p.implies(as, *ss, depth) {
+				return true
+			}
+		}
+	case OpNot:
+		if p.refutes(as, *s.statement, depth) {
+			return true
+		}
+	default:
+		for _, a := range as {
+			if !sameSelector(a, s) {
+				continue
+			}
+			if p.atomImplies(a, s) {
+				return true
+			}
+		}
+	}
+
+	// vacuously true if the statements are contradictory
+	for i, a := range as {
+		if p.refutes(without(as, i), a, depth) {
+			return true
+		}
+	}
+
+	// case split on disjunctions
+	if depth < maxSplitDepth {
+		for i, a := range as {
+			if a.Operator() != OpOr || len(a.statements) == 0 {
+				continue
+			}
+			rest := without(as, i)
+			all := true
+			for _, b := range a.statements {
+				if !p.implies(append(rest, *b), s, depth+1) {
+					all = false
+					break
+				}
+			}
+			if all {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// refutes returns true if the conjunction of the statements as is proved to
+// imply the negation of the statement t.
+func (p *prover) refutes(as []Statement, t Statement, depth int) bool {
+	as = flatten(as)
+	key := memoKey("refutes", as, t, depth)
+	if refuted, ok := p.memo[key]; ok {
+		return refuted
+	}
+	if !p.step() {
+		return false
+	}
+	refuted := p.refute(as, t, depth)
+	p.memo[key] = refuted
+	return refuted
+}
+
+func (p *prover) refute(as []Statement, t Statement, depth int) bool {
+	for _, a := range as {
+		// not(x) and t => x, so not(t)
+		if a.Operator() == OpNot && (equalStatements(*a.statement, t) || p.implies([]Statement{t}, *a.statement, maxSplitDepth)) {
+			return true
+		}
+	}
+
+	switch t.Operator() {
+	case OpNot:
+		return p.implies(as, *t.statement, depth)
+	case OpAnd:
+		for _, ts := range t.statements {
+			if p.refutes(as, *ts, depth) {
+				return true
+			}
+		}
+		return false
+	case OpOr:
+		if len(t.statements) == 0 {
+			return false
+		}
+		for _, ts := range t.statements {
+			if !p.refutes(as, *ts, depth) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, a := range as {
+		if !sameSelector(a, t) {
+			continue
+		}
+		if atomRefutes(a, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// atomImplies determines if statement a implies statement s, where both
+// statements select the same value.
+func (p *prover) atomImplies(a Statement, s Statement) bool {
+	switch a.Operator() {
+	case OpEqual:
+		// the selected value is known, so evaluate s against it
+		return evaluate(s, statementValue(a))
+	case OpAll, OpAny:
+		if s.Operator() == a.Operator() {
+			return p.implies([]Statement{*a.statement}, *s.statement, 0)
+		}
+		return false
+	case OpNot, OpAnd, OpOr:
+		return false
+	}
+
+	switch s.Operator() {
+	case OpNotEqual:
+		// a can only be true if the value exists, so if a rejects the value
+		// then the selected value is not equal to it
+		return !evaluate(a, statementValue(s))
+	case OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual:
+		return boundImplies(a, s)
+	case OpLike:
+		if a.Operator() != OpLike {
+			return false
+		}
+		if a.model.Pattern == s.model.Pattern || s.model.Pattern == "*" {
+			return true
+		}
+		// a literal pattern is an equality on a string
+		if !hasGlobMeta(a.model.Pattern) {
+			return s.glob.Match(a.model.Pattern)
+		}
+	}
+	return false
+}
+
+// atomRefutes determines if statement a implies the negation of statement t,
+// where both statements select the same value.
+func atomRefutes(a Statement, t Statement) bool {
+	switch a.Operator() {
+	case OpEqual:
+		return !evaluate(t, statementValue(a))
+	case OpNot, OpAnd, OpOr, OpAll, OpAny:
+		return false
+	}
+	switch t.Operator() {
+	case OpEqual:
+		return !evaluate(a, statementValue(t))
+	case OpNotEqual:
+		return false
+	case OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual:
+		return boundRefutes(a, t)
+	}
+	return false
+}
+
+// boundImplies determines if ordering statement a implies ordering statement
+// s. Both statements must select the same value.
+func boundImplies(a Statement, s Statement) bool {
+	u := statementValue(a)
+	v := statementValue(s)
+	switch s.Operator() {
+	case OpGreaterThan:
+		switch a.Operator() {
+		case OpGreaterThan:
+			return isOrdered(u, v, gte)
+		case OpGreaterThanOrEqual:
+			return isOrdered(u, v, gt)
+		}
+	case OpGreaterThanOrEqual:
+		switch a.Operator() {
+		case OpGreaterThan, OpGreaterThanOrEqual:
+			return isOrdered(u, v, gte)
+		}
+	case OpLessThan:
+		switch a.Operator() {
+		case OpLessThan:
+			return isOrdered(u, v, lte)
+		case OpLessThanOrEqual:
+			return isOrdered(u, v, lt)
+		}
+	case OpLessThanOrEqual:
+		switch a.Operator() {
+		case OpLessThan, OpLessThanOrEqual:
+			return isOrdered(u, v, lte)
+		}
+	}
+	return false
+}
+
+// boundRefutes determines if ordering statement a implies the negation of
+// ordering statement t. Both statements must select the same value.
+func boundRefutes(a Statement, t Statement) bool {
+	u := statementValue(a)
+	v := statementValue(t)
+	switch a.Operator() {
+	case OpGreaterThan:
+		switch t.Operator() {
+		case OpLessThan, OpLessThanOrEqual:
+			return isOrdered(v, u, lte)
+		}
+	case OpGreaterThanOrEqual:
+		switch t.Operator() {
+		case OpLessThan:
+			return isOrdered(v, u, lte)
+		case OpLessThanOrEqual:
+			return isOrdered(v, u, lt)
+		}
+	case OpLessThan:
+		switch t.Operator() {
+		case OpGreaterThan, OpGreaterThanOrEqual:
+			return isOrdered(v, u, gte)
+		}
+	case OpLessThanOrEqual:
+		switch t.Operator() {
+		case OpGreaterThan:
+			return isOrdered(v, u, gte)
+		case OpGreaterThanOrEqual:
+			return isOrdered(v, u, gt)
+		}
+	}
+	return false
+}
+
+// evaluate matches the statement against the passed value, as if it were the
+// value selected by the statement's selector.
+func evaluate(s Statement, value any) bool {
+	m := s.model
+	m.Selector = "."
+	rs, err := newStatement(m)
+	if err != nil {
+		return false
+	}
+	ok, _ := MatchStatement(rs, value)
+	return ok
+}
+
+func statementValue(s Statement) any {
+	if s.model.Value == nil {
+		return nil
+	}
+	return s.model.Value.Value
+}
+
+func without(stmts []Statement, i int) []Statement {
+	out := make([]Statement, 0, len(stmts)-1)
+	out = append(out, stmts[:i]...)
+	return append(out, stmts[i+1:]...)
+}
+
+func equalStatements(a Statement, b Statement) bool {
+	var ab, bb bytes.Buffer
+	if err := a.MarshalCBOR(&ab); err != nil {
+		return false
+	}
+	if err := b.MarshalCBOR(&bb); err != nil {
+		return false
+	}
+	return bytes.Equal(ab.Bytes(), bb.Bytes())
+}
+
+func sameSelector(a Statement, b Statement) bool {
+	if a.selector == nil || b.selector == nil {
+		return false
+	}
+	return canonicalSelector(a.selector) == canonicalSelector(b.selector)
+}
+
+// canonicalSelector returns a string representation of the selector where
+// equivalent selectors (e.g. `.a` and `.["a"]`) are equal.
+func canonicalSelector(sel selector.Selector) string {
+	var b strings.Builder
+	for _, seg := range sel {
+		switch {
+		case seg.Identity:
+			continue
+		case seg.Iterator:
+			b.WriteString("[]")
+		case seg.Field != "":
+			fmt.Fprintf(&b, "[%q]", seg.Field)
+		case seg.Slice != nil:
+			fmt.Fprintf(&b, "%v", seg.Slice)
+		default:
+			fmt.Fprintf(&b, "[%d]", seg.Index)
+		}
+		if seg.Optional {
+			b.WriteString("?")
+		}
+	}
+	return b.String()
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[]{}\`)
+}
+
+// absent is a candidate value indicating that the selected value should not
+// be set.
+type absent struct{}
+
+// witnesses generates candidate values for the policy statements, that may be
+// used to find a counterexample for an implication. Candidate values are
+// derived from the values, bounds and patterns of the statements.
+func witnesses(stmts []Statement, limit int) []any {
+	var paths []selector.Selector
+	candidates := map[string][]any{}
+	for _, s := range stmts {
+		collectCandidates(s, &paths, candidates)
+	}
+	if len(paths) == 0 {
+		return []any{ipld.Map{}}
+	}
+
+	var results []any
+	idx := make([]int, len(paths))
+	for len(results) < limit {
+		var root any = absent{}
+		ok := true
+		for i, p := range paths {
+			v := candidates[canonicalSelector(p)][idx[i]]
+			if _, isAbsent := v.(absent); isAbsent {
+				continue
+			}
+			root, ok = assign(root, p, v)
+			if !ok {
+				break
+			}
+		}
+		if ok {
+			if _, isAbsent := root.(absent); !isAbsent {
+				results = append(results, finalize(root))
+			}
+		}
+		// advance to the next combination
+		i := 0
+		for ; i < len(paths); i++ {
+			idx[i]++
+			if idx[i] < len(candidates[canonicalSelector(paths[i])]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i == len(paths) {
+			break
+		}
+	}
+	return results
+}
+
+func collectCandidates(s Statement, paths *[]selector.Selector, candidates map[string][]any) {
+	switch s.Operator() {
+	case OpAnd, OpOr:
+		for _, ss := range s.statements {
+			collectCandidates(*ss, paths, candidates)
+		}
+		return
+	case OpNot:
+		collectCandidates(*s.statement, paths, candidates)
+		return
+	}
+	if !isAssignable(s.selector) {
+		return
+	}
+	key := canonicalSelector(s.selector)
+	if _, ok := candidates[key]; !ok {
+		*paths = append(*paths, s.selector)
+		candidates[key] = []any{absent{}}
+	}
+	var values []any
+	switch s.Operator() {
+	case OpEqual, OpNotEqual, OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual:
+		v := statementValue(s)
+		values = append(values, v)
+		switch n := v.(type) {
+		case int64:
+			values = append(values, n-1, n+1)
+		case float64:
+			values = append(values, n-1, n+1)
+		case string:
+			values = append(values, n+"x")
+		default:
+			values = append(values, "x")
+		}
+	case OpLike:
+		values = append(values,
+			strings.ReplaceAll(s.model.Pattern, "*", ""),
+			strings.ReplaceAll(s.model.Pattern, "*", "x"),
+			"",
+		)
+	case OpAll, OpAny:
+		values = append(values, []any{})
+		for _, e := range witnesses([]Statement{*s.statement}, maxElementWitnesses) {
+			values = append(values, []any{e})
+		}
+	}
+	candidates[key] = append(candidates[key], values...)
+}
+
+// isAssignable returns true if a value can be constructed for the selector.
+func isAssignable(sel selector.Selector) bool {
+	if sel == nil {
+		return false
+	}
+	for _, seg := range sel {
+		if seg.Iterator || seg.Slice != nil || (!seg.Identity && seg.Field == "" && seg.Index < 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// assign sets the value at the selector path in root, returning the new root
+// or false if the value conflicts with a value already set.
+func assign(root any, sel selector.Selector, value any) (any, bool) {
+	var segs []selector.Segment
+	for _, seg := range sel {
+		if !seg.Identity {
+			segs = append(segs, seg)
+		}
+	}
+	return assignSegments(root, segs, value)
+}
+
+func assignSegments(cur any, segs []selector.Segment, value any) (any, bool) {
+	if len(segs) == 0 {
+		if _, ok := cur.(absent); ok {
+			return value, true
+		}
+		return nil, false
+	}
+	seg := segs[0]
+	if seg.Field != "" {
+		var m ipld.Map
+		switch c := cur.(type) {
+		case absent:
+			m = ipld.Map{}
+		case ipld.Map:
+			m = maps.Clone(c)
+		default:
+			return nil, false
+		}
+		var child any = absent{}
+		if v, ok := m[seg.Field]; ok {
+			child = v
+		}
+		v, ok := assignSegments(child, segs[1:], value)
+		if !ok {
+			return nil, false
+		}
+		m[seg.Field] = v
+		return m, true
+	}
+	var l []any
+	switch c := cur.(type) {
+	case absent:
+		l = []any{}
+	case []any:
+		l = slices.Clone(c)
+	default:
+		return nil, false
+	}
+	for len(l) <= seg.Index {
+		l = append(l, absent{})
+	}
+	v, ok := assignSegments(l[seg.Index], segs[1:], value)
+	if !ok {
+		return nil, false
+	}
+	l[seg.Index] = v
+	return l, true
+}
+
+// finalize replaces unset list items with null.
+func finalize(value any) any {
+	switch v := value.(type) {
+	case absent:
+		return nil
+	case ipld.Map:
+		m := make(ipld.Map, len(v))
+		for k, item := range v {
+			m[k] = finalize(item)
+		}
+		return m
+	case []any:
+		l := make([]any, 0, len(v))
+		for _, item := range v {
+			l = append(l, finalize(item))
+		}
+		return l
+	}
+	return value
+}
@@ -0,0 +1,111 @@
+package policy_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImplies(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected policy.Implication
+	}{
+		{"empty", `[]`, `[]`, policy.Implied},
+		{"anything implies empty", `[["==", ".a", 1]]`, `[]`, policy.Implied},
+		{"empty does not imply statement", `[]`, `[["==", ".a", 1]]`, policy.NotImplied},
+		{"same statement", `[["==", ".a", 1]]`, `[["==", ".a", 1]]`, policy.Implied},
+		{"equivalent selector", `[["==", ".a", 1]]`, `[["==", ".[\"a\"]", 1]]`, policy.Implied},
+		{"additional statement", `[["==", ".a", 1], ["==", ".b", 2]]`, `[["==", ".a", 1]]`, policy.Implied},
+		{"missing statement", `[["==", ".a", 1]]`, `[["==", ".a", 1], ["==", ".b", 2]]`, policy.NotImplied},
+		{"different value", `[["==", ".a", 1]]`, `[["==", ".a", 2]]`, policy.NotImplied},
+		{"equality implies not equal", `[["==", ".a", 1]]`, `[["!=", ".a", 2]]`, policy.Implied},
+		{"equality implies bound", `[["==", ".a", 5]]`, `[[">", ".a", 1]]`, policy.Implied},
+		{"equality outside bound", `[["==", ".a", 5]]`, `[["<", ".a", 5]]`, policy.NotImplied},
+		{"equality implies like", `[["==", ".a", "foo.txt"]]`, `[["like", ".a", "*.txt"]]`, policy.Implied},
+		{"tighter bound", `[[">", ".a", 5]]`, `[[">=", ".a", 5]]`, policy.Implied},
+		{"tighter upper bound", `[["<=", ".a", 10]]`, `[["<", ".a", 11]]`, policy.Implied},
+		{"looser bound", `[[">=", ".a", 5]]`, `[[">", ".a", 5]]`, policy.NotImplied},
+		{"looser upper bound", `[["<", ".a", 100]]`, `[["<", ".a", 10]]`, policy.NotImplied},
+		{"float bound", `[[">", ".a", 1.5]]`, `[[">=", ".a", 1]]`, policy.Implied},
+		{"float equality implies int bound", `[["==", ".a", 2.5]]`, `[["<", ".a", 3]]`, policy.Implied},
+		{"looser float bound", `[["<=", ".a", 2.5]]`, `[["<", ".a", 2]]`, policy.NotImplied},
+		{"bound excludes value", `[[">", ".a", 5]]`, `[["!=", ".a", 3]]`, policy.Implied},
+		{"same like", `[["like", ".a", "*.txt"]]`, `[["like", ".a", "*.txt"]]`, policy.Implied},
+		{"like anything", `[["like", ".a", "*.txt"]]`, `[["like", ".a", "*"]]`, policy.Implied},
+		{"literal like", `[["like", ".a", "foo.txt"]]`, `[["like", ".a", "*.txt"]]`, policy.Implied},
+		{"looser like", `[["like", ".a", "*"]]`, `[["like", ".a", "*.txt"]]`, policy.NotImplied},
+		{"undecidable like", `[["like", ".a", "a*b"]]`, `[["like", ".a", "a*"]]`, policy.Unknown},
+		{"and", `[["and", [["==", ".a", 1], ["==", ".b", 2]]]]`, `[["==", ".b", 2]]`, policy.Implied},
+		{"implies and", `[["==", ".a", 1], ["==", ".b", 2]]`, `[["and", [["==", ".a", 1], ["==", ".b", 2]]]]`, policy.Implied},
+		{"implies or", `[["==", ".a", 1]]`, `[["or", [["==", ".a", 1], ["==", ".a", 2]]]]`, policy.Implied},
+		{"or implies or", `[["or", [["==", ".a", 1], ["==", ".a", 2]]]]`, `[["or", [["==", ".a", 2], ["==", ".a", 1], ["==", ".a", 3]]]]`, policy.Implied},
+		{"or does not imply", `[["or", [["==", ".a", 1], ["==", ".a", 2]]]]`, `[["==", ".a", 1]]`, policy.NotImplied},
+		{"or of bounds", `[["or", [["==", ".a", 1], [">", ".a", 5]]]]`, `[["!=", ".a", 3]]`, policy.Implied},
+		{"not contrapositive", `[["not", ["like", ".a", "*"]]]`, `[["not", ["==", ".a", "foo"]]]`, policy.Implied},
+		{"double negation", `[["==", ".a", 1]]`, `[["not", ["not", ["==", ".a", 1]]]]`, policy.Implied},
+		{"not excludes", `[["not", ["==", ".a", 1]]]`, `[["==", ".a", 1]]`, policy.NotImplied},
+		{"contradiction", `[["==", ".a", 1], ["==", ".a", 2]]`, `[["==", ".b", 3]]`, policy.Implied},
+		{"all", `[["all", ".a", [">", ".", 5]]]`, `[["all", ".a", [">", ".", 1]]]`, policy.Implied},
+		{"all looser", `[["all", ".a", [">", ".", 1]]]`, `[["all", ".a", [">", ".", 5]]]`, policy.NotImplied},
+		{"any", `[["any", ".a", ["==", ".", 1]]]`, `[["any", ".a", [">=", ".", 1]]]`, policy.Implied},
+		{"all does not imply any", `[["all", ".a", ["==", ".", 1]]]`, `[["any", ".a", ["==", ".", 1]]]`, policy.NotImplied},
+		{"equality implies all", `[["==", ".a", [1, 2]]]`, `[["all", ".a", [">", ".", 0]]]`, policy.Implied},
+		{"nested selector", `[["==", ".a.b", 1]]`, `[["==", ".a.b", 2]]`, policy.NotImplied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := policy.Parse(tc.a)
+			require.NoError(t, err)
+			b, err := policy.Parse(tc.b)
+			require.NoError(t, err)
+
+			res, err := policy.Implies(a, b)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res, "expected %s, got %s", tc.expected, res)
+		})
+	}
+
+	t.Run("built policies", func(t *testing.T) {
+		parent, err := policy.Build(policy.GreaterThan(".size", 0))
+		require.NoError(t, err)
+		child, err := policy.Build(
+			policy.GreaterThan(".size", 0),
+			policy.LessThanOrEqual(".size", 1024),
+			policy.Equal(".name", "foo"),
+		)
+		require.NoError(t, err)
+
+		res, err := policy.Implies(child, parent)
+		require.NoError(t, err)
+		require.Equal(t, policy.Implied, res)
+
+		res, err = policy.Implies(parent, child)
+		require.NoError(t, err)
+		require.Equal(t, policy.NotImplied, res)
+	})
+
+	t.Run("many negations", func(t *testing.T) {
+		var stmts []string
+		for i := range 20 {
+			stmts = append(stmts, fmt.Sprintf(`["not", ["==", ".a", %d]]`, i))
+		}
+		a, err := policy.Parse("[" + strings.Join(stmts, ", ") + "]")
+		require.NoError(t, err)
+		b, err := policy.Parse(`[["==", ".b", 1]]`)
+		require.NoError(t, err)
+
+		start := time.Now()
+		res, err := policy.Implies(a, b)
+		require.NoError(t, err)
+		require.NotEqual(t, policy.Implied, res)
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
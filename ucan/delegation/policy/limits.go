@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	pdm "github.com/alanshaw/ucantone/ucan/delegation/policy/datamodel"
+)
+
+// CheckLimits returns a limit exceeded error if the policy has statements
+// nested deeper than maxDepth, or more than maxStatements statements in total,
+// including nested statements. Top level statements have a depth of 1.
+func CheckLimits(pol ucan.Policy, maxDepth int, maxStatements int) error {
+	p, err := New(pol.Statements()...)
+	if err != nil {
+		return err
+	}
+	models := make([]pdm.StatementModel, 0, len(p.statements))
+	for _, s := range p.statements {
+		models = append(models, s.model)
+	}
+	return checkLimits(models, maxDepth, maxStatements)
+}
+
+func checkLimits(models []pdm.StatementModel, maxDepth int, maxStatements int) error {
+	type entry struct {
+		model *pdm.StatementModel
+		depth int
+	}
+	stack := make([]entry, 0, len(models))
+	for i := range models {
+		stack = append(stack, entry{&models[i], 1})
+	}
+	count := 0
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		count++
+		if count > maxStatements {
+			return limits.NewExceededError(limits.PolicyStatementLimit, int64(maxStatements))
+		}
+		if e.depth > maxDepth {
+			return limits.NewExceededError(limits.PolicyDepthLimit, int64(maxDepth))
+		}
+		if e.model.Statement != nil {
+			stack = append(stack, entry{e.model.Statement, e.depth + 1})
+		}
+		for _, s := range e.model.Statements {
+			if s != nil {
+				stack = append(stack, entry{s, e.depth + 1})
+			}
+		}
+	}
+	return nil
+}
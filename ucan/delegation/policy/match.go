@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 
 	"github.com/alanshaw/ucantone/ucan"
@@ -37,7 +38,7 @@ func MatchStatement(statement ucan.Statement, value any) (bool, error) {
 		if s.model.Value != nil {
 			statementValue = s.model.Value.Value
 		}
-		if !reflect.DeepEqual(statementValue, selectedValue) {
+		if !isEqual(statementValue, selectedValue) {
 			return false, NewMatchError(statement, fmt.Errorf(`matching "%s": "%v" does not equal "%v"`, s.Selector(), selectedValue, statementValue))
 		}
 		return true, nil
@@ -50,7 +51,7 @@ func MatchStatement(statement ucan.Statement, value any) (bool, error) {
 		if s.model.Value != nil {
 			statementValue = s.model.Value.Value
 		}
-		if reflect.DeepEqual(statementValue, selectedValue) {
+		if isEqual(statementValue, selectedValue) {
 			return false, NewMatchError(statement, fmt.Errorf(`matching "%s": "%v" equals "%v"`, s.Selector(), selectedValue, statementValue))
 		}
 		return true, nil
@@ -223,20 +224,111 @@ func MatchStatement(statement ucan.Statement, value any) (bool, error) {
 	panic(fmt.Errorf("unknown statement operator: %s", statement.Operator()))
 }
 
+// isEqual determines if two values are deeply equal. Numbers are compared by
+// value, regardless of whether they are integers or floats, so 1 == 1.0.
+func isEqual(a any, b any) bool {
+	if an, ok := toNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			return compareNumbers(an, bn) == 0
+		}
+		return false
+	}
+	if _, ok := a.([]byte); ok {
+		return reflect.DeepEqual(a, b)
+	}
+	av := reflect.ValueOf(a)
+	bv := reflect.ValueOf(b)
+	switch av.Kind() {
+	case reflect.Slice:
+		if bv.Kind() != reflect.Slice || bv.Type() == reflect.TypeOf([]byte{}) || av.Len() != bv.Len() {
+			return false
+		}
+		for i := range av.Len() {
+			if !isEqual(av.Index(i).Interface(), bv.Index(i).Interface()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		if bv.Kind() != reflect.Map || av.Type().Key() != bv.Type().Key() || av.Len() != bv.Len() {
+			return false
+		}
+		iter := av.MapRange()
+		for iter.Next() {
+			v := bv.MapIndex(iter.Key())
+			if !v.IsValid() || !isEqual(iter.Value().Interface(), v.Interface()) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isOrdered determines if the order of a relative to b satisfies the passed
+// function. Integers and floats may be compared with each other. Values that
+// are not numbers are not ordered.
 func isOrdered(a any, b any, satisfies func(order int) bool) bool {
-	if aint, ok := a.(int); ok {
-		a = int64(aint)
+	an, ok := toNumber(a)
+	if !ok {
+		return false
 	}
-	if bint, ok := b.(int); ok {
-		b = int64(bint)
+	bn, ok := toNumber(b)
+	if !ok {
+		return false
 	}
-	if aint64, ok := a.(int64); ok {
-		if bint64, ok := b.(int64); ok {
-			return satisfies(cmp.Compare(aint64, bint64))
+	return satisfies(compareNumbers(an, bn))
+}
+
+// toNumber converts integer values to int64 and float values to float64.
+func toNumber(v any) (any, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return nil, false
+}
+
+// compareNumbers compares two numbers returned from [toNumber]. Integers are
+// compared with floats exactly, without converting the integer to a float.
+func compareNumbers(a any, b any) int {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return cmp.Compare(a, b)
+		case float64:
+			return compareIntFloat(a, b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return -compareIntFloat(b, a)
+		case float64:
+			return cmp.Compare(a, b)
 		}
 	}
-	// TODO: support float
-	return false
+	panic(fmt.Errorf("not a number: %T", a))
+}
+
+func compareIntFloat(i int64, f float64) int {
+	if f >= math.MaxInt64 {
+		return -1
+	}
+	if f < math.MinInt64 {
+		return 1
+	}
+	t := math.Trunc(f)
+	if c := cmp.Compare(i, int64(t)); c != 0 {
+		return c
+	}
+	return cmp.Compare(0, f-t)
 }
 
 func gt(order int) bool  { return order == 1 }
@@ -356,6 +356,126 @@ func TestMatch(t *testing.T) {
 			value:  "138",
 			match:  false,
 		},
+		{
+			name:   "comparison equal float match",
+			policy: policy.Equal(".", 1.5),
+			value:  1.5,
+			match:  true,
+		},
+		{
+			name:   "comparison equal float no match",
+			policy: policy.Equal(".", 1.5),
+			value:  1.25,
+			match:  false,
+		},
+		{
+			name:   "comparison equal float int match",
+			policy: policy.Equal(".", 138.0),
+			value:  138,
+			match:  true,
+		},
+		{
+			name:   "comparison equal int float match",
+			policy: policy.Equal(".", 138),
+			value:  138.0,
+			match:  true,
+		},
+		{
+			name:   "comparison equal int float no match",
+			policy: policy.Equal(".", 138),
+			value:  138.5,
+			match:  false,
+		},
+		{
+			name:   "comparison equal list of numbers match",
+			policy: policy.Equal(".", []any{1, 2.0}),
+			value:  []float64{1, 2},
+			match:  true,
+		},
+		{
+			name:   "comparison not equal float int no match",
+			policy: policy.NotEqual(".", 1.0),
+			value:  1,
+			match:  false,
+		},
+		{
+			name:   "comparison greater than float match",
+			policy: policy.GreaterThan(".", 1.5),
+			value:  1.75,
+			match:  true,
+		},
+		{
+			name:   "comparison greater than float no match",
+			policy: policy.GreaterThan(".", 1.5),
+			value:  1.5,
+			match:  false,
+		},
+		{
+			name:   "comparison greater than float int match",
+			policy: policy.GreaterThan(".", 137.9),
+			value:  138,
+			match:  true,
+		},
+		{
+			name:   "comparison greater than int float match",
+			policy: policy.GreaterThan(".", 138),
+			value:  138.1,
+			match:  true,
+		},
+		{
+			name:   "comparison greater than int float no match",
+			policy: policy.GreaterThan(".", 138),
+			value:  137.9,
+			match:  false,
+		},
+		{
+			name:   "comparison greater than or equal int float match",
+			policy: policy.GreaterThanOrEqual(".", 138),
+			value:  138.0,
+			match:  true,
+		},
+		{
+			name:   "comparison greater than or equal negative float int match",
+			policy: policy.GreaterThanOrEqual(".", -1.5),
+			value:  -1,
+			match:  true,
+		},
+		{
+			name:   "comparison less than float int match",
+			policy: policy.LessThan(".", 138.5),
+			value:  138,
+			match:  true,
+		},
+		{
+			name:   "comparison less than float int no match",
+			policy: policy.LessThan(".", 138.0),
+			value:  138,
+			match:  false,
+		},
+		{
+			name:   "comparison less than int float match",
+			policy: policy.LessThan(".", -1),
+			value:  -1.5,
+			match:  true,
+		},
+		{
+			name:   "comparison less than large int float match",
+			policy: policy.LessThan(".", int64(1<<53+1)),
+			value:  float64(1 << 53),
+			match:  true,
+		},
+		{
+			name:   "comparison less than or equal float int match",
+			policy: policy.LessThanOrEqual(".", 138.0),
+			value:  138,
+			match:  true,
+		},
+		{
+			name:   "comparison less than or equal float no match non-float",
+			policy: policy.LessThanOrEqual(".", 138.0),
+			value:  "138",
+			match:  false,
+		},
 		{
 			name:   "negation match",
 			policy: policy.Not(policy.Equal(".", true)),
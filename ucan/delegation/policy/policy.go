@@ -207,13 +207,20 @@ func (s Statement) Selector() string {
 	return s.model.Selector
 }
 
+// Argument returns the argument of the statement: the value for comparison
+// operators, which is nil if it is absent, the pattern for "like", the nested
+// statements for "and" and "or", and the nested statement for "not", "all" and
+// "any".
 func (s Statement) Argument() any {
 	switch s.model.Op {
 	case OpEqual, OpNotEqual, OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual:
+		if s.model.Value == nil {
+			return nil
+		}
 		return s.model.Value.Value
-	case OpAnd, OpOr, OpAll, OpAny:
+	case OpAnd, OpOr:
 		return s.statements
-	case OpNot:
+	case OpNot, OpAll, OpAny:
 		return s.statement
 	case OpLike:
 		return s.model.Pattern
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	fdm "github.com/alanshaw/ucantone/ucan/delegation/policy/internal/fixtures/datamodel"
 	"github.com/stretchr/testify/require"
@@ -76,3 +77,71 @@ func TestFixtures(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckLimits(t *testing.T) {
+	nested := func(depth int) policy.StatementBuilderFunc {
+		stmt := policy.Equal(".foo", "bar")
+		for range depth - 1 {
+			stmt = policy.Not(stmt)
+		}
+		return stmt
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		pol, err := policy.Build(nested(3), policy.Equal(".baz", 1))
+		require.NoError(t, err)
+		require.NoError(t, policy.CheckLimits(pol, 3, 4))
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		pol, err := policy.Build(nested(4))
+		require.NoError(t, err)
+		require.ErrorContains(t, policy.CheckLimits(pol, 3, 10), limits.PolicyDepthLimit)
+	})
+
+	t.Run("too many statements", func(t *testing.T) {
+		pol, err := policy.Build(policy.And(
+			policy.Equal(".a", 1),
+			policy.Equal(".b", 2),
+			policy.Equal(".c", 3),
+		))
+		require.NoError(t, err)
+		require.ErrorContains(t, policy.CheckLimits(pol, 10, 3), limits.PolicyStatementLimit)
+	})
+
+	t.Run("decode does not check limits", func(t *testing.T) {
+		pol, err := policy.Build(nested(limits.Default.MaxPolicyDepth + 1))
+		require.NoError(t, err)
+		var b bytes.Buffer
+		require.NoError(t, pol.MarshalCBOR(&b))
+
+		var decoded policy.Policy
+		require.NoError(t, decoded.UnmarshalCBOR(&b))
+		err = policy.CheckLimits(decoded, limits.Default.MaxPolicyDepth, limits.Default.MaxPolicyStatements)
+		require.ErrorContains(t, err, limits.PolicyDepthLimit)
+	})
+}
+
+func TestStatementArgument(t *testing.T) {
+	t.Run("quantifiers return the nested statement", func(t *testing.T) {
+		for _, build := range []func(string, policy.StatementBuilderFunc) policy.StatementBuilderFunc{policy.All, policy.Any} {
+			p, err := policy.Build(build(".items", policy.Equal(".n", 1)))
+			require.NoError(t, err)
+
+			// previously the (empty) list of statements used by "and" and "or" was
+			// returned
+			nested, ok := p.Statements()[0].Argument().(*policy.Statement)
+			require.True(t, ok)
+			require.Equal(t, policy.OpEqual, nested.Operator())
+			require.Equal(t, ".n", nested.Selector())
+		}
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		p, err := policy.Parse(`[["==", ".foo", null]]`)
+		require.NoError(t, err)
+
+		// previously this panicked when the value was absent
+		require.Nil(t, p.Statements()[0].Argument())
+	})
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/alanshaw/ucantone/limits"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/ipfs/go-cid"
+)
+
+// FileStore is a [DelegationStore] that persists delegations to a directory on
+// disk. Each delegation is stored in a file named by its CID, containing the
+// bytes returned by [delegation.Encode]. The directory is read when the store
+// is created and an in-memory index is maintained for lookups.
+type FileStore struct {
+	dir   string
+	index *MemoryStore
+}
+
+// FileStoreOption is an option configuring a [FileStore].
+type FileStoreOption func(cfg *fileStoreConfig)
+
+type fileStoreConfig struct {
+	limits limits.Limits
+}
+
+// WithLimits configures the resource limits enforced when decoding the
+// delegations in the directory. Zero values in the limits are replaced by the
+// defaults.
+func WithLimits(l limits.Limits) FileStoreOption {
+	return func(cfg *fileStoreConfig) {
+		cfg.limits = l
+	}
+}
+
+// NewFileStore creates a delegation store that persists delegations in the
+// passed directory. The directory is created if it does not exist.
+//
+// Delegations are decoded leniently, since a delegation that was decoded with
+// [delegation.WithStrict] false may be stored with its non-canonical bytes.
+// The file name is checked against the CID of the bytes instead.
+func NewFileStore(dir string, options ...FileStoreOption) (*FileStore, error) {
+	cfg := fileStoreConfig{}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating delegation store directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading delegation store directory: %w", err)
+	}
+	index := NewMemoryStore()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		link, err := cid.Parse(entry.Name())
+		if err != nil {
+			continue // not a delegation file
+		}
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading delegation file: %w", err)
+		}
+		dlg, err := delegation.Decode(b, delegation.WithStrict(false), delegation.WithLimits(cfg.limits))
+		if err != nil {
+			return nil, fmt.Errorf("decoding delegation %q: %w", link, err)
+		}
+		if dlg.Link() != link {
+			return nil, fmt.Errorf("delegation file %q contains delegation %q", link, dlg.Link())
+		}
+		index.put(dlg)
+	}
+	return &FileStore{dir: dir, index: index}, nil
+}
+
+func (fs *FileStore) Put(ctx context.Context, dlg ucan.Delegation) error {
+	b, err := delegation.Encode(dlg)
+	if err != nil {
+		return fmt.Errorf("encoding delegation: %w", err)
+	}
+	// write to a temporary file and rename so that partially written
+	// delegations are never read
+	tmp, err := os.CreateTemp(fs.dir, ".tmp-")
+	if err != nil {
+		return fmt.Errorf("creating delegation file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing delegation file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing delegation file: %w", err)
+	}
+	// the index lock is held while the file is moved into place, so that the
+	// index and the directory agree when Put and Delete race
+	fs.index.mutex.Lock()
+	defer fs.index.mutex.Unlock()
+	if err := os.Rename(tmp.Name(), fs.path(dlg.Link())); err != nil {
+		return fmt.Errorf("renaming delegation file: %w", err)
+	}
+	fs.index.put(dlg)
+	return nil
+}
+
+func (fs *FileStore) Get(ctx context.Context, link ucan.Link) (ucan.Delegation, error) {
+	return fs.index.Get(ctx, link)
+}
+
+func (fs *FileStore) Delete(ctx context.Context, link ucan.Link) error {
+	fs.index.mutex.Lock()
+	defer fs.index.mutex.Unlock()
+	if err := os.Remove(fs.path(link)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing delegation file: %w", err)
+	}
+	fs.index.delete(link)
+	return nil
+}
+
+func (fs *FileStore) Find(ctx context.Context, query Query) ([]ucan.Delegation, error) {
+	return fs.index.Find(ctx, query)
+}
+
+// Prune removes the files of delegations that have expired, and removes them
+// from the index once their file has been removed. If a file cannot be removed,
+// the delegation remains in the store and an error is returned along with the
+// CIDs of the delegations that were removed.
+func (fs *FileStore) Prune(ctx context.Context, now ucan.UTCUnixTimestamp) ([]ucan.Link, error) {
+	fs.index.mutex.Lock()
+	defer fs.index.mutex.Unlock()
+	pruned := []ucan.Link{}
+	var errs error
+	for _, link := range fs.index.expired(now) {
+		if err := os.Remove(fs.path(link)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = errors.Join(errs, fmt.Errorf("removing delegation file: %w", err))
+			continue
+		}
+		fs.index.delete(link)
+		pruned = append(pruned, link)
+	}
+	return pruned, errs
+}
+
+func (fs *FileStore) path(link ucan.Link) string {
+	return filepath.Join(fs.dir, link.String())
+}
+
+var _ DelegationStore = (*FileStore)(nil)
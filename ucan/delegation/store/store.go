@@ -0,0 +1,230 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator"
+)
+
+// ErrNotFound is returned when a delegation is not found in the store.
+var ErrNotFound = errors.New("delegation not found")
+
+// Query filters the delegations returned by [DelegationStore.Find]. Zero value
+// fields are not used to filter.
+type Query struct {
+	// Audience matches delegations issued to the audience.
+	Audience did.DID
+	// Subject matches delegations for the subject. Powerline delegations (null
+	// subject) apply to any subject, so are also matched.
+	Subject did.DID
+	// Command matches delegations whose command is the same as or a
+	// sub-command of this command e.g. "/blob" matches "/blob" and "/blob/add"
+	// but not "/blobs".
+	Command ucan.Command
+	// Proves matches delegations whose command is the same as or a parent of
+	// this command i.e. delegations that can be used as proof when invoking it.
+	// e.g. "/blob/add" matches "/", "/blob" and "/blob/add" but not "/blobs".
+	Proves ucan.Command
+}
+
+// DelegationStore persists delegations and allows them to be looked up.
+type DelegationStore interface {
+	// Put adds a delegation to the store.
+	Put(ctx context.Context, dlg ucan.Delegation) error
+	// Get retrieves a delegation by CID. It returns [ErrNotFound] if the
+	// delegation is not in the store.
+	Get(ctx context.Context, link ucan.Link) (ucan.Delegation, error)
+	// Delete removes a delegation from the store. It is not an error to delete a
+	// delegation that is not in the store.
+	Delete(ctx context.Context, link ucan.Link) error
+	// Find returns the delegations that match the query, ordered by CID.
+	Find(ctx context.Context, query Query) ([]ucan.Delegation, error)
+	// Prune removes delegations that have expired at the passed time. It returns
+	// the CIDs of the removed delegations.
+	Prune(ctx context.Context, now ucan.UTCUnixTimestamp) ([]ucan.Link, error)
+}
+
+// NewProofResolver creates a [validator.ProofResolverFunc] that resolves proofs
+// from the passed store.
+func NewProofResolver(store DelegationStore) validator.ProofResolverFunc {
+	return func(ctx context.Context, link ucan.Link) (ucan.Delegation, error) {
+		return store.Get(ctx, link)
+	}
+}
+
+// MemoryStore is a [DelegationStore] that keeps delegations in memory, indexed
+// by CID, audience and subject.
+type MemoryStore struct {
+	mutex      sync.RWMutex
+	data       map[ucan.Link]ucan.Delegation
+	byAudience map[did.DID]map[ucan.Link]struct{}
+	// powerline delegations are indexed under the zero value DID
+	bySubject map[did.DID]map[ucan.Link]struct{}
+}
+
+// NewMemoryStore creates a new, empty, in-memory delegation store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		data:       map[ucan.Link]ucan.Delegation{},
+		byAudience: map[did.DID]map[ucan.Link]struct{}{},
+		bySubject:  map[did.DID]map[ucan.Link]struct{}{},
+	}
+}
+
+func (ms *MemoryStore) Put(ctx context.Context, dlg ucan.Delegation) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.put(dlg)
+	return nil
+}
+
+func (ms *MemoryStore) Get(ctx context.Context, link ucan.Link) (ucan.Delegation, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	dlg, ok := ms.data[link]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return dlg, nil
+}
+
+func (ms *MemoryStore) Delete(ctx context.Context, link ucan.Link) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.delete(link)
+	return nil
+}
+
+func (ms *MemoryStore) Find(ctx context.Context, query Query) ([]ucan.Delegation, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+
+	var candidates []ucan.Link
+	switch {
+	case query.Audience != (did.DID{}):
+		for link := range ms.byAudience[query.Audience] {
+			candidates = append(candidates, link)
+		}
+	case query.Subject != (did.DID{}):
+		for link := range ms.bySubject[query.Subject] {
+			candidates = append(candidates, link)
+		}
+		for link := range ms.bySubject[did.DID{}] {
+			candidates = append(candidates, link)
+		}
+	default:
+		for link := range ms.data {
+			candidates = append(candidates, link)
+		}
+	}
+	slices.SortFunc(candidates, func(a, b ucan.Link) int {
+		return bytes.Compare(a.Bytes(), b.Bytes())
+	})
+
+	results := []ucan.Delegation{}
+	for _, link := range candidates {
+		dlg := ms.data[link]
+		if matches(dlg, query) {
+			results = append(results, dlg)
+		}
+	}
+	return results, nil
+}
+
+func (ms *MemoryStore) Prune(ctx context.Context, now ucan.UTCUnixTimestamp) ([]ucan.Link, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	return ms.prune(now), nil
+}
+
+// put, delete, prune and expired access the store without acquiring the
+// mutex, the caller must hold it.
+
+func (ms *MemoryStore) put(dlg ucan.Delegation) {
+	link := dlg.Link()
+	if _, ok := ms.data[link]; ok {
+		return
+	}
+	ms.data[link] = dlg
+	addIndex(ms.byAudience, dlg.Audience().DID(), link)
+	addIndex(ms.bySubject, subjectKey(dlg), link)
+}
+
+func (ms *MemoryStore) prune(now ucan.UTCUnixTimestamp) []ucan.Link {
+	pruned := ms.expired(now)
+	for _, link := range pruned {
+		ms.delete(link)
+	}
+	return pruned
+}
+
+func (ms *MemoryStore) expired(now ucan.UTCUnixTimestamp) []ucan.Link {
+	links := []ucan.Link{}
+	for link, dlg := range ms.data {
+		if validator.ValidateNotExpired(dlg, now) != nil {
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+func (ms *MemoryStore) delete(link ucan.Link) {
+	dlg, ok := ms.data[link]
+	if !ok {
+		return
+	}
+	delete(ms.data, link)
+	removeIndex(ms.byAudience, dlg.Audience().DID(), link)
+	removeIndex(ms.bySubject, subjectKey(dlg), link)
+}
+
+var _ DelegationStore = (*MemoryStore)(nil)
+
+func matches(dlg ucan.Delegation, query Query) bool {
+	if query.Audience != (did.DID{}) && dlg.Audience().DID() != query.Audience {
+		return false
+	}
+	if query.Subject != (did.DID{}) && dlg.Subject() != nil && dlg.Subject().DID() != query.Subject {
+		return false
+	}
+	if query.Command != "" && !query.Command.Proves(dlg.Command()) {
+		return false
+	}
+	if query.Proves != "" && !dlg.Command().Proves(query.Proves) {
+		return false
+	}
+	return true
+}
+
+func subjectKey(dlg ucan.Delegation) did.DID {
+	if dlg.Subject() == nil {
+		return did.DID{}
+	}
+	return dlg.Subject().DID()
+}
+
+func addIndex(index map[did.DID]map[ucan.Link]struct{}, key did.DID, link ucan.Link) {
+	links, ok := index[key]
+	if !ok {
+		links = map[ucan.Link]struct{}{}
+		index[key] = links
+	}
+	links[link] = struct{}{}
+}
+
+func removeIndex(index map[did.DID]map[ucan.Link]struct{}, key did.DID, link ucan.Link) {
+	links, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(links, link)
+	if len(links) == 0 {
+		delete(index, key)
+	}
+}
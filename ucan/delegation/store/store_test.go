@@ -0,0 +1,249 @@
+package store_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/store"
+	"github.com/alanshaw/ucantone/validator/capability"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	impls := map[string]func(t *testing.T) store.DelegationStore{
+		"memory": func(t *testing.T) store.DelegationStore {
+			return store.NewMemoryStore()
+		},
+		"file": func(t *testing.T) store.DelegationStore {
+			return testutil.Must(store.NewFileStore(t.TempDir()))(t)
+		},
+	}
+
+	space := testutil.RandomSigner(t)
+	otherSpace := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+
+	Blob := testutil.Must(capability.New("/blob"))(t)
+	BlobAdd := testutil.Must(capability.New("/blob/add"))(t)
+	Blobs := testutil.Must(capability.New("/blobs"))(t)
+
+	for name, newStore := range impls {
+		t.Run(name, func(t *testing.T) {
+			t.Run("put and get", func(t *testing.T) {
+				s := newStore(t)
+				dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+
+				require.NoError(t, s.Put(t.Context(), dlg))
+				// putting the same delegation again is a no-op
+				require.NoError(t, s.Put(t.Context(), dlg))
+
+				got, err := s.Get(t.Context(), dlg.Link())
+				require.NoError(t, err)
+				require.Equal(t, dlg.Link(), got.Link())
+			})
+
+			t.Run("get not found", func(t *testing.T) {
+				s := newStore(t)
+				_, err := s.Get(t.Context(), testutil.RandomCID(t))
+				require.True(t, errors.Is(err, store.ErrNotFound))
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				s := newStore(t)
+				dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+				require.NoError(t, s.Put(t.Context(), dlg))
+				require.NoError(t, s.Delete(t.Context(), dlg.Link()))
+
+				_, err := s.Get(t.Context(), dlg.Link())
+				require.True(t, errors.Is(err, store.ErrNotFound))
+
+				found, err := s.Find(t.Context(), store.Query{Audience: alice.DID()})
+				require.NoError(t, err)
+				require.Empty(t, found)
+
+				// deleting again is not an error
+				require.NoError(t, s.Delete(t.Context(), dlg.Link()))
+			})
+
+			t.Run("find", func(t *testing.T) {
+				s := newStore(t)
+				aliceBlob := testutil.Must(Blob.Delegate(space, alice, space))(t)
+				aliceBlobAdd := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+				aliceBlobs := testutil.Must(Blobs.Delegate(space, alice, space))(t)
+				aliceOther := testutil.Must(BlobAdd.Delegate(otherSpace, alice, otherSpace))(t)
+				alicePowerline := testutil.Must(BlobAdd.Delegate(bob, alice, nil, delegation.WithPowerline()))(t)
+				bobBlobAdd := testutil.Must(BlobAdd.Delegate(alice, bob, space))(t)
+
+				all := []ucan.Delegation{aliceBlob, aliceBlobAdd, aliceBlobs, aliceOther, alicePowerline, bobBlobAdd}
+				for _, dlg := range all {
+					require.NoError(t, s.Put(t.Context(), dlg))
+				}
+
+				testCases := []struct {
+					name     string
+					query    store.Query
+					expected []ucan.Delegation
+				}{
+					{"all", store.Query{}, all},
+					{"audience", store.Query{Audience: bob.DID()}, []ucan.Delegation{bobBlobAdd}},
+					{"subject", store.Query{Subject: otherSpace.DID()}, []ucan.Delegation{aliceOther, alicePowerline}},
+					{"command prefix", store.Query{Command: Blob.Command()}, []ucan.Delegation{aliceBlob, aliceBlobAdd, aliceOther, alicePowerline, bobBlobAdd}},
+					{"exact command", store.Query{Command: Blobs.Command()}, []ucan.Delegation{aliceBlobs}},
+					{"proves command", store.Query{Proves: BlobAdd.Command()}, []ucan.Delegation{aliceBlob, aliceBlobAdd, aliceOther, alicePowerline, bobBlobAdd}},
+					{"proves parent command", store.Query{Proves: Blob.Command()}, []ucan.Delegation{aliceBlob}},
+					{
+						"audience, subject and proves",
+						store.Query{Audience: alice.DID(), Subject: space.DID(), Proves: BlobAdd.Command()},
+						[]ucan.Delegation{aliceBlob, aliceBlobAdd, alicePowerline},
+					},
+					{
+						"audience, subject and command",
+						store.Query{Audience: alice.DID(), Subject: space.DID(), Command: BlobAdd.Command()},
+						[]ucan.Delegation{aliceBlobAdd, alicePowerline},
+					},
+				}
+
+				for _, tc := range testCases {
+					t.Run(tc.name, func(t *testing.T) {
+						found, err := s.Find(t.Context(), tc.query)
+						require.NoError(t, err)
+						require.ElementsMatch(t, links(tc.expected), links(found))
+					})
+				}
+			})
+
+			t.Run("prune", func(t *testing.T) {
+				s := newStore(t)
+				now := ucan.Now()
+				expired := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now-1)))(t)
+				active := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now+60)))(t)
+				forever := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithNoExpiration()))(t)
+				for _, dlg := range []ucan.Delegation{expired, active, forever} {
+					require.NoError(t, s.Put(t.Context(), dlg))
+				}
+
+				pruned, err := s.Prune(t.Context(), now)
+				require.NoError(t, err)
+				require.Equal(t, []ucan.Link{expired.Link()}, pruned)
+
+				found, err := s.Find(t.Context(), store.Query{})
+				require.NoError(t, err)
+				require.ElementsMatch(t, []ucan.Link{active.Link(), forever.Link()}, links(found))
+			})
+
+			t.Run("proof resolver", func(t *testing.T) {
+				s := newStore(t)
+				dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+				require.NoError(t, s.Put(t.Context(), dlg))
+
+				resolve := store.NewProofResolver(s)
+				got, err := resolve(t.Context(), dlg.Link())
+				require.NoError(t, err)
+				require.Equal(t, dlg.Link(), got.Link())
+
+				_, err = resolve(t.Context(), testutil.RandomCID(t))
+				require.True(t, errors.Is(err, store.ErrNotFound))
+			})
+		})
+	}
+
+	t.Run("file store reload", func(t *testing.T) {
+		dir := t.TempDir()
+		s := testutil.Must(store.NewFileStore(dir))(t)
+		dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+		deleted := testutil.Must(BlobAdd.Delegate(space, bob, space))(t)
+		require.NoError(t, s.Put(t.Context(), dlg))
+		require.NoError(t, s.Put(t.Context(), deleted))
+		require.NoError(t, s.Delete(t.Context(), deleted.Link()))
+
+		reopened := testutil.Must(store.NewFileStore(dir))(t)
+		got, err := reopened.Get(t.Context(), dlg.Link())
+		require.NoError(t, err)
+		require.Equal(t, dlg.Link(), got.Link())
+
+		found, err := reopened.Find(t.Context(), store.Query{Subject: space.DID()})
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{dlg.Link()}, links(found))
+	})
+
+	t.Run("file store reload non-canonical", func(t *testing.T) {
+		dir := t.TempDir()
+		s := testutil.Must(store.NewFileStore(dir))(t)
+		dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+		// the same delegation, with a non-minimal envelope array header
+		nonCanonical := append([]byte{0x98, 0x02}, dlg.Bytes()[1:]...)
+		decoded := testutil.Must(delegation.Decode(nonCanonical, delegation.WithStrict(false)))(t)
+		require.NoError(t, s.Put(t.Context(), decoded))
+
+		reopened := testutil.Must(store.NewFileStore(dir))(t)
+		got, err := reopened.Get(t.Context(), decoded.Link())
+		require.NoError(t, err)
+		require.Equal(t, decoded.Link(), got.Link())
+	})
+
+	t.Run("file store prune failure", func(t *testing.T) {
+		dir := t.TempDir()
+		s := testutil.Must(store.NewFileStore(dir))(t)
+		now := ucan.Now()
+		removable := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now-1)))(t)
+		stuck := testutil.Must(BlobAdd.Delegate(space, bob, space, delegation.WithExpiration(now-1)))(t)
+		require.NoError(t, s.Put(t.Context(), removable))
+		require.NoError(t, s.Put(t.Context(), stuck))
+
+		// a non-empty directory in place of the file cannot be removed
+		path := filepath.Join(dir, stuck.Link().String())
+		require.NoError(t, os.Remove(path))
+		require.NoError(t, os.MkdirAll(filepath.Join(path, "child"), 0755))
+
+		pruned, err := s.Prune(t.Context(), now)
+		require.Error(t, err)
+		require.Equal(t, []ucan.Link{removable.Link()}, pruned)
+
+		// the delegation whose file could not be removed is still in the store
+		_, err = s.Get(t.Context(), stuck.Link())
+		require.NoError(t, err)
+		_, err = s.Get(t.Context(), removable.Link())
+		require.True(t, errors.Is(err, store.ErrNotFound))
+	})
+
+	t.Run("file store concurrent put and delete", func(t *testing.T) {
+		dir := t.TempDir()
+		s := testutil.Must(store.NewFileStore(dir))(t)
+		dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+
+		var wg sync.WaitGroup
+		for range 20 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, s.Put(t.Context(), dlg))
+			}()
+			go func() {
+				defer wg.Done()
+				require.NoError(t, s.Delete(t.Context(), dlg.Link()))
+			}()
+		}
+		wg.Wait()
+
+		// the directory agrees with the index
+		_, err := s.Get(t.Context(), dlg.Link())
+		reopened := testutil.Must(store.NewFileStore(dir))(t)
+		_, rerr := reopened.Get(t.Context(), dlg.Link())
+		require.Equal(t, err == nil, rerr == nil)
+	})
+}
+
+func links(dlgs []ucan.Delegation) []ucan.Link {
+	var links []ucan.Link
+	for _, d := range dlgs {
+		links = append(links, d.Link())
+	}
+	return links
+}
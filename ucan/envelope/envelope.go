@@ -0,0 +1,64 @@
+// Package envelope inspects the signed envelope that encloses every UCAN token.
+//
+// https://github.com/ucan-wg/spec/blob/main/README.md#envelope
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// SigPayloadHeaderKey is the key of the varsig header in the signature payload.
+const SigPayloadHeaderKey = "h"
+
+// Tag reads the type tag of the token in the CBOR encoded envelope, without
+// decoding the token payload. e.g. "ucan/dlg@1.0.0-rc.1".
+func Tag(b []byte) (string, error) {
+	cr := cbg.NewCborReader(bytes.NewReader(b))
+	maj, n, err := cr.ReadHeader()
+	if err != nil {
+		return "", fmt.Errorf("reading envelope header: %w", err)
+	}
+	if maj != cbg.MajArray || n != 2 {
+		return "", errors.New("envelope is not a list of 2 items")
+	}
+	if err := skipBytes(cr); err != nil {
+		return "", fmt.Errorf("reading envelope signature: %w", err)
+	}
+	maj, n, err = cr.ReadHeader()
+	if err != nil {
+		return "", fmt.Errorf("reading signature payload header: %w", err)
+	}
+	if maj != cbg.MajMap {
+		return "", errors.New("signature payload is not a map")
+	}
+	for range n {
+		key, err := cbg.ReadString(cr)
+		if err != nil {
+			return "", fmt.Errorf("reading signature payload key: %w", err)
+		}
+		if key != SigPayloadHeaderKey {
+			return key, nil
+		}
+		if err := skipBytes(cr); err != nil {
+			return "", fmt.Errorf("reading varsig header: %w", err)
+		}
+	}
+	return "", errors.New("signature payload has no tag")
+}
+
+func skipBytes(cr *cbg.CborReader) error {
+	maj, n, err := cr.ReadHeader()
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajByteString {
+		return errors.New("expected bytes")
+	}
+	_, err = io.CopyN(io.Discard, cr, int64(n))
+	return err
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	cmd "github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/crypto/signature"
@@ -159,7 +160,13 @@ func (inv *Invocation) MarshalCBOR(w io.Writer) error {
 	return err
 }
 
+// UnmarshalCBOR decodes the invocation, returning an error if it is not encoded
+// in the canonical DAG-CBOR form.
 func (inv *Invocation) UnmarshalCBOR(r io.Reader) error {
+	return inv.unmarshalCBOR(r, true)
+}
+
+func (inv *Invocation) unmarshalCBOR(r io.Reader, strict bool) error {
 	*inv = Invocation{}
 	var w bytes.Buffer
 	model := idm.EnvelopeModel{}
@@ -167,6 +174,11 @@ func (inv *Invocation) UnmarshalCBOR(r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("unmarshaling invocation envelope CBOR: %w", err)
 	}
+	if strict {
+		if err := dagcbor.CheckCanonical(w.Bytes()); err != nil {
+			return fmt.Errorf("checking invocation CBOR: %w", err)
+		}
+	}
 	if model.SigPayload.TokenPayload1_0_0_rc1 == nil {
 		return errors.New("invalid or unsupported invocation token payload")
 	}
@@ -256,10 +268,37 @@ func Encode(inv ucan.Invocation) ([]byte, error) {
 }
 
 // Decode invocation from CBOR.
-func Decode(b []byte) (*Invocation, error) {
+func Decode(b []byte, options ...DecodeOption) (*Invocation, error) {
+	cfg := decodeConfig{strict: true, limits: limits.Default}
+	for _, opt := range options {
+		opt(&cfg)
+	}
 	inv := Invocation{}
-	err := inv.UnmarshalCBOR(bytes.NewReader(b))
-	return &inv, err
+	// the nesting depth is checked before decoding, which bounds the recursion
+	// of the decoders
+	if err := dagcbor.CheckNesting(b, cfg.limits.MaxNesting); err != nil {
+		return &inv, fmt.Errorf("checking invocation CBOR: %w", err)
+	}
+	if cfg.strict {
+		// check the whole input so that trailing bytes are also rejected
+		if err := dagcbor.CheckCanonical(b); err != nil {
+			return &inv, fmt.Errorf("checking invocation CBOR: %w", err)
+		}
+		err := inv.unmarshalCBOR(bytes.NewReader(b), false)
+		return &inv, err
+	}
+	// maps within the invocation are always decoded strictly, so decode the
+	// canonical form but keep the bytes as they were received
+	canonical, raw, link, err := dagcbor.DecodeLenient(b)
+	if err != nil {
+		return &inv, fmt.Errorf("checking invocation CBOR: %w", err)
+	}
+	if err := inv.unmarshalCBOR(bytes.NewReader(canonical), false); err != nil {
+		return &inv, err
+	}
+	inv.link = link
+	inv.bytes = raw
+	return &inv, nil
 }
 
 func Invoke(
@@ -1,9 +1,14 @@
 package invocation_test
 
 import (
+	"bytes"
+	"errors"
+	"slices"
 	"testing"
 
 	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/principal/secp256k1"
 	"github.com/alanshaw/ucantone/testutil"
 	"github.com/alanshaw/ucantone/ucan"
@@ -180,3 +185,60 @@ func TestInvoke(t *testing.T) {
 		require.True(t, ok)
 	})
 }
+
+func TestStrictDecode(t *testing.T) {
+	issuer := testutil.RandomSigner(t)
+	subject := testutil.RandomDID(t)
+	command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	inv, err := invocation.Invoke(issuer, subject, command, testutil.RandomArgs(t))
+	require.NoError(t, err)
+
+	trailing := append(slices.Clone(inv.Bytes()), 0x00)
+
+	_, err = invocation.Decode(trailing)
+	require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+
+	_, err = invocation.Decode(trailing, invocation.WithStrict(false))
+	require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+
+	t.Run("unsorted arguments", func(t *testing.T) {
+		inv, err := invocation.Invoke(issuer, subject, command, ipld.Map{"a": int64(1), "b": int64(2)})
+		require.NoError(t, err)
+
+		// {"a": 1, "b": 2} => {"b": 2, "a": 1}
+		sorted := []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x02}
+		require.Equal(t, 1, bytes.Count(inv.Bytes(), sorted))
+		unsorted := bytes.Replace(inv.Bytes(), sorted, []byte{0xa2, 0x61, 'b', 0x02, 0x61, 'a', 0x01}, 1)
+
+		_, err = invocation.Decode(unsorted)
+		require.True(t, errors.Is(err, dagcbor.ErrNonCanonical))
+
+		decoded, err := invocation.Decode(unsorted, invocation.WithStrict(false))
+		require.NoError(t, err)
+		require.Equal(t, inv.Arguments(), decoded.Arguments())
+	})
+}
+
+func TestDecodeLimits(t *testing.T) {
+	issuer := testutil.RandomSigner(t)
+	subject := testutil.RandomDID(t)
+	command := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	var value any = "deep"
+	for range limits.Default.MaxNesting + 10 {
+		value = []any{value}
+	}
+	inv, err := invocation.Invoke(issuer, subject, command, ipld.Map{"value": value})
+	require.NoError(t, err)
+
+	_, err = invocation.Decode(inv.Bytes())
+	require.ErrorContains(t, err, limits.NestingLimit)
+
+	_, err = invocation.Decode(inv.Bytes(), invocation.WithLimits(limits.Limits{MaxNesting: 8}))
+	require.ErrorContains(t, err, limits.NestingLimit)
+
+	decoded, err := invocation.Decode(inv.Bytes(), invocation.WithLimits(limits.Unlimited))
+	require.NoError(t, err)
+	require.Equal(t, inv.Link(), decoded.Link())
+}
@@ -3,6 +3,7 @@ package invocation
 import (
 	"github.com/alanshaw/ucantone/did"
 	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/limits"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/ipfs/go-cid"
 )
@@ -95,3 +96,32 @@ func WithCause(cause ucan.Link) Option {
 		cfg.cause = &cause
 	}
 }
+
+// DecodeOption is an option configuring how a UCAN invocation is decoded.
+type DecodeOption func(cfg *decodeConfig)
+
+type decodeConfig struct {
+	strict bool
+	limits limits.Limits
+}
+
+// WithStrict configures whether the encoded invocation must be in the canonical
+// DAG-CBOR form. This applies to the whole token, including arguments and
+// metadata. Strict decoding is enabled by default. When disabled, the token is
+// decoded from its canonical form, but the bytes and link are those of the
+// data as it was received.
+func WithStrict(enabled bool) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.strict = enabled
+	}
+}
+
+// WithLimits configures the limits enforced when decoding the invocation: the
+// nesting depth of lists and maps. Zero values in the limits are replaced by
+// the defaults, and [limits.Default] is enforced when this option is not used.
+// Pass [limits.Unlimited] to decode an invocation from a trusted source.
+func WithLimits(l limits.Limits) DecodeOption {
+	return func(cfg *decodeConfig) {
+		cfg.limits = l.OrDefault()
+	}
+}
@@ -1,7 +1,6 @@
 package receipt
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -48,13 +47,18 @@ func (rcpt *Receipt) UnmarshalCBOR(r io.Reader) error {
 	if err != nil {
 		return err
 	}
+	return rcpt.fromInvocation(inv)
+}
 
+// fromInvocation initializes the receipt from the decoded invocation that
+// encodes it.
+func (rcpt *Receipt) fromInvocation(inv invocation.Invocation) error {
 	if inv.Command() != Command {
 		return fmt.Errorf("invalid receipt command %s, expected %s", inv.Command().String(), Command.String())
 	}
 
 	var receiptArgs rdm.ArgsModel
-	err = datamodel.Rebind(datamodel.Map(inv.Arguments()), &receiptArgs)
+	err := datamodel.Rebind(datamodel.Map(inv.Arguments()), &receiptArgs)
 	if err != nil {
 		return fmt.Errorf("decoding receipt arguments: %w", err)
 	}
@@ -81,10 +85,15 @@ func Encode(rcpt ucan.Receipt) ([]byte, error) {
 	return rcpt.Bytes(), nil
 }
 
-// Decode receipt from CBOR.
-func Decode(b []byte) (*Receipt, error) {
+// Decode receipt from CBOR. Receipts are invocations, so they are decoded
+// using invocation decode options.
+func Decode(b []byte, options ...invocation.DecodeOption) (*Receipt, error) {
 	rcpt := Receipt{}
-	err := rcpt.UnmarshalCBOR(bytes.NewReader(b))
+	inv, err := invocation.Decode(b, options...)
+	if err != nil {
+		return &rcpt, err
+	}
+	err = rcpt.fromInvocation(*inv)
 	return &rcpt, err
 }
 
@@ -0,0 +1,161 @@
+// Package token decodes UCAN tokens of any type, and stores and retrieves them
+// from a [blockstore.Blockstore].
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/alanshaw/ucantone/ipld/blockstore"
+	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	ddm "github.com/alanshaw/ucantone/ucan/delegation/datamodel"
+	"github.com/alanshaw/ucantone/ucan/envelope"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	idm "github.com/alanshaw/ucantone/ucan/invocation/datamodel"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	"github.com/alanshaw/ucantone/validator"
+)
+
+// ErrUnknownTag is returned when decoding a token whose envelope tag has no
+// registered decoder.
+var ErrUnknownTag = errors.New("unknown token tag")
+
+// DecoderFunc decodes a CBOR encoded UCAN token.
+type DecoderFunc func(b []byte) (ucan.Token, error)
+
+// Registry chooses the decoder for a token by the tag in its envelope.
+type Registry struct {
+	mutex    sync.RWMutex
+	decoders map[string]DecoderFunc
+}
+
+// NewRegistry creates a new, empty, token registry.
+func NewRegistry() *Registry {
+	return &Registry{decoders: map[string]DecoderFunc{}}
+}
+
+// Register adds a decoder for tokens with the passed envelope tag, replacing
+// any existing decoder for the tag.
+func (r *Registry) Register(tag string, decoder DecoderFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.decoders[tag] = decoder
+}
+
+// Decode a CBOR encoded token using the decoder registered for its envelope
+// tag. It returns an error wrapping [ErrUnknownTag] if there is no decoder for
+// the tag.
+func (r *Registry) Decode(b []byte) (ucan.Token, error) {
+	tag, err := envelope.Tag(b)
+	if err != nil {
+		// the envelope may not be canonically encoded, in which case the tag is
+		// read from the canonical form, and the decoder passed the original bytes
+		canonical, _, cerr := dagcbor.Canonicalize(b)
+		if cerr != nil {
+			return nil, fmt.Errorf("reading token tag: %w", err)
+		}
+		if tag, err = envelope.Tag(canonical); err != nil {
+			return nil, fmt.Errorf("reading token tag: %w", err)
+		}
+	}
+	r.mutex.RLock()
+	decoder, ok := r.decoders[tag]
+	r.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTag, tag)
+	}
+	return decoder(b)
+}
+
+// Get retrieves a token from the block store and decodes it. It returns
+// [blockstore.ErrNotFound] if the token is not in the store.
+func (r *Registry) Get(ctx context.Context, bs blockstore.Blockstore, link ucan.Link) (ucan.Token, error) {
+	blk, err := bs.Get(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := r.Decode(blk.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("decoding token %q: %w", link, err)
+	}
+	if tkn.Link() != link {
+		return nil, fmt.Errorf("block %q contains token %q", link, tkn.Link())
+	}
+	return tkn, nil
+}
+
+// Default is the registry used by the package level functions. It decodes
+// delegations, invocations and receipts.
+var Default = NewRegistry()
+
+func init() {
+	Default.Register(ddm.Tag, decodeDelegation)
+	Default.Register(idm.Tag, decodeInvocation)
+}
+
+// decodeDelegation decodes a delegation leniently, so that tokens stored with
+// their non-canonical bytes can be read back. Callers that retrieve tokens by
+// CID check it against the bytes.
+func decodeDelegation(b []byte) (ucan.Token, error) {
+	dlg, err := delegation.Decode(b, delegation.WithStrict(false))
+	if err != nil {
+		return nil, err
+	}
+	return dlg, nil
+}
+
+// decodeInvocation decodes an invocation, or a receipt, since receipts are
+// invocations of the receipt command. Like delegations, they are decoded
+// leniently.
+func decodeInvocation(b []byte) (ucan.Token, error) {
+	inv, err := invocation.Decode(b, invocation.WithStrict(false))
+	if err != nil {
+		return nil, err
+	}
+	if inv.Command() != receipt.Command {
+		return inv, nil
+	}
+	rcpt, err := receipt.Decode(b, invocation.WithStrict(false))
+	if err != nil {
+		return nil, err
+	}
+	return rcpt, nil
+}
+
+// Decode a CBOR encoded token using the [Default] registry. Tokens are decoded
+// leniently, see [delegation.WithStrict].
+func Decode(b []byte) (ucan.Token, error) {
+	return Default.Decode(b)
+}
+
+// Get retrieves a token from the block store and decodes it using the
+// [Default] registry.
+func Get(ctx context.Context, bs blockstore.Blockstore, link ucan.Link) (ucan.Token, error) {
+	return Default.Get(ctx, bs, link)
+}
+
+// Put adds a token to the block store. Tokens are stored using their exact
+// encoded bytes, so they may be retrieved by the same CID.
+func Put(ctx context.Context, bs blockstore.Blockstore, tkn ucan.Token) error {
+	return bs.Put(ctx, tkn)
+}
+
+// NewProofResolver creates a [validator.ProofResolverFunc] that resolves proofs
+// from the passed block store.
+func NewProofResolver(bs blockstore.Blockstore) validator.ProofResolverFunc {
+	return func(ctx context.Context, link ucan.Link) (ucan.Delegation, error) {
+		tkn, err := Get(ctx, bs, link)
+		if err != nil {
+			return nil, err
+		}
+		dlg, ok := tkn.(ucan.Delegation)
+		if !ok {
+			return nil, fmt.Errorf("token %q is not a delegation", link)
+		}
+		return dlg, nil
+	}
+}
@@ -0,0 +1,126 @@
+package token_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/blockstore"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/command"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	ddm "github.com/alanshaw/ucantone/ucan/delegation/datamodel"
+	"github.com/alanshaw/ucantone/ucan/envelope"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	idm "github.com/alanshaw/ucantone/ucan/invocation/datamodel"
+	"github.com/alanshaw/ucantone/ucan/receipt"
+	"github.com/alanshaw/ucantone/ucan/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+	cmd := testutil.Must(command.Parse("/test/invoke"))(t)
+
+	dlg, err := delegation.Delegate(alice, bob, alice, cmd)
+	require.NoError(t, err)
+
+	inv, err := invocation.Invoke(bob, alice, cmd, testutil.RandomArgs(t), invocation.WithProofs(dlg.Link()))
+	require.NoError(t, err)
+
+	rcpt, err := receipt.Issue(service, inv.Task().Link(), result.OK[ipld.Map, ipld.Any](datamodel.Map{}))
+	require.NoError(t, err)
+
+	t.Run("envelope tag", func(t *testing.T) {
+		tag, err := envelope.Tag(dlg.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, ddm.Tag, tag)
+
+		tag, err = envelope.Tag(inv.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, idm.Tag, tag)
+
+		_, err = envelope.Tag([]byte{0x01})
+		require.Error(t, err)
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		tkn, err := token.Decode(dlg.Bytes())
+		require.NoError(t, err)
+		require.IsType(t, &delegation.Delegation{}, tkn)
+		require.Equal(t, dlg.Link(), tkn.Link())
+
+		tkn, err = token.Decode(inv.Bytes())
+		require.NoError(t, err)
+		require.IsType(t, &invocation.Invocation{}, tkn)
+		require.Equal(t, inv.Link(), tkn.Link())
+
+		tkn, err = token.Decode(rcpt.Bytes())
+		require.NoError(t, err)
+		require.IsType(t, &receipt.Receipt{}, tkn)
+		require.Equal(t, rcpt.Link(), tkn.Link())
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		_, err := token.NewRegistry().Decode(dlg.Bytes())
+		require.True(t, errors.Is(err, token.ErrUnknownTag))
+	})
+
+	t.Run("custom decoder", func(t *testing.T) {
+		reg := token.NewRegistry()
+		reg.Register(ddm.Tag, func(b []byte) (ucan.Token, error) {
+			return delegation.Decode(b, delegation.WithStrict(false))
+		})
+		tkn, err := reg.Decode(dlg.Bytes())
+		require.NoError(t, err)
+		require.Equal(t, dlg.Link(), tkn.Link())
+	})
+
+	t.Run("put and get", func(t *testing.T) {
+		bs := blockstore.NewMemoryStore()
+		for _, tkn := range []ucan.Token{dlg, inv, rcpt} {
+			require.NoError(t, token.Put(t.Context(), bs, tkn))
+		}
+
+		tkn, err := token.Get(t.Context(), bs, rcpt.Link())
+		require.NoError(t, err)
+		require.IsType(t, &receipt.Receipt{}, tkn)
+
+		_, err = token.Get(t.Context(), bs, testutil.RandomCID(t))
+		require.True(t, errors.Is(err, blockstore.ErrNotFound))
+	})
+
+	t.Run("put and get non-canonical", func(t *testing.T) {
+		// the same delegation, with a non-minimal envelope array header
+		nonCanonical := append([]byte{0x98, 0x02}, dlg.Bytes()[1:]...)
+		decoded, err := delegation.Decode(nonCanonical, delegation.WithStrict(false))
+		require.NoError(t, err)
+
+		bs := blockstore.NewMemoryStore()
+		require.NoError(t, token.Put(t.Context(), bs, decoded))
+
+		tkn, err := token.Get(t.Context(), bs, decoded.Link())
+		require.NoError(t, err)
+		require.Equal(t, decoded.Link(), tkn.Link())
+		require.NotEqual(t, dlg.Link(), tkn.Link())
+	})
+
+	t.Run("proof resolver", func(t *testing.T) {
+		bs := blockstore.NewMemoryStore()
+		require.NoError(t, token.Put(t.Context(), bs, dlg))
+		require.NoError(t, token.Put(t.Context(), bs, inv))
+
+		resolve := token.NewProofResolver(bs)
+		prf, err := resolve(t.Context(), dlg.Link())
+		require.NoError(t, err)
+		require.Equal(t, dlg.Link(), prf.Link())
+
+		_, err = resolve(t.Context(), inv.Link())
+		require.ErrorContains(t, err, "not a delegation")
+	})
+}
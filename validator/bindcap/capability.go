@@ -1,18 +1,17 @@
 package bindcap
 
 import (
-	"reflect"
-
 	"github.com/alanshaw/ucantone/ipld/codec/dagcbor"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/delegation"
 	"github.com/alanshaw/ucantone/ucan/invocation"
 	"github.com/alanshaw/ucantone/validator/capability"
-	verrs "github.com/alanshaw/ucantone/validator/errors"
 	"github.com/ipfs/go-cid"
 )
 
+// Arguments are the arguments of a capability. Use [datamodel.Typed] to bind
+// arguments to a plain Go struct using reflection.
 type Arguments interface {
 	dagcbor.Marshalable
 }
@@ -32,6 +31,16 @@ func New[A Arguments](cmd ucan.Command, options ...capability.Option) (*Capabili
 	return &Capability[A]{cap}, nil
 }
 
+// MustNew is like [New] but panics if the capability cannot be created. It
+// simplifies the declaration of capabilities as package level variables.
+func MustNew[A Arguments](cmd ucan.Command, options ...capability.Option) *Capability[A] {
+	cap, err := New[A](cmd, options...)
+	if err != nil {
+		panic(err)
+	}
+	return cap
+}
+
 // Match an invocation against the capability, resulting in a match, which is
 // the task from the invocation, verified to be matching with delegation
 // policies.
@@ -39,15 +48,8 @@ func New[A Arguments](cmd ucan.Command, options ...capability.Option) (*Capabili
 // Match also ensures the invocation arguments can be bound to the
 // specified Arguments type A.
 func (c *Capability[A]) Match(inv ucan.Invocation, proofs map[cid.Cid]ucan.Delegation) (*capability.Match, error) {
-	var args A
-	// if args is a pointer type, then we need to create an instance of it because
-	// rebind requires a non-nil pointer.
-	typ := reflect.TypeOf(args)
-	if typ.Kind() == reflect.Ptr {
-		args = reflect.New(typ.Elem()).Interface().(A)
-	}
-	if err := datamodel.Rebind(datamodel.Map(inv.Arguments()), args); err != nil {
-		return nil, verrs.NewMalformedArgumentsError(inv.Command(), err)
+	if _, err := datamodel.BindAs[A](inv.Arguments()); err != nil {
+		return nil, capability.NewMalformedArgumentsError(inv.Command(), err)
 	}
 	return c.cap.Match(inv, proofs)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/schema"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/delegation"
@@ -22,8 +23,9 @@ type Match struct {
 
 // Capability that can be used to validate an invocation against proof policies.
 type Capability struct {
-	cmd ucan.Command
-	pol ucan.Policy
+	cmd    ucan.Command
+	pol    ucan.Policy
+	schema schema.Schema
 }
 
 // New creates a new capability definition that can be used to validate an
@@ -40,13 +42,23 @@ func New(cmd ucan.Command, options ...Option) (*Capability, error) {
 	if err != nil {
 		return nil, fmt.Errorf("parsing command: %w", err)
 	}
-	return &Capability{cmd, cfg.pol}, nil
+	return &Capability{cmd, cfg.pol, cfg.schema}, nil
 }
 
 // Match an invocation against the capability, resulting in a match, which is
 // the task from the invocation, verified to be matching with delegation
 // policies.
+//
+// If the capability has a schema, the invocation arguments are validated
+// against it before any policy is evaluated, and a MalformedArguments error is
+// returned if they do not match.
 func (c *Capability) Match(inv ucan.Invocation, proofs map[cid.Cid]ucan.Delegation) (*Match, error) {
+	if c.schema != nil {
+		if err := schema.Validate(c.schema, inv.Arguments()); err != nil {
+			return nil, NewMalformedArgumentsError(inv.Command(), err)
+		}
+	}
+
 	ok, err := policy.Match(c.pol, inv.Arguments())
 	if !ok {
 		return nil, err
@@ -76,6 +88,12 @@ func (c *Capability) Policy() ucan.Policy {
 	return c.pol
 }
 
+// Schema is the schema invocation arguments must match, or nil if the
+// arguments are not validated.
+func (c *Capability) Schema() schema.Schema {
+	return c.schema
+}
+
 func (c *Capability) Delegate(issuer ucan.Signer, audience ucan.Principal, subject ucan.Subject, options ...delegation.Option) (*delegation.Delegation, error) {
 	return delegation.Delegate(issuer, audience, subject, c.cmd, options...)
 }
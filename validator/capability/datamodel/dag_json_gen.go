@@ -0,0 +1,193 @@
+// Code generated by github.com/alanshaw/dag-json-gen. DO NOT EDIT.
+
+package datamodel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	jsg "github.com/alanshaw/dag-json-gen"
+	cid "github.com/ipfs/go-cid"
+)
+
+var _ = cid.Undef
+var _ = math.E
+var _ = sort.Sort
+var _ = errors.Is
+
+func (t *MalformedArgumentsErrorModel) MarshalDagJSON(w io.Writer) error {
+	jw := jsg.NewDagJsonWriter(w)
+	if t == nil {
+		err := jw.WriteNull()
+		return err
+	}
+	if err := jw.WriteObjectOpen(); err != nil {
+		return err
+	}
+	written := 0
+
+	// t.Message (string) (string)
+	if len("message") > 8192 {
+		return fmt.Errorf("String in field \"message\" was too long")
+	}
+	if err := jw.WriteString(string("message")); err != nil {
+		return fmt.Errorf("\"message\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Message) > 8192 {
+		return fmt.Errorf("String in field t.Message was too long")
+	}
+	if err := jw.WriteString(string(t.Message)); err != nil {
+		return fmt.Errorf("t.Message: %w", err)
+	}
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.ErrorName (string) (string)
+	if len("name") > 8192 {
+		return fmt.Errorf("String in field \"name\" was too long")
+	}
+	if err := jw.WriteString(string("name")); err != nil {
+		return fmt.Errorf("\"name\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.ErrorName) > 8192 {
+		return fmt.Errorf("String in field t.ErrorName was too long")
+	}
+	if err := jw.WriteString(string(t.ErrorName)); err != nil {
+		return fmt.Errorf("t.ErrorName: %w", err)
+	}
+	written++
+	if written > 0 {
+		if err := jw.WriteComma(); err != nil {
+			return err
+		}
+	}
+
+	// t.Path (string) (string)
+	if len("path") > 8192 {
+		return fmt.Errorf("String in field \"path\" was too long")
+	}
+	if err := jw.WriteString(string("path")); err != nil {
+		return fmt.Errorf("\"path\": %w", err)
+	}
+	if err := jw.WriteObjectColon(); err != nil {
+		return err
+	}
+	if len(t.Path) > 8192 {
+		return fmt.Errorf("String in field t.Path was too long")
+	}
+	if err := jw.WriteString(string(t.Path)); err != nil {
+		return fmt.Errorf("t.Path: %w", err)
+	}
+	written++
+	if err := jw.WriteObjectClose(); err != nil {
+		return err
+	}
+	return nil
+}
+func (t *MalformedArgumentsErrorModel) UnmarshalDagJSON(r io.Reader) (err error) {
+	*t = MalformedArgumentsErrorModel{}
+
+	jr := jsg.NewDagJsonReader(r)
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+	if err := jr.ReadObjectOpen(); err != nil {
+		return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+	}
+	close, err := jr.PeekObjectClose()
+	if err != nil {
+		return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+	}
+	if close {
+		if err := jr.ReadObjectClose(); err != nil {
+			return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+		}
+	} else {
+		for i := uint64(0); i < 8192; i++ {
+			name, err := jr.ReadString(8192)
+			if err != nil {
+				if errors.Is(err, jsg.ErrLimitExceeded) {
+					return fmt.Errorf("MalformedArgumentsErrorModel: string too large")
+				}
+				return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+			}
+			if err := jr.ReadObjectColon(); err != nil {
+				return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+			}
+			switch name {
+
+			// t.Message (string) (string)
+			case "message":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Message: string too long")
+						}
+						return fmt.Errorf("t.Message: %w", err)
+					}
+					t.Message = string(sval)
+				}
+
+				// t.ErrorName (string) (string)
+			case "name":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.ErrorName: string too long")
+						}
+						return fmt.Errorf("t.ErrorName: %w", err)
+					}
+					t.ErrorName = string(sval)
+				}
+
+				// t.Path (string) (string)
+			case "path":
+				{
+					sval, err := jr.ReadString(8192)
+					if err != nil {
+						if errors.Is(err, jsg.ErrLimitExceeded) {
+							return fmt.Errorf("t.Path: string too long")
+						}
+						return fmt.Errorf("t.Path: %w", err)
+					}
+					t.Path = string(sval)
+				}
+			default:
+				// Field doesn't exist on this type, so ignore it
+				if err := jr.DiscardType(); err != nil {
+					return fmt.Errorf("MalformedArgumentsErrorModel: ignoring field %s: %w", name, err)
+				}
+			}
+
+			close, err := jr.ReadObjectCloseOrComma()
+			if err != nil {
+				return fmt.Errorf("MalformedArgumentsErrorModel: %w", err)
+			}
+			if close {
+				break
+			}
+			if i == 8192-1 {
+				return fmt.Errorf("MalformedArgumentsErrorModel: map too large")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package datamodel
+
+type MalformedArgumentsErrorModel struct {
+	ErrorName string `cborgen:"name" dagjsongen:"name"`
+	Message   string `cborgen:"message" dagjsongen:"message"`
+	// Path is the location of the malformed value in the arguments, in selector
+	// syntax e.g. ".to[0]".
+	Path string `cborgen:"path" dagjsongen:"path"`
+}
+
+func (me MalformedArgumentsErrorModel) Name() string {
+	return me.ErrorName
+}
+
+func (me MalformedArgumentsErrorModel) Error() string {
+	return me.Message
+}
+
+var _ error = (*MalformedArgumentsErrorModel)(nil)
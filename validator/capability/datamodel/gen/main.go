@@ -0,0 +1,20 @@
+package main
+
+import (
+	jsg "github.com/alanshaw/dag-json-gen"
+	cdm "github.com/alanshaw/ucantone/validator/capability/datamodel"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func main() {
+	if err := cbg.WriteMapEncodersToFile("../cbor_gen.go", "datamodel",
+		cdm.MalformedArgumentsErrorModel{},
+	); err != nil {
+		panic(err)
+	}
+	if err := jsg.WriteMapEncodersToFile("../dag_json_gen.go", "datamodel",
+		cdm.MalformedArgumentsErrorModel{},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -1,17 +1,28 @@
 package capability
 
 import (
+	"errors"
 	"fmt"
 
-	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ipld/schema"
 	"github.com/alanshaw/ucantone/ucan"
+	cdm "github.com/alanshaw/ucantone/validator/capability/datamodel"
 )
 
 const MalformedArgumentsErrorName = "MalformedArguments"
 
-func NewMalformedArgumentsError(cmd ucan.Command, cause error) edm.ErrorModel {
-	return edm.ErrorModel{
+// NewMalformedArgumentsError creates an error indicating the invocation
+// arguments are malformed. If the cause is a [*schema.Error], the path of the
+// malformed value is included.
+func NewMalformedArgumentsError(cmd ucan.Command, cause error) *cdm.MalformedArgumentsErrorModel {
+	var path string
+	var serr *schema.Error
+	if errors.As(cause, &serr) {
+		path = serr.Path
+	}
+	return &cdm.MalformedArgumentsErrorModel{
 		ErrorName: MalformedArgumentsErrorName,
 		Message:   fmt.Sprintf("malformed arguments for command %s: %s", cmd, cause.Error()),
+		Path:      path,
 	}
 }
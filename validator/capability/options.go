@@ -1,12 +1,14 @@
 package capability
 
 import (
+	"github.com/alanshaw/ucantone/ipld/schema"
 	"github.com/alanshaw/ucantone/ucan"
 	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 )
 
 type capabilityConfig struct {
-	pol policy.Policy
+	pol    policy.Policy
+	schema schema.Schema
 }
 
 // Option is an option configuring a capability definition.
@@ -36,3 +38,12 @@ func WithPolicyBuilder(statements ...policy.StatementBuilderFunc) Option {
 		return nil
 	}
 }
+
+// WithSchema configures the schema that invocation arguments must match. Use
+// [schema.Struct] to declare the fields of the arguments.
+func WithSchema(s schema.Schema) Option {
+	return func(cfg *capabilityConfig) error {
+		cfg.schema = s
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package chain
+
+import (
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/alanshaw/ucantone/validator"
+)
+
+// Find searches the passed delegations for the shortest valid proof chain that
+// authorizes the issuer to invoke the command on the subject.
+//
+// The returned delegations are ordered from the root delegation (issued by the
+// subject) to the delegation issued to the invoker, which is the order expected
+// by invocation.WithProofs (see [Links]). An empty chain is returned if the
+// issuer can invoke the command without proofs (e.g. it is the subject).
+//
+// Delegations in the chain are aligned by principal and subject, the command of
+// each proves the command of the next and finally the invoked command, and all
+// are within their time bounds.
+func Find(
+	issuer ucan.Principal,
+	subject ucan.Subject,
+	command ucan.Command,
+	delegations []ucan.Delegation,
+	options ...Option,
+) ([]ucan.Delegation, error) {
+	cfg := findConfig{canIssue: validator.IsSelfIssued}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	now := ucan.Now()
+	if cfg.now != nil {
+		now = *cfg.now
+	}
+
+	if cfg.canIssue(delegation.NewCapability(subject, command, policy.Policy{}), issuer) {
+		return []ucan.Delegation{}, nil
+	}
+
+	usable := func(dlg ucan.Delegation) bool {
+		if dlg.Subject() != nil && dlg.Subject().DID() != subject.DID() {
+			return false
+		}
+		if validator.ValidateNotExpired(dlg, now) != nil {
+			return false
+		}
+		if validator.ValidateNotTooEarly(dlg, now) != nil {
+			return false
+		}
+		if cfg.args != nil {
+			if ok, _ := policy.Match(dlg.Policy(), cfg.args); !ok {
+				return false
+			}
+		}
+		return true
+	}
+
+	// index candidate delegations by audience
+	byAudience := map[did.DID][]ucan.Delegation{}
+	for _, dlg := range delegations {
+		if !usable(dlg) {
+			continue
+		}
+		aud := dlg.Audience().DID()
+		byAudience[aud] = append(byAudience[aud], dlg)
+	}
+
+	// Breadth first search from the invoker towards the subject. Each node is a
+	// delegation, linked to the delegation it proves (its child).
+	type node struct {
+		dlg   ucan.Delegation
+		child *node
+	}
+
+	visited := map[ucan.Link]struct{}{}
+	var queue []*node
+	for _, dlg := range byAudience[issuer.DID()] {
+		if !dlg.Command().Proves(command) {
+			continue
+		}
+		visited[dlg.Link()] = struct{}{}
+		queue = append(queue, &node{dlg: dlg})
+	}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if isRoot(n.dlg, subject, cfg.canIssue) {
+			var chain []ucan.Delegation
+			for c := n; c != nil; c = c.child {
+				chain = append(chain, c.dlg)
+			}
+			return chain, nil
+		}
+
+		for _, parent := range byAudience[n.dlg.Issuer().DID()] {
+			if _, ok := visited[parent.Link()]; ok {
+				continue
+			}
+			if !parent.Command().Proves(n.dlg.Command()) {
+				continue
+			}
+			visited[parent.Link()] = struct{}{}
+			queue = append(queue, &node{dlg: parent, child: n})
+		}
+	}
+
+	return nil, NewNoProofChainError(issuer, subject, command)
+}
+
+// Links returns the links of the passed delegations, in order.
+func Links(chain []ucan.Delegation) []ucan.Link {
+	links := make([]ucan.Link, 0, len(chain))
+	for _, dlg := range chain {
+		links = append(links, dlg.Link())
+	}
+	return links
+}
+
+func isRoot(dlg ucan.Delegation, subject ucan.Subject, canIssue validator.CanIssueFunc) bool {
+	// powerline is not allowed as root delegation
+	if delegation.IsPowerline(dlg) {
+		return false
+	}
+	return dlg.Subject().DID() == subject.DID() && canIssue(dlg, dlg.Issuer())
+}
@@ -0,0 +1,168 @@
+package chain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/validator"
+	"github.com/alanshaw/ucantone/validator/capability"
+	"github.com/alanshaw/ucantone/validator/chain"
+	"github.com/stretchr/testify/require"
+)
+
+type NamedError interface {
+	error
+	Name() string
+}
+
+func TestFind(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	carol := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	Blob, err := capability.New("/blob")
+	require.NoError(t, err)
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+	BlobRemove, err := capability.New("/blob/remove")
+	require.NoError(t, err)
+
+	t.Run("self issued", func(t *testing.T) {
+		proofs, err := chain.Find(space, space, BlobAdd.Command(), nil)
+		require.NoError(t, err)
+		require.Empty(t, proofs)
+	})
+
+	t.Run("single delegation", func(t *testing.T) {
+		dlg := testutil.Must(BlobAdd.Delegate(space, alice, space))(t)
+
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{dlg})
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{dlg.Link()}, chain.Links(proofs))
+	})
+
+	t.Run("multiple delegations in order", func(t *testing.T) {
+		// space -> carol -> bob -> alice, passed in reverse order
+		carolDlg := testutil.Must(Blob.Delegate(space, carol, space))(t)
+		bobDlg := testutil.Must(Blob.Delegate(carol, bob, space))(t)
+		aliceDlg := testutil.Must(BlobAdd.Delegate(bob, alice, space))(t)
+
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{aliceDlg, bobDlg, carolDlg})
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{carolDlg.Link(), bobDlg.Link(), aliceDlg.Link()}, chain.Links(proofs))
+
+		// the chain is accepted by the validator
+		inv := testutil.Must(BlobAdd.Invoke(
+			alice,
+			space,
+			datamodel.Map{},
+			invocation.WithAudience(service),
+			invocation.WithProofs(chain.Links(proofs)...),
+		))(t)
+		_, err = validator.Access(t.Context(), service.Verifier(), BlobAdd, inv, validator.WithProofs(proofs...))
+		require.NoError(t, err)
+	})
+
+	t.Run("shortest chain", func(t *testing.T) {
+		// space -> carol -> bob -> alice
+		carolDlg := testutil.Must(BlobAdd.Delegate(space, carol, space))(t)
+		bobDlg := testutil.Must(BlobAdd.Delegate(carol, bob, space))(t)
+		aliceDlg0 := testutil.Must(BlobAdd.Delegate(bob, alice, space))(t)
+		// space -> carol -> alice
+		aliceDlg1 := testutil.Must(BlobAdd.Delegate(carol, alice, space))(t)
+
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{aliceDlg0, bobDlg, carolDlg, aliceDlg1})
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{carolDlg.Link(), aliceDlg1.Link()}, chain.Links(proofs))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		// bob -> alice -> bob, never reaching space
+		aliceDlg := testutil.Must(BlobAdd.Delegate(bob, alice, space))(t)
+		bobDlg := testutil.Must(BlobAdd.Delegate(alice, bob, space))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{aliceDlg, bobDlg})
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+
+	t.Run("powerline", func(t *testing.T) {
+		accountDlg := testutil.Must(BlobAdd.Delegate(space, bob, space))(t)
+		deviceDlg := testutil.Must(BlobAdd.Delegate(bob, alice, nil, delegation.WithPowerline()))(t)
+
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{deviceDlg, accountDlg})
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{accountDlg.Link(), deviceDlg.Link()}, chain.Links(proofs))
+	})
+
+	t.Run("powerline root", func(t *testing.T) {
+		dlg := testutil.Must(BlobAdd.Delegate(space, alice, nil, delegation.WithPowerline()))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{dlg})
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+
+	t.Run("command", func(t *testing.T) {
+		dlg := testutil.Must(BlobRemove.Delegate(space, alice, space))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{dlg})
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+
+	t.Run("widened command", func(t *testing.T) {
+		// space -> bob (/blob/add) -> alice (/blob)
+		bobDlg := testutil.Must(BlobAdd.Delegate(space, bob, space))(t)
+		aliceDlg := testutil.Must(Blob.Delegate(bob, alice, space))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{bobDlg, aliceDlg})
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+
+	t.Run("subject", func(t *testing.T) {
+		dlg := testutil.Must(BlobAdd.Delegate(bob, alice, bob))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{dlg})
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+
+	t.Run("time bounds", func(t *testing.T) {
+		now := ucan.Now()
+		expired := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now-1)))(t)
+		inactive := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithNotBefore(now+60), delegation.WithNoExpiration()))(t)
+
+		_, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{expired, inactive}, chain.WithTime(now))
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+
+		// but valid at a different time
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{expired, inactive}, chain.WithTime(now+120))
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{inactive.Link()}, chain.Links(proofs))
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		small := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithPolicyBuilder(policy.LessThan(".size", 100))))(t)
+		large := testutil.Must(BlobAdd.Delegate(space, alice, space, delegation.WithPolicyBuilder(policy.LessThan(".size", 1000))))(t)
+
+		proofs, err := chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{small, large}, chain.WithArguments(datamodel.Map{"size": 500}))
+		require.NoError(t, err)
+		require.Equal(t, []ucan.Link{large.Link()}, chain.Links(proofs))
+
+		_, err = chain.Find(alice, space, BlobAdd.Command(), []ucan.Delegation{small, large}, chain.WithArguments(datamodel.Map{"size": 5000}))
+		requireNamedError(t, err, chain.NoProofChainErrorName)
+	})
+}
+
+func requireNamedError(t *testing.T, err error, name string) {
+	t.Helper()
+	require.Error(t, err)
+	var namedErr NamedError
+	require.True(t, errors.As(err, &namedErr))
+	require.Equal(t, name, namedErr.Name())
+}
@@ -0,0 +1,17 @@
+package chain
+
+import (
+	"fmt"
+
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+const NoProofChainErrorName = "NoProofChain"
+
+func NewNoProofChainError(issuer ucan.Principal, subject ucan.Subject, cmd ucan.Command) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: NoProofChainErrorName,
+		Message:   fmt.Sprintf("no valid proof chain found for %q to invoke %q on %q", issuer.DID(), cmd, subject.DID()),
+	}
+}
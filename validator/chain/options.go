@@ -0,0 +1,42 @@
+package chain
+
+import (
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator"
+)
+
+type findConfig struct {
+	args     ipld.Map
+	canIssue validator.CanIssueFunc
+	now      *ucan.UTCUnixTimestamp
+}
+
+// Option is an option configuring proof chain discovery.
+type Option func(cfg *findConfig)
+
+// WithArguments sets the arguments of the intended invocation. When set, the
+// policies of candidate delegations are matched against the arguments and
+// delegations whose policies do not match are excluded from the chain.
+func WithArguments(args ipld.Map) Option {
+	return func(cfg *findConfig) {
+		cfg.args = args
+	}
+}
+
+// WithCanIssue informs the search whether a given capability can be issued by
+// a given principal. It is used to determine the root of the chain. By default
+// a delegation is a root if it is self issued i.e. issued by the subject.
+func WithCanIssue(canIssue validator.CanIssueFunc) Option {
+	return func(cfg *findConfig) {
+		cfg.canIssue = canIssue
+	}
+}
+
+// WithTime sets the time to be used as "now" when checking the time bounds of
+// candidate delegations.
+func WithTime(now ucan.UTCUnixTimestamp) Option {
+	return func(cfg *findConfig) {
+		cfg.now = &now
+	}
+}
@@ -106,8 +106,27 @@ func NewSubjectAlignmentError(subject ucan.Subject, t ucan.Token) edm.ErrorModel
 	}
 }
 
+const CommandAlignmentErrorName = "InvalidCommand"
+
+func NewCommandAlignmentError(t ucan.Token, dlg ucan.Delegation) edm.ErrorModel {
+	var name string
+	if _, ok := t.(ucan.Invocation); ok {
+		name = "invocation"
+	} else {
+		name = "delegation"
+	}
+	return edm.ErrorModel{
+		ErrorName: CommandAlignmentErrorName,
+		Message:   fmt.Sprintf("%s %q command %q is not proved by delegation %q command %q", name, t.Link(), t.Command(), dlg.Link(), dlg.Command()),
+	}
+}
+
+// Deprecated: Use MalformedArgumentsErrorName from the validator/capability
+// package.
 const MalformedArgumentsErrorName = "MalformedArguments"
 
+// Deprecated: Use NewMalformedArgumentsError from the validator/capability
+// package, which also includes the path of the malformed value.
 func NewMalformedArgumentsError(cmd ucan.Command, cause error) edm.ErrorModel {
 	return edm.ErrorModel{
 		ErrorName: MalformedArgumentsErrorName,
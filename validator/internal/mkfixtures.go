@@ -64,6 +64,7 @@ func main() {
 			makeValidMultipleActiveProofsFixture(),
 			makeValidPowerlineFixture(),
 			makeValidPolicyMatchFixture(),
+			makeValidCommandAttenuationFixture(),
 		},
 		Invalid: []fdm.InvalidModel{
 			makeInvalidNoProofFixture(),
@@ -79,6 +80,8 @@ func main() {
 			makeInvalidInvocationSignatureFixture(),
 			makeInvalidPowerlineFixture(),
 			makeInvalidPolicyViolationFixture(),
+			makeInvalidInvocationCommandAlignmentFixture(),
+			makeInvalidProofCommandAlignmentFixture(),
 		},
 	}
 
@@ -266,6 +269,7 @@ func makeValidPowerlineFixture() fdm.ValidModel {
 		alice,
 		nil,
 		cmd,
+		delegation.WithPowerline(),
 		delegation.WithNoExpiration(),
 		delegation.WithNonce(nonce[1]),
 	))
@@ -321,6 +325,45 @@ func makeValidPolicyMatchFixture() fdm.ValidModel {
 	}
 }
 
+func makeValidCommandAttenuationFixture() fdm.ValidModel {
+	dlg0 := must(delegation.Delegate(
+		carol,
+		bob,
+		carol,
+		command.Top(),
+		delegation.WithNoExpiration(),
+		delegation.WithNonce(nonce[0]),
+	))
+
+	dlg1 := must(delegation.Delegate(
+		bob,
+		alice,
+		carol,
+		must(command.Parse("/msg")),
+		delegation.WithNoExpiration(),
+		delegation.WithNonce(nonce[1]),
+	))
+
+	inv := must(invocation.Invoke(
+		alice,
+		carol,
+		cmd,
+		ipld.Map{},
+		invocation.WithIssuedAt(iat),
+		invocation.WithNoExpiration(),
+		invocation.WithProofs(dlg0.Link(), dlg1.Link()),
+		invocation.WithNonce(nonce[2]),
+	))
+
+	return fdm.ValidModel{
+		Name:        "command attenuation",
+		Description: "a proof chain where each delegation narrows the command of the previous and the invocation command is proved by the last",
+		Invocation:  must(invocation.Encode(inv)),
+		Proofs:      [][]byte{must(delegation.Encode(dlg0)), must(delegation.Encode(dlg1))},
+		Time:        vat,
+	}
+}
+
 func makeInvalidNoProofFixture() fdm.InvalidModel {
 	inv := must(invocation.Invoke(
 		alice,
@@ -725,6 +768,7 @@ func makeInvalidPowerlineFixture() fdm.InvalidModel {
 		alice,
 		nil,
 		cmd,
+		delegation.WithPowerline(),
 		delegation.WithNoExpiration(),
 		delegation.WithNonce(nonce[0]),
 	))
@@ -782,6 +826,77 @@ func makeInvalidPolicyViolationFixture() fdm.InvalidModel {
 	}
 }
 
+func makeInvalidInvocationCommandAlignmentFixture() fdm.InvalidModel {
+	dlg0 := must(delegation.Delegate(
+		bob,
+		alice,
+		bob,
+		must(command.Parse("/store/add")),
+		delegation.WithNoExpiration(),
+		delegation.WithNonce(nonce[0]),
+	))
+
+	inv := must(invocation.Invoke(
+		alice,
+		bob,
+		cmd,
+		ipld.Map{},
+		invocation.WithIssuedAt(iat),
+		invocation.WithNoExpiration(),
+		invocation.WithProofs(dlg0.Link()),
+		invocation.WithNonce(nonce[1]),
+	))
+
+	return fdm.InvalidModel{
+		Name:        "invocation command alignment",
+		Description: "the command of the invocation is not proved by the command of the delegation",
+		Invocation:  must(invocation.Encode(inv)),
+		Proofs:      [][]byte{must(delegation.Encode(dlg0))},
+		Error:       fdm.ErrorModel{Name: verrs.CommandAlignmentErrorName},
+		Time:        vat,
+	}
+}
+
+func makeInvalidProofCommandAlignmentFixture() fdm.InvalidModel {
+	dlg0 := must(delegation.Delegate(
+		carol,
+		bob,
+		carol,
+		cmd,
+		delegation.WithNoExpiration(),
+		delegation.WithNonce(nonce[0]),
+	))
+
+	dlg1 := must(delegation.Delegate(
+		bob,
+		alice,
+		carol,
+		command.Top(),
+		delegation.WithNoExpiration(),
+		delegation.WithNonce(nonce[1]),
+	))
+
+	inv := must(invocation.Invoke(
+		alice,
+		carol,
+		cmd,
+		ipld.Map{},
+		invocation.WithIssuedAt(iat),
+		invocation.WithNoExpiration(),
+		invocation.WithProofs(dlg0.Link(), dlg1.Link()),
+		invocation.WithNonce(nonce[2]),
+	))
+
+	return fdm.InvalidModel{
+		Name:        "proof command alignment",
+		Description: "a delegation in the proof chain widens the command of the previous delegation",
+		Invocation:  must(invocation.Encode(inv)),
+		Proofs:      [][]byte{must(delegation.Encode(dlg0)), must(delegation.Encode(dlg1))},
+		Error:       fdm.ErrorModel{Name: verrs.CommandAlignmentErrorName},
+		Time:        vat,
+	}
+}
+
 func must[O any](o O, x error) O {
 	if x != nil {
 		panic(x)
@@ -9,6 +9,8 @@ type validationConfig struct {
 	proofs                     []ucan.Delegation
 	resolveProof               ProofResolverFunc
 	resolveDIDKey              DIDResolverFunc
+	signatureCache             SignatureCache
+	trace                      *Trace
 	validateAuthorization      ValidateAuthorizationFunc
 	validationTime             ucan.UTCUnixTimestamp
 	verifyNonStandardSignature NonStandardSignatureVerifierFunc
@@ -49,6 +51,10 @@ func WithDIDResolver(resolveDIDKey DIDResolverFunc) Option {
 	}
 }
 
+// WithAuthorizationValidator sets a function that is called to further
+// validate an authorization after the invocation has been validated and the
+// capability matched. It is typically used to check that the delegations from
+// the authorization have not been revoked.
 func WithAuthorizationValidator(validateAuthorization ValidateAuthorizationFunc) Option {
 	return func(vc *validationConfig) {
 		vc.validateAuthorization = validateAuthorization
@@ -77,3 +83,24 @@ func WithMetadata(meta ucan.Container) Option {
 		vc.metadata = meta
 	}
 }
+
+// WithSignatureCache sets a cache of verified delegation signatures. When a
+// delegation signature has already been verified by the same key it is not
+// verified again. Time bounds are always checked, regardless of the cache.
+//
+// By default, no cache is used and all signatures are verified.
+func WithSignatureCache(cache SignatureCache) Option {
+	return func(vc *validationConfig) {
+		vc.signatureCache = cache
+	}
+}
+
+// WithTrace enables tracing of the validation checks. After validation, the
+// passed trace is populated with a breakdown of the checks performed on the
+// invocation and each of its proofs, regardless of whether validation
+// succeeded or failed.
+func WithTrace(trace *Trace) Option {
+	return func(vc *validationConfig) {
+		vc.trace = trace
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	rdm "github.com/alanshaw/ucantone/validator/revocation/datamodel"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func main() {
+	if err := cbg.WriteMapEncodersToFile("../cbor_gen.go", "datamodel",
+		rdm.RevokeArgumentsModel{},
+		rdm.RevokeOKModel{},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,19 @@
+package datamodel
+
+import "github.com/alanshaw/ucantone/ucan"
+
+// RevokeArgumentsModel are the arguments for a `/ucan/revoke` invocation.
+type RevokeArgumentsModel struct {
+	// The CID of the delegation to revoke.
+	UCAN ucan.Link `cborgen:"ucan"`
+	// The path of authority from the revoker to the revoked delegation. It
+	// starts with a delegation issued by the revoker and ends with the revoked
+	// delegation.
+	Path []ucan.Link `cborgen:"path"`
+}
+
+// RevokeOKModel is the successful result of a `/ucan/revoke` invocation.
+type RevokeOKModel struct {
+	// The time the revocation was recorded.
+	Time ucan.UTCUnixTimestamp `cborgen:"time"`
+}
@@ -0,0 +1,27 @@
+package revocation
+
+import (
+	"fmt"
+
+	"github.com/alanshaw/ucantone/did"
+	edm "github.com/alanshaw/ucantone/errors/datamodel"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+const RevokedErrorName = "Revoked"
+
+func NewRevokedError(dlg ucan.Delegation, revoker did.DID) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: RevokedErrorName,
+		Message:   fmt.Sprintf("delegation %q has been revoked by %q", dlg.Link(), revoker),
+	}
+}
+
+const UnauthorizedRevocationErrorName = "UnauthorizedRevocation"
+
+func NewUnauthorizedRevocationError(revoker ucan.Principal, dlg ucan.Link, reason string) edm.ErrorModel {
+	return edm.ErrorModel{
+		ErrorName: UnauthorizedRevocationErrorName,
+		Message:   fmt.Sprintf("%q cannot revoke delegation %q: %s", revoker.DID(), dlg, reason),
+	}
+}
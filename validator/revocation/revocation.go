@@ -0,0 +1,149 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/execution/bindexec"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/validator"
+	"github.com/alanshaw/ucantone/validator/bindcap"
+	verrs "github.com/alanshaw/ucantone/validator/errors"
+	rdm "github.com/alanshaw/ucantone/validator/revocation/datamodel"
+)
+
+// Command is the command used to revoke a delegation.
+//
+// https://github.com/ucan-wg/revocation/blob/main/README.md
+const Command = ucan.Command("/ucan/revoke")
+
+// Revoke is the capability for revoking a delegation. The revoker is the
+// issuer of the invocation, which is typically also the subject.
+var Revoke = bindcap.MustNew[*rdm.RevokeArgumentsModel](Command)
+
+// NewAuthorizationValidator creates a [validator.ValidateAuthorizationFunc]
+// that rejects an authorization if any of its proofs have been revoked by the
+// issuer of that proof or by the issuer of any proof that precedes it in the
+// proof chain.
+func NewAuthorizationValidator(store RevocationStore) validator.ValidateAuthorizationFunc {
+	return func(ctx context.Context, auth validator.Authorization) error {
+		issuers := map[did.DID]struct{}{}
+		for _, link := range auth.Invocation.Proofs() {
+			prf, ok := auth.Proofs[link]
+			if !ok {
+				return verrs.NewUnavailableProofError(link, errors.New("missing from authorization"))
+			}
+			issuers[prf.Issuer().DID()] = struct{}{}
+
+			revokers, err := store.Revokers(ctx, link)
+			if err != nil {
+				return fmt.Errorf("getting revokers for %q: %w", link, err)
+			}
+			for _, r := range revokers {
+				if _, ok := issuers[r]; ok {
+					return NewRevokedError(prf, r)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// NewHandler creates an [execution.HandlerFunc] for the [Revoke] capability
+// that records revocations in the passed store.
+//
+// The delegations in the revocation path must be included in the invocation
+// metadata. The path is verified by [VerifyPath].
+func NewHandler(store RevocationStore) execution.HandlerFunc {
+	return bindexec.NewHandler(func(req *bindexec.Request[*rdm.RevokeArgumentsModel], res *bindexec.Response[*rdm.RevokeOKModel]) error {
+		args := req.Task().BindArguments()
+		revoker := req.Invocation().Issuer()
+
+		err := VerifyPath(revoker, args.UCAN, args.Path, req.Metadata())
+		if err != nil {
+			return res.SetFailure(err)
+		}
+
+		err = store.Add(req.Context(), args.UCAN, revoker.DID())
+		if err != nil {
+			return fmt.Errorf("adding revocation: %w", err)
+		}
+
+		return res.SetSuccess(&rdm.RevokeOKModel{Time: ucan.Now()})
+	})
+}
+
+// VerifyPath verifies that the passed path is a chain of delegations that
+// authorizes the revoker to revoke the revoked delegation. The delegations are
+// resolved from the passed container.
+//
+// The path must end with the revoked delegation. Each delegation must be signed
+// by its issuer, and each subsequent delegation must be issued by the audience
+// of the previous and must not widen its command or change its subject. The
+// revoker must be the issuer of a delegation in the path. Unless the revoker
+// issued the revoked delegation, the path must start with a root delegation,
+// issued by its subject, so that the authority of the revoker cannot be forged.
+//
+// Signatures are verified for did:key issuers, see [validator.ParsePrincipal].
+func VerifyPath(revoker ucan.Principal, revoked ucan.Link, path []ucan.Link, meta ucan.Container) error {
+	if len(path) == 0 {
+		return NewUnauthorizedRevocationError(revoker, revoked, "empty revocation path")
+	}
+	if path[len(path)-1] != revoked {
+		return NewUnauthorizedRevocationError(revoker, revoked, "revocation path does not end with revoked delegation")
+	}
+
+	dlgs := make([]ucan.Delegation, 0, len(path))
+	for i, link := range path {
+		var dlg ucan.Delegation
+		if meta != nil {
+			dlg, _ = meta.Delegation(link)
+		}
+		if dlg == nil {
+			return verrs.NewUnavailableProofError(link, errors.New("missing from invocation metadata"))
+		}
+		if err := verifySignature(dlg); err != nil {
+			return err
+		}
+		if i > 0 {
+			prev := dlgs[i-1]
+			if dlg.Issuer().DID() != prev.Audience().DID() {
+				return NewUnauthorizedRevocationError(revoker, revoked, fmt.Sprintf("delegation %q is not issued by the audience of %q", link, prev.Link()))
+			}
+			if !prev.Command().Proves(dlg.Command()) {
+				return NewUnauthorizedRevocationError(revoker, revoked, fmt.Sprintf("delegation %q command %q is not proved by %q", link, dlg.Command(), prev.Link()))
+			}
+			if dlg.Subject() != nil && (prev.Subject() == nil || dlg.Subject().DID() != prev.Subject().DID()) {
+				return NewUnauthorizedRevocationError(revoker, revoked, fmt.Sprintf("delegation %q subject does not match %q", link, prev.Link()))
+			}
+		}
+		dlgs = append(dlgs, dlg)
+	}
+
+	// the issuer of a delegation may always revoke it
+	if dlgs[len(dlgs)-1].Issuer().DID() == revoker.DID() {
+		return nil
+	}
+	root := dlgs[0]
+	if root.Subject() == nil || root.Subject().DID() != root.Issuer().DID() {
+		return NewUnauthorizedRevocationError(revoker, revoked, fmt.Sprintf("delegation %q is not issued by its subject", root.Link()))
+	}
+	for _, dlg := range dlgs {
+		if dlg.Issuer().DID() == revoker.DID() {
+			return nil
+		}
+	}
+	return NewUnauthorizedRevocationError(revoker, revoked, "revoker is not an issuer in the revocation path")
+}
+
+// verifySignature verifies the delegation was signed by its issuer.
+func verifySignature(dlg ucan.Delegation) error {
+	vfr, err := validator.ParsePrincipal(dlg.Issuer().DID().String())
+	if err != nil {
+		return verrs.NewUnverifiableSignatureError(dlg, err)
+	}
+	return validator.VerifyDelegationSignature(dlg, vfr)
+}
@@ -0,0 +1,276 @@
+package revocation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/execution"
+	"github.com/alanshaw/ucantone/execution/dispatcher"
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/result"
+	"github.com/alanshaw/ucantone/testutil"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/invocation"
+	"github.com/alanshaw/ucantone/validator"
+	"github.com/alanshaw/ucantone/validator/capability"
+	verrs "github.com/alanshaw/ucantone/validator/errors"
+	"github.com/alanshaw/ucantone/validator/revocation"
+	rdm "github.com/alanshaw/ucantone/validator/revocation/datamodel"
+	"github.com/stretchr/testify/require"
+)
+
+type NamedError interface {
+	error
+	Name() string
+}
+
+// impostor is a signer that claims the identity of another principal.
+type impostor struct {
+	ucan.Signer
+	id did.DID
+}
+
+func (i impostor) DID() did.DID {
+	return i.id
+}
+
+func TestAuthorizationValidator(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	mallory := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	// space -> alice -> bob
+	aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+	require.NoError(t, err)
+	bobDlg, err := BlobAdd.Delegate(alice, bob, space)
+	require.NoError(t, err)
+
+	inv, err := BlobAdd.Invoke(
+		bob,
+		space,
+		datamodel.Map{"digest": []byte(testutil.RandomDigest(t))},
+		invocation.WithAudience(service),
+		invocation.WithProofs(aliceDlg.Link(), bobDlg.Link()),
+	)
+	require.NoError(t, err)
+
+	access := func(t *testing.T, store revocation.RevocationStore) error {
+		_, err := validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg, bobDlg),
+			validator.WithAuthorizationValidator(revocation.NewAuthorizationValidator(store)),
+		)
+		return err
+	}
+
+	t.Run("not revoked", func(t *testing.T) {
+		require.NoError(t, access(t, revocation.NewMemoryStore()))
+	})
+
+	t.Run("revoked by issuer", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		require.NoError(t, store.Add(t.Context(), bobDlg.Link(), alice.DID()))
+
+		err := access(t, store)
+		require.Error(t, err)
+
+		var namedErr NamedError
+		require.True(t, errors.As(err, &namedErr))
+		require.Equal(t, revocation.RevokedErrorName, namedErr.Name())
+	})
+
+	t.Run("revoked by issuer earlier in chain", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		require.NoError(t, store.Add(t.Context(), bobDlg.Link(), space.DID()))
+
+		err := access(t, store)
+		require.Error(t, err)
+
+		var namedErr NamedError
+		require.True(t, errors.As(err, &namedErr))
+		require.Equal(t, revocation.RevokedErrorName, namedErr.Name())
+	})
+
+	t.Run("revoked by issuer later in chain", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		// alice may not revoke the delegation she was given by the space
+		require.NoError(t, store.Add(t.Context(), aliceDlg.Link(), alice.DID()))
+		require.NoError(t, access(t, store))
+	})
+
+	t.Run("revoked by principal not in chain", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		require.NoError(t, store.Add(t.Context(), bobDlg.Link(), mallory.DID()))
+		require.NoError(t, access(t, store))
+	})
+}
+
+func TestHandler(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	mallory := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	// space -> alice -> bob
+	aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+	require.NoError(t, err)
+	bobDlg, err := BlobAdd.Delegate(alice, bob, space)
+	require.NoError(t, err)
+
+	revoke := func(t *testing.T, store revocation.RevocationStore, revoker ucan.Signer, path ...ucan.Delegation) ipld.Any {
+		executor := dispatcher.New(service)
+		executor.Handle(revocation.Revoke, revocation.NewHandler(store))
+
+		var links []ucan.Link
+		for _, p := range path {
+			links = append(links, p.Link())
+		}
+		inv, err := revocation.Revoke.Invoke(
+			revoker,
+			revoker,
+			&rdm.RevokeArgumentsModel{UCAN: bobDlg.Link(), Path: links},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		resp, err := executor.Execute(execution.NewRequest(t.Context(), inv, execution.WithDelegations(path...)))
+		require.NoError(t, err)
+
+		o, x := result.Unwrap(resp.Receipt().Out())
+		if x != nil {
+			return x.(ipld.Map)["name"]
+		}
+		require.NotNil(t, o)
+		return nil
+	}
+
+	t.Run("revoke as issuer", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		require.Nil(t, revoke(t, store, alice, bobDlg))
+
+		revokers, err := store.Revokers(t.Context(), bobDlg.Link())
+		require.NoError(t, err)
+		require.Len(t, revokers, 1)
+		require.Equal(t, alice.DID(), revokers[0])
+	})
+
+	t.Run("revoke as issuer earlier in chain", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		require.Nil(t, revoke(t, store, space, aliceDlg, bobDlg))
+
+		revokers, err := store.Revokers(t.Context(), bobDlg.Link())
+		require.NoError(t, err)
+		require.Len(t, revokers, 1)
+		require.Equal(t, space.DID(), revokers[0])
+	})
+
+	t.Run("unauthorized revoker", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		name := revoke(t, store, mallory, bobDlg)
+		require.Equal(t, revocation.UnauthorizedRevocationErrorName, name)
+
+		revokers, err := store.Revokers(t.Context(), bobDlg.Link())
+		require.NoError(t, err)
+		require.Empty(t, revokers)
+	})
+
+	t.Run("misaligned path", func(t *testing.T) {
+		store := revocation.NewMemoryStore()
+		name := revoke(t, store, space, bobDlg, bobDlg)
+		require.Equal(t, revocation.UnauthorizedRevocationErrorName, name)
+	})
+
+	t.Run("forged path", func(t *testing.T) {
+		// mallory self-issues a delegation to alice to appear in the chain
+		forged, err := BlobAdd.Delegate(mallory, alice, space)
+		require.NoError(t, err)
+
+		store := revocation.NewMemoryStore()
+		name := revoke(t, store, mallory, forged, bobDlg)
+		require.Equal(t, revocation.UnauthorizedRevocationErrorName, name)
+
+		revokers, err := store.Revokers(t.Context(), bobDlg.Link())
+		require.NoError(t, err)
+		require.Empty(t, revokers)
+	})
+
+	t.Run("widened command in path", func(t *testing.T) {
+		narrow, err := delegation.Delegate(space, alice, space, "/blob/add/shard")
+		require.NoError(t, err)
+
+		name := revoke(t, revocation.NewMemoryStore(), space, narrow, bobDlg)
+		require.Equal(t, revocation.UnauthorizedRevocationErrorName, name)
+	})
+
+	t.Run("invalid signature in path", func(t *testing.T) {
+		// signed by mallory, but claims to be issued by the space
+		forged, err := BlobAdd.Delegate(impostor{mallory, space.DID()}, alice, space)
+		require.NoError(t, err)
+
+		name := revoke(t, revocation.NewMemoryStore(), space, forged, bobDlg)
+		require.Equal(t, verrs.InvalidSignatureErrorName, name)
+	})
+
+	t.Run("missing path delegation", func(t *testing.T) {
+		executor := dispatcher.New(service)
+		executor.Handle(revocation.Revoke, revocation.NewHandler(revocation.NewMemoryStore()))
+
+		inv, err := revocation.Revoke.Invoke(
+			alice,
+			alice,
+			&rdm.RevokeArgumentsModel{UCAN: bobDlg.Link(), Path: []ucan.Link{bobDlg.Link()}},
+			invocation.WithAudience(service),
+		)
+		require.NoError(t, err)
+
+		resp, err := executor.Execute(execution.NewRequest(t.Context(), inv))
+		require.NoError(t, err)
+
+		_, x := result.Unwrap(resp.Receipt().Out())
+		require.NotNil(t, x)
+		require.Equal(t, verrs.UnavailableProofErrorName, x.(ipld.Map)["name"])
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	dlg := testutil.RandomCID(t)
+	alice := testutil.RandomDID(t)
+	bob := testutil.RandomDID(t)
+
+	store, err := revocation.NewFileStore(dir)
+	require.NoError(t, err)
+
+	revokers, err := store.Revokers(t.Context(), dlg)
+	require.NoError(t, err)
+	require.Empty(t, revokers)
+
+	require.NoError(t, store.Add(t.Context(), dlg, alice))
+	require.NoError(t, store.Add(t.Context(), dlg, bob))
+	require.NoError(t, store.Add(t.Context(), dlg, alice)) // duplicate
+
+	// revocations persist across instances
+	store, err = revocation.NewFileStore(dir)
+	require.NoError(t, err)
+
+	revokers, err = store.Revokers(t.Context(), dlg)
+	require.NoError(t, err)
+	require.Len(t, revokers, 2)
+	require.Equal(t, alice, revokers[0])
+	require.Equal(t, bob, revokers[1])
+}
@@ -0,0 +1,134 @@
+package revocation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// RevocationStore records which principals have revoked which delegations.
+type RevocationStore interface {
+	// Add records that the delegation identified by the passed link has been
+	// revoked by the passed principal.
+	Add(ctx context.Context, delegation ucan.Link, revoker did.DID) error
+	// Revokers returns the principals that have revoked the delegation
+	// identified by the passed link. It returns an empty slice if the delegation
+	// has not been revoked.
+	Revokers(ctx context.Context, delegation ucan.Link) ([]did.DID, error)
+}
+
+// MemoryStore is a [RevocationStore] that keeps revocations in memory.
+type MemoryStore struct {
+	mutex sync.RWMutex
+	data  map[ucan.Link][]did.DID
+}
+
+// NewMemoryStore creates a new, empty, in-memory revocation store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[ucan.Link][]did.DID{}}
+}
+
+func (ms *MemoryStore) Add(ctx context.Context, delegation ucan.Link, revoker did.DID) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if slices.Contains(ms.data[delegation], revoker) {
+		return nil
+	}
+	ms.data[delegation] = append(ms.data[delegation], revoker)
+	return nil
+}
+
+func (ms *MemoryStore) Revokers(ctx context.Context, delegation ucan.Link) ([]did.DID, error) {
+	ms.mutex.RLock()
+	defer ms.mutex.RUnlock()
+	revokers, ok := ms.data[delegation]
+	if !ok {
+		return []did.DID{}, nil
+	}
+	return slices.Clone(revokers), nil
+}
+
+var _ RevocationStore = (*MemoryStore)(nil)
+
+// FileStore is a [RevocationStore] that persists revocations to a directory on
+// disk. Each revoked delegation has a file named by its CID, which contains the
+// DIDs of the revokers, one per line.
+type FileStore struct {
+	mutex sync.RWMutex
+	dir   string
+}
+
+// NewFileStore creates a revocation store that persists revocations in the
+// passed directory. The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating revocation store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (fs *FileStore) Add(ctx context.Context, delegation ucan.Link, revoker did.DID) error {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	revokers, err := fs.read(delegation)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(revokers, revoker) {
+		return nil
+	}
+	f, err := os.OpenFile(fs.path(delegation), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening revocation file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(revoker.String() + "\n"); err != nil {
+		return fmt.Errorf("writing revocation file: %w", err)
+	}
+	return f.Sync()
+}
+
+func (fs *FileStore) Revokers(ctx context.Context, delegation ucan.Link) ([]did.DID, error) {
+	fs.mutex.RLock()
+	defer fs.mutex.RUnlock()
+	return fs.read(delegation)
+}
+
+func (fs *FileStore) path(delegation ucan.Link) string {
+	return filepath.Join(fs.dir, delegation.String())
+}
+
+func (fs *FileStore) read(delegation ucan.Link) ([]did.DID, error) {
+	b, err := os.ReadFile(fs.path(delegation))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []did.DID{}, nil
+		}
+		return nil, fmt.Errorf("reading revocation file: %w", err)
+	}
+	revokers := []did.DID{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		revoker, err := did.Parse(line)
+		if err != nil {
+			return nil, fmt.Errorf("parsing revoker DID: %w", err)
+		}
+		revokers = append(revokers, revoker)
+	}
+	return revokers, scanner.Err()
+}
+
+var _ RevocationStore = (*FileStore)(nil)
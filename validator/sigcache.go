@@ -0,0 +1,111 @@
+package validator
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/principal/verifier"
+	"github.com/alanshaw/ucantone/ucan"
+)
+
+// SignatureCache records tokens whose signatures have been successfully
+// verified, so that the (relatively expensive) signature verification can be
+// skipped when the same token is seen again.
+//
+// Entries are keyed by token CID and the DID of the key that verified the
+// signature. Since the CID covers the signature bytes, a cache hit means the
+// exact same token was previously verified by the same key.
+type SignatureCache interface {
+	// Has returns true if the signature of the token identified by the passed
+	// link was previously verified by the passed key.
+	Has(token ucan.Link, key did.DID) bool
+	// Add records that the signature of the token identified by the passed link
+	// was verified by the passed key.
+	Add(token ucan.Link, key did.DID)
+}
+
+type sigCacheKey struct {
+	token ucan.Link
+	key   did.DID
+}
+
+// LRUSignatureCache is a size bounded [SignatureCache] that evicts the least
+// recently used entries when full. It is safe for concurrent use.
+type LRUSignatureCache struct {
+	mutex   sync.Mutex
+	size    int
+	order   *list.List
+	entries map[sigCacheKey]*list.Element
+}
+
+// NewSignatureCache creates a new [LRUSignatureCache] that holds at most size
+// entries.
+func NewSignatureCache(size int) *LRUSignatureCache {
+	if size < 1 {
+		size = 1
+	}
+	return &LRUSignatureCache{
+		size:    size,
+		order:   list.New(),
+		entries: map[sigCacheKey]*list.Element{},
+	}
+}
+
+func (c *LRUSignatureCache) Has(token ucan.Link, key did.DID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	el, ok := c.entries[sigCacheKey{token, key}]
+	if !ok {
+		return false
+	}
+	c.order.MoveToFront(el)
+	return true
+}
+
+func (c *LRUSignatureCache) Add(token ucan.Link, key did.DID) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	k := sigCacheKey{token, key}
+	if el, ok := c.entries[k]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[k] = c.order.PushFront(k)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(sigCacheKey))
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUSignatureCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+var _ SignatureCache = (*LRUSignatureCache)(nil)
+
+// verifyDelegationSignature verifies the delegation signature, consulting the
+// cache (if not nil) first and recording successful verifications in it.
+func verifyDelegationSignature(cache SignatureCache, dlg ucan.Delegation, vfr ucan.Verifier) error {
+	if cache == nil {
+		return VerifyDelegationSignature(dlg, vfr)
+	}
+	// key by the underlying did:key so that signatures from rotated keys of a
+	// non did:key principal are not considered verified
+	key := vfr.DID()
+	if uw, ok := vfr.(verifier.Unwrapper); ok {
+		key = uw.Unwrap().DID()
+	}
+	if cache.Has(dlg.Link(), key) {
+		return nil
+	}
+	if err := VerifyDelegationSignature(dlg, vfr); err != nil {
+		return err
+	}
+	cache.Add(dlg.Link(), key)
+	return nil
+}
@@ -0,0 +1,441 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/alanshaw/ucantone/ipld"
+	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ipld/schema"
+	"github.com/alanshaw/ucantone/ucan"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy/selector"
+)
+
+// CheckStatus is the outcome of a single check performed by the validator.
+type CheckStatus string
+
+const (
+	// CheckOK indicates the check passed.
+	CheckOK CheckStatus = "ok"
+	// CheckFailed indicates the check failed.
+	CheckFailed CheckStatus = "failed"
+	// CheckSkipped indicates the check was not performed, because validation
+	// stopped at an earlier failure.
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// CheckResult is the result of a single check performed by the validator.
+type CheckResult struct {
+	Status CheckStatus
+	// Error is the reason the check failed. It is nil unless the status is
+	// [CheckFailed].
+	Error error
+}
+
+// StatementResult is the result of matching a single policy statement against
+// the invocation arguments.
+type StatementResult struct {
+	Statement ucan.Statement
+	// Selector is the path of the value the statement was matched against,
+	// relative to the invocation arguments. For statements nested in an "all"
+	// or "any" statement it includes the index or key of the element e.g.
+	// ".items[1].size".
+	Selector string
+	Result   CheckResult
+	// Statements are the results of the nested statements that caused an "and",
+	// "or", "not", "all" or "any" statement to fail. It is empty if the
+	// statement matched.
+	Statements []StatementResult
+}
+
+// ProofTrace is the breakdown of the checks performed on a single proof.
+type ProofTrace struct {
+	// Link is the CID of the proof.
+	Link ucan.Link
+	// Delegation is the resolved proof. It is nil if the proof could not be
+	// resolved.
+	Delegation ucan.Delegation
+	// Resolution is the result of resolving the proof.
+	Resolution CheckResult
+	Signature  CheckResult
+	TimeBounds CheckResult
+	// Alignment is the result of checking the subject, principal and command
+	// alignment of the proof with the proof before it in the chain (or the root
+	// checks, if the proof is the first in the chain).
+	Alignment CheckResult
+	// Policy are the results of matching each statement of the proof policy
+	// against the invocation arguments. It is empty if the capability was not
+	// matched.
+	Policy []StatementResult
+}
+
+// Trace is a report of the checks performed by the validator when validating
+// an invocation. The results are recorded as the checks are performed, so the
+// trace reflects exactly what the validator decided. Validation stops at the
+// first failure, checks after it are reported as [CheckSkipped].
+//
+// A trace can be obtained by passing [WithTrace] to [Access].
+type Trace struct {
+	// Invocation is the CID of the validated invocation.
+	Invocation ucan.Link
+	Signature  CheckResult
+	TimeBounds CheckResult
+	// Alignment is the result of checking the invocation issuer and command are
+	// aligned with the final proof (or that the invocation may be self issued,
+	// if there are no proofs).
+	Alignment CheckResult
+	// Match is the result of matching the invocation against the capability,
+	// including its argument schema and the policies of the proofs.
+	Match CheckResult
+	// Policy are the results of matching each statement of the capability
+	// policy against the invocation arguments. It is empty if the capability
+	// was not matched.
+	Policy []StatementResult
+	// Authorization is the result of the authorization validator, typically a
+	// revocation check, see [WithAuthorizationValidator].
+	Authorization CheckResult
+	// Proofs are the traces of each proof in the order they appear in the
+	// invocation.
+	Proofs []ProofTrace
+	// Error is the error returned by the validator, or nil if the invocation was
+	// authorized.
+	Error error
+}
+
+// ToIPLD converts the trace to an IPLD map, suitable for embedding in a
+// receipt.
+func (t Trace) ToIPLD() ipld.Map {
+	proofs := make([]ipld.Any, 0, len(t.Proofs))
+	for _, p := range t.Proofs {
+		pm := ipld.Map{
+			"link":       p.Link,
+			"resolution": checkResultToIPLD(p.Resolution),
+			"signature":  checkResultToIPLD(p.Signature),
+			"timeBounds": checkResultToIPLD(p.TimeBounds),
+			"alignment":  checkResultToIPLD(p.Alignment),
+			"policy":     statementResultsToIPLD(p.Policy),
+		}
+		if p.Delegation != nil {
+			pm["issuer"] = p.Delegation.Issuer().DID().String()
+			pm["audience"] = p.Delegation.Audience().DID().String()
+			if p.Delegation.Subject() != nil {
+				pm["subject"] = p.Delegation.Subject().DID().String()
+			} else {
+				pm["subject"] = nil
+			}
+			pm["command"] = p.Delegation.Command().String()
+		}
+		proofs = append(proofs, pm)
+	}
+	m := ipld.Map{
+		"invocation":    t.Invocation,
+		"ok":            t.Error == nil,
+		"signature":     checkResultToIPLD(t.Signature),
+		"timeBounds":    checkResultToIPLD(t.TimeBounds),
+		"alignment":     checkResultToIPLD(t.Alignment),
+		"match":         checkResultToIPLD(t.Match),
+		"policy":        statementResultsToIPLD(t.Policy),
+		"authorization": checkResultToIPLD(t.Authorization),
+		"proofs":        proofs,
+	}
+	if t.Error != nil {
+		m["error"] = errorToIPLD(t.Error)
+	}
+	return m
+}
+
+func (t Trace) MarshalCBOR(w io.Writer) error {
+	return datamodel.Map(t.ToIPLD()).MarshalCBOR(w)
+}
+
+func (t Trace) MarshalDagJSON(w io.Writer) error {
+	return datamodel.Map(t.ToIPLD()).MarshalDagJSON(w)
+}
+
+func checkResultToIPLD(r CheckResult) ipld.Map {
+	m := ipld.Map{"status": string(r.Status)}
+	if r.Error != nil {
+		m["error"] = errorToIPLD(r.Error)
+	}
+	return m
+}
+
+func statementResultsToIPLD(results []StatementResult) []ipld.Any {
+	list := make([]ipld.Any, 0, len(results))
+	for _, r := range results {
+		m := checkResultToIPLD(r.Result)
+		m["operator"] = r.Statement.Operator()
+		m["selector"] = r.Selector
+		if stmt, err := statementToIPLD(r.Statement); err == nil {
+			m["statement"] = stmt
+		}
+		if len(r.Statements) > 0 {
+			m["statements"] = statementResultsToIPLD(r.Statements)
+		}
+		list = append(list, m)
+	}
+	return list
+}
+
+// statementToIPLD converts the statement to its IPLD representation e.g.
+// ["==", ".size", 1].
+func statementToIPLD(stmt ucan.Statement) (ipld.Any, error) {
+	pol, err := policy.New(stmt)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := pol.Statements()[0].(policy.Statement).MarshalCBOR(&buf); err != nil {
+		return nil, err
+	}
+	var value datamodel.Any
+	if err := value.UnmarshalCBOR(&buf); err != nil {
+		return nil, err
+	}
+	return value.Value, nil
+}
+
+func errorToIPLD(err error) ipld.Map {
+	m := ipld.Map{"message": err.Error()}
+	var named interface{ Name() string }
+	if errors.As(err, &named) {
+		m["name"] = named.Name()
+	}
+	return m
+}
+
+func newCheckResult(err error) CheckResult {
+	if err != nil {
+		return CheckResult{Status: CheckFailed, Error: err}
+	}
+	return CheckResult{Status: CheckOK}
+}
+
+var skipped = CheckResult{Status: CheckSkipped}
+
+func matchStatements(pol ucan.Policy, args ipld.Map) []StatementResult {
+	var results []StatementResult
+	for _, stmt := range pol.Statements() {
+		results = append(results, matchStatement(stmt, args, ""))
+	}
+	return results
+}
+
+// matchStatement matches the statement against the value found at path in the
+// invocation arguments. If the statement fails, the nested statements that
+// caused the failure are also matched and recorded.
+func matchStatement(stmt ucan.Statement, value any, path string) StatementResult {
+	ok, err := policy.MatchStatement(stmt, value)
+	if ok {
+		err = nil
+	} else if err == nil {
+		err = fmt.Errorf("statement %q did not match", stmt.Operator())
+	}
+	result := StatementResult{
+		Statement: stmt,
+		Selector:  joinSelector(path, stmt.Selector()),
+		Result:    newCheckResult(err),
+	}
+	if ok {
+		return result
+	}
+	switch stmt.Operator() {
+	case policy.OpNot:
+		// the nested statement matched, which is why the statement failed
+		for _, nested := range nestedStatements(stmt) {
+			result.Statements = append(result.Statements, matchStatement(nested, value, path))
+		}
+	case policy.OpAnd, policy.OpOr:
+		for _, nested := range nestedStatements(stmt) {
+			if r := matchStatement(nested, value, path); r.Result.Status == CheckFailed {
+				result.Statements = append(result.Statements, r)
+			}
+		}
+	case policy.OpAll, policy.OpAny:
+		sel, err := selector.Parse(stmt.Selector())
+		if err != nil {
+			return result
+		}
+		selected, err := selector.Select(sel, value)
+		if err != nil {
+			return result
+		}
+		for _, nested := range nestedStatements(stmt) {
+			rv := reflect.ValueOf(selected)
+			switch rv.Kind() {
+			case reflect.Slice:
+				for i := range rv.Len() {
+					r := matchStatement(nested, rv.Index(i).Interface(), schema.IndexPath(result.Selector, i))
+					if r.Result.Status == CheckFailed {
+						result.Statements = append(result.Statements, r)
+					}
+				}
+			case reflect.Map:
+				keys := rv.MapKeys()
+				slices.SortFunc(keys, func(a, b reflect.Value) int {
+					return strings.Compare(a.String(), b.String())
+				})
+				for _, k := range keys {
+					r := matchStatement(nested, rv.MapIndex(k).Interface(), schema.FieldPath(result.Selector, k.String()))
+					if r.Result.Status == CheckFailed {
+						result.Statements = append(result.Statements, r)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// nestedStatements returns the statements nested in an "and", "or", "not",
+// "all" or "any" statement.
+func nestedStatements(stmt ucan.Statement) []ucan.Statement {
+	switch arg := stmt.Argument().(type) {
+	case ucan.Statement:
+		return []ucan.Statement{arg}
+	case []ucan.Statement:
+		return arg
+	case []*policy.Statement:
+		stmts := make([]ucan.Statement, 0, len(arg))
+		for _, s := range arg {
+			stmts = append(stmts, s)
+		}
+		return stmts
+	}
+	return nil
+}
+
+// joinSelector appends the selector of a nested statement to the path of the
+// value it is matched against.
+func joinSelector(path, sel string) string {
+	if path == "" {
+		return sel
+	}
+	if sel == "" || sel == "." {
+		return path
+	}
+	if strings.HasPrefix(sel, ".[") {
+		sel = sel[1:]
+	}
+	return path + sel
+}
+
+// newTrace creates a trace for the invocation with every check skipped. The
+// checks are recorded as they are performed by the validator.
+func newTrace(inv ucan.Invocation) Trace {
+	t := Trace{
+		Invocation:    inv.Link(),
+		Signature:     skipped,
+		TimeBounds:    skipped,
+		Alignment:     skipped,
+		Match:         skipped,
+		Authorization: skipped,
+	}
+	for _, link := range inv.Proofs() {
+		t.Proofs = append(t.Proofs, ProofTrace{
+			Link:       link,
+			Resolution: skipped,
+			Signature:  skipped,
+			TimeBounds: skipped,
+			Alignment:  skipped,
+		})
+	}
+	return t
+}
+
+// The following methods record the result of a check and return the passed
+// error. They do nothing if the trace is nil, i.e. tracing is not enabled.
+
+func (t *Trace) signature(err error) error {
+	if t != nil {
+		t.Signature = newCheckResult(err)
+	}
+	return err
+}
+
+func (t *Trace) timeBounds(err error) error {
+	if t != nil {
+		t.TimeBounds = newCheckResult(err)
+	}
+	return err
+}
+
+func (t *Trace) alignment(err error) error {
+	if t != nil {
+		t.Alignment = newCheckResult(err)
+	}
+	return err
+}
+
+// match records the result of matching the capability, and the results of the
+// individual policy statements of the capability and the resolved proofs.
+func (t *Trace) match(capability Capability, inv ucan.Invocation, err error) error {
+	if t != nil {
+		t.Match = newCheckResult(err)
+		t.Policy = matchStatements(capability.Policy(), inv.Arguments())
+		for i := range t.Proofs {
+			if dlg := t.Proofs[i].Delegation; dlg != nil {
+				t.Proofs[i].Policy = matchStatements(dlg.Policy(), inv.Arguments())
+			}
+		}
+	}
+	return err
+}
+
+func (t *Trace) authorization(err error) error {
+	if t != nil {
+		t.Authorization = newCheckResult(err)
+	}
+	return err
+}
+
+// proofResolution records the result of resolving the proof with the passed
+// link.
+func (t *Trace) proofResolution(link ucan.Link, prf ucan.Delegation, err error) error {
+	if t != nil {
+		for i := range t.Proofs {
+			if t.Proofs[i].Link == link {
+				t.Proofs[i].Delegation = prf
+				t.Proofs[i].Resolution = newCheckResult(err)
+			}
+		}
+	}
+	return err
+}
+
+// proofTimeBounds records the result of checking the time bounds of the proof
+// with the passed link.
+func (t *Trace) proofTimeBounds(link ucan.Link, err error) error {
+	if t != nil {
+		for i := range t.Proofs {
+			if t.Proofs[i].Link == link {
+				t.Proofs[i].TimeBounds = newCheckResult(err)
+			}
+		}
+	}
+	return err
+}
+
+// proofSignature records the result of verifying the signature of the proof
+// at index i in the invocation proofs.
+func (t *Trace) proofSignature(i int, err error) error {
+	if t != nil && i < len(t.Proofs) {
+		t.Proofs[i].Signature = newCheckResult(err)
+	}
+	return err
+}
+
+// proofAlignment records the result of checking the alignment of the proof at
+// index i in the invocation proofs.
+func (t *Trace) proofAlignment(i int, err error) error {
+	if t != nil && i < len(t.Proofs) {
+		t.Proofs[i].Alignment = newCheckResult(err)
+	}
+	return err
+}
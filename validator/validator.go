@@ -135,34 +135,62 @@ func Access(
 		opt(&cfg)
 	}
 
+	if cfg.trace != nil {
+		*cfg.trace = newTrace(invocation)
+	}
+	auth, err := access(ctx, authority, capability, invocation, cfg)
+	if cfg.trace != nil {
+		cfg.trace.Error = err
+	}
+	return auth, err
+}
+
+func access(
+	ctx context.Context,
+	authority ucan.Verifier,
+	capability Capability,
+	invocation ucan.Invocation,
+	cfg validationConfig,
+) (Authorization, error) {
 	proofs := map[cid.Cid]ucan.Delegation{}
 	for _, p := range cfg.proofs {
 		proofs[p.Link()] = p
 	}
 
-	proofs, err := ResolveProofs(ctx, proofs, cfg.resolveProof, invocation.Proofs())
+	proofs, err := resolveProofs(ctx, proofs, cfg.resolveProof, invocation.Proofs(), cfg.trace)
 	if err != nil {
 		return Authorization{}, err
 	}
 
-	err = Validate(ctx, authority, cfg.canIssue, cfg.parsePrincipal, cfg.resolveDIDKey, cfg.verifyNonStandardSignature, cfg.validationTime, invocation, proofs, cfg.metadata)
+	err = validate(ctx, authority, invocation, proofs, cfg)
 	if err != nil {
 		return Authorization{}, err
 	}
 
 	match, err := capability.Match(invocation, proofs)
-	if err != nil {
+	if cfg.trace.match(capability, invocation, err) != nil {
 		return Authorization{}, err
 	}
 
-	return Authorization{
+	auth := Authorization{
 		Invocation: invocation,
 		Task:       match.Task,
 		Proofs:     match.Proofs,
-	}, nil
+	}
+
+	err = cfg.validateAuthorization(ctx, auth)
+	if cfg.trace.authorization(err) != nil {
+		return Authorization{}, err
+	}
+
+	return auth, nil
 }
 
 func ResolveProofs(ctx context.Context, providedProofs map[cid.Cid]ucan.Delegation, resolve ProofResolverFunc, links []ucan.Link) (map[cid.Cid]ucan.Delegation, error) {
+	return resolveProofs(ctx, providedProofs, resolve, links, nil)
+}
+
+func resolveProofs(ctx context.Context, providedProofs map[cid.Cid]ucan.Delegation, resolve ProofResolverFunc, links []ucan.Link, t *Trace) (map[cid.Cid]ucan.Delegation, error) {
 	proofs := map[cid.Cid]ucan.Delegation{}
 	for _, link := range links {
 		prf, ok := providedProofs[link]
@@ -170,9 +198,10 @@ func ResolveProofs(ctx context.Context, providedProofs map[cid.Cid]ucan.Delegati
 			var err error
 			prf, err = resolve(ctx, link)
 			if err != nil {
-				return nil, verrs.NewUnavailableProofError(link, err)
+				return nil, t.proofResolution(link, nil, verrs.NewUnavailableProofError(link, err))
 			}
 		}
+		t.proofResolution(link, prf, nil)
 		proofs[link] = prf
 	}
 	return proofs, nil
@@ -192,23 +221,40 @@ func Validate(
 	prfs map[cid.Cid]ucan.Delegation,
 	meta ucan.Container,
 ) error {
-	err := ValidateNotExpired(inv, now)
+	cfg := validationConfig{
+		canIssue:                   canIssue,
+		parsePrincipal:             parsePrincipal,
+		resolveDIDKey:              resolveDIDKey,
+		verifyNonStandardSignature: verifyNonStandardSignature,
+		validationTime:             now,
+		metadata:                   meta,
+	}
+	return validate(ctx, authority, inv, prfs, cfg)
+}
+
+func validate(
+	ctx context.Context,
+	authority ucan.Verifier,
+	inv ucan.Invocation,
+	prfs map[cid.Cid]ucan.Delegation,
+	cfg validationConfig,
+) error {
+	err := cfg.trace.timeBounds(ValidateNotExpired(inv, cfg.validationTime))
 	if err != nil {
 		return err
 	}
 
-	for _, p := range prfs {
-		err := ValidateNotExpired(p, now)
-		if err != nil {
-			return err
+	for link, p := range prfs {
+		err := ValidateNotExpired(p, cfg.validationTime)
+		if err == nil {
+			err = ValidateNotTooEarly(p, cfg.validationTime)
 		}
-		err = ValidateNotTooEarly(p, now)
-		if err != nil {
+		if cfg.trace.proofTimeBounds(link, err) != nil {
 			return err
 		}
 	}
 
-	return VerifyAuthorization(ctx, authority, canIssue, parsePrincipal, resolveDIDKey, verifyNonStandardSignature, inv, prfs, meta)
+	return verifyAuthorization(ctx, authority, inv, prfs, cfg)
 }
 
 func ValidateNotExpired(token ucan.Token, now ucan.UTCUnixTimestamp) error {
@@ -250,6 +296,128 @@ func VerifyAuthorization(
 	inv ucan.Invocation,
 	prfs map[cid.Cid]ucan.Delegation,
 	meta ucan.Container,
+) error {
+	cfg := validationConfig{
+		canIssue:                   canIssue,
+		parsePrincipal:             parsePrincipal,
+		resolveDIDKey:              resolveDIDKey,
+		verifyNonStandardSignature: verifyNonStandardSignature,
+		metadata:                   meta,
+	}
+	return verifyAuthorization(ctx, authority, inv, prfs, cfg)
+}
+
+// verifyAuthorization is [VerifyAuthorization] using the validation config. If
+// the config has a signature cache, delegations whose signatures have already
+// been verified by the same key are not verified again.
+func verifyAuthorization(
+	ctx context.Context,
+	authority ucan.Verifier,
+	inv ucan.Invocation,
+	prfs map[cid.Cid]ucan.Delegation,
+	cfg validationConfig,
+) error {
+	t := cfg.trace
+	if err := t.signature(verifyInvocationIssuerSignature(ctx, authority, cfg.parsePrincipal, cfg.resolveDIDKey, cfg.verifyNonStandardSignature, inv, cfg.metadata)); err != nil {
+		return err
+	}
+
+	prfChain := inv.Proofs()
+	if len(prfChain) > 0 {
+		prf, ok := prfs[prfChain[len(prfChain)-1]]
+		if !ok {
+			return verrs.NewUnavailableProofError(prfChain[len(prfChain)-1], errors.New("missing from map"))
+		}
+
+		// check principal alignment
+		if inv.Issuer().DID() != prf.Audience().DID() {
+			return t.alignment(verrs.NewPrincipalAlignmentError(inv.Issuer(), prf))
+		}
+
+		// check the final delegation proves the invoked command
+		if !prf.Command().Proves(inv.Command()) {
+			return t.alignment(verrs.NewCommandAlignmentError(inv, prf))
+		}
+		t.alignment(nil)
+
+		for i, p := range prfChain {
+			prf, ok := prfs[p]
+			if !ok {
+				return verrs.NewUnavailableProofError(p, errors.New("missing from map"))
+			}
+			// this is the root delegation
+			if i == 0 {
+				if err := t.proofAlignment(i, verifyRootAlignment(inv, prf, cfg.canIssue)); err != nil {
+					return err
+				}
+			} else {
+				prev := prfs[inv.Proofs()[i-1]]
+				if err := t.proofAlignment(i, verifyProofAlignment(inv, prev, prf)); err != nil {
+					return err
+				}
+			}
+
+			if err := t.proofSignature(i, verifyDelegationIssuerSignature(ctx, authority, cfg.parsePrincipal, cfg.resolveDIDKey, cfg.verifyNonStandardSignature, cfg.signatureCache, prf, cfg.metadata)); err != nil {
+				return err
+			}
+		}
+	} else {
+		// check invocation issuer/subject alignment
+		cap := delegation.NewCapability(inv.Subject(), inv.Command(), policy.Policy{})
+		if !cfg.canIssue(cap, inv.Issuer()) {
+			return t.alignment(verrs.NewInvalidClaimError(fmt.Sprintf("%q cannot issue invocations for %q", inv.Issuer().DID(), inv.Subject().DID())))
+		}
+		t.alignment(nil)
+	}
+
+	return nil
+}
+
+// verifyRootAlignment checks the root delegation of the proof chain is issued
+// by a principal that can issue delegations for the invocation subject.
+func verifyRootAlignment(inv ucan.Invocation, prf ucan.Delegation, canIssue CanIssueFunc) error {
+	// powerline is not allowed as root delegation.
+	// a priori there is no such thing as a null subject.
+	if prf.Subject() == nil {
+		return verrs.NewInvalidClaimError("root delegation subject is null")
+	}
+	if prf.Subject().DID() != inv.Subject().DID() {
+		return verrs.NewSubjectAlignmentError(inv.Subject(), prf)
+	}
+	// check root issuer/subject alignment
+	if !canIssue(ucan.Capability(prf), prf.Issuer()) {
+		return verrs.NewInvalidClaimError(fmt.Sprintf("%q cannot issue delegations for %q", prf.Issuer().DID(), prf.Subject().DID()))
+	}
+	return nil
+}
+
+// verifyProofAlignment checks the subject, principal and command alignment of
+// a (non-root) delegation with the delegation before it in the proof chain.
+func verifyProofAlignment(inv ucan.Invocation, prev ucan.Delegation, prf ucan.Delegation) error {
+	// check subject and principal alignment
+	if prf.Subject() != nil && prf.Subject().DID() != inv.Subject().DID() {
+		return verrs.NewSubjectAlignmentError(inv.Subject(), prf)
+	}
+	if prf.Issuer().DID() != prev.Audience().DID() {
+		return verrs.NewPrincipalAlignmentError(prf.Issuer(), prev)
+	}
+	// check the command is not widened by the delegation
+	if !prev.Command().Proves(prf.Command()) {
+		return verrs.NewCommandAlignmentError(prf, prev)
+	}
+	return nil
+}
+
+// verifyInvocationIssuerSignature verifies the invocation was signed by its
+// issuer, resolving the issuer key as necessary.
+func verifyInvocationIssuerSignature(
+	ctx context.Context,
+	authority ucan.Verifier,
+	parsePrincipal PrincipalParserFunc,
+	resolveDIDKey DIDResolverFunc,
+	verifyNonStandardSignature NonStandardSignatureVerifierFunc,
+	inv ucan.Invocation,
+	meta ucan.Container,
 ) error {
 	issuer := inv.Issuer().DID()
 	// If the issuer is a did:key we just verify a signature
@@ -300,108 +468,70 @@ func VerifyAuthorization(
 			return verrs.NewUnverifiableSignatureError(inv, verifyErr)
 		}
 	}
+	return nil
+}
 
-	prfChain := inv.Proofs()
-	if len(prfChain) > 0 {
-		prf, ok := prfs[prfChain[len(prfChain)-1]]
-		if !ok {
-			return verrs.NewUnavailableProofError(prfChain[len(prfChain)-1], errors.New("missing from map"))
+// verifyDelegationIssuerSignature verifies the delegation was signed by its
+// issuer, resolving the issuer key as necessary.
+func verifyDelegationIssuerSignature(
+	ctx context.Context,
+	authority ucan.Verifier,
+	parsePrincipal PrincipalParserFunc,
+	resolveDIDKey DIDResolverFunc,
+	verifyNonStandardSignature NonStandardSignatureVerifierFunc,
+	signatureCache SignatureCache,
+	prf ucan.Delegation,
+	meta ucan.Container,
+) error {
+	issuer := prf.Issuer().DID()
+	// If the issuer is a did:key we just verify a signature
+	if strings.HasPrefix(issuer.String(), "did:key:") {
+		verifier, err := parsePrincipal(issuer.String())
+		if err != nil {
+			return verrs.NewUnverifiableSignatureError(prf, err)
 		}
-
-		// check principal alignment
-		if inv.Issuer().DID() != prf.Audience().DID() {
-			return verrs.NewPrincipalAlignmentError(inv.Issuer(), prf)
+		if err := verifyDelegationSignature(signatureCache, prf, verifier); err != nil {
+			return err
+		}
+	} else if issuer == authority.DID() {
+		if err := verifyDelegationSignature(signatureCache, prf, authority); err != nil {
+			return err
+		}
+	} else if prf.Signature().Header().SignatureAlgorithm().Code() == nonstandard.Code {
+		if err := verifyNonStandardSignature(ctx, prf, meta); err != nil {
+			return err
+		}
+	} else {
+		// Otherwise we try to resolve did:key from the DID instead
+		// and use that to verify the signature
+		ids, err := resolveDIDKey(ctx, issuer)
+		if err != nil {
+			return err
 		}
 
-		for i, p := range prfChain {
-			prf, ok := prfs[p]
-			if !ok {
-				return verrs.NewUnavailableProofError(p, errors.New("missing from map"))
+		var verifyErr error
+		for _, id := range ids {
+			vfr, err := parsePrincipal(id.String())
+			if err != nil {
+				verifyErr = err
+				continue
 			}
-			issuer := prf.Issuer().DID()
-
-			// this is the root delegation
-			if i == 0 {
-				// powerline is not allowed as root delegation.
-				// a priori there is no such thing as a null subject.
-				if prf.Subject() == nil {
-					return verrs.NewInvalidClaimError("root delegation subject is null")
-				}
-				if prf.Subject().DID() != inv.Subject().DID() {
-					return verrs.NewSubjectAlignmentError(inv.Subject(), prf)
-				}
-				// check root issuer/subject alignment
-				if !canIssue(ucan.Capability(prf), prf.Issuer()) {
-					return verrs.NewInvalidClaimError(fmt.Sprintf("%q cannot issue delegations for %q", issuer, prf.Subject().DID()))
-				}
-			} else {
-				// otherwise check subject and principal alignment
-				if prf.Subject() != nil && prf.Subject().DID() != inv.Subject().DID() {
-					return verrs.NewSubjectAlignmentError(inv.Subject(), prf)
-				}
-				prev := prfs[inv.Proofs()[i-1]]
-				if issuer != prev.Audience().DID() {
-					return verrs.NewPrincipalAlignmentError(prf.Issuer(), prev)
-				}
+			wvfr, err := verifier.Wrap(vfr, issuer)
+			if err != nil {
+				verifyErr = err
+				continue
 			}
-
-			// If the issuer is a did:key we just verify a signature
-			if strings.HasPrefix(issuer.String(), "did:key:") {
-				verifier, err := parsePrincipal(issuer.String())
-				if err != nil {
-					return verrs.NewUnverifiableSignatureError(prf, err)
-				}
-				if err := VerifyDelegationSignature(prf, verifier); err != nil {
-					return err
-				}
-			} else if issuer == authority.DID() {
-				if err := VerifyDelegationSignature(prf, authority); err != nil {
-					return err
-				}
-			} else if prf.Signature().Header().SignatureAlgorithm().Code() == nonstandard.Code {
-				if err := verifyNonStandardSignature(ctx, prf, meta); err != nil {
-					return err
-				}
-			} else {
-				// Otherwise we try to resolve did:key from the DID instead
-				// and use that to verify the signature
-				ids, err := resolveDIDKey(ctx, issuer)
-				if err != nil {
-					return err
-				}
-
-				var verifyErr error
-				for _, id := range ids {
-					vfr, err := parsePrincipal(id.String())
-					if err != nil {
-						verifyErr = err
-						continue
-					}
-					wvfr, err := verifier.Wrap(vfr, issuer)
-					if err != nil {
-						verifyErr = err
-						continue
-					}
-					err = VerifyDelegationSignature(prf, wvfr)
-					if err != nil {
-						verifyErr = err
-						continue
-					}
-					break
-				}
-				if verifyErr != nil {
-					return verrs.NewUnverifiableSignatureError(prf, verifyErr)
-				}
+			err = verifyDelegationSignature(signatureCache, prf, wvfr)
+			if err != nil {
+				verifyErr = err
+				continue
 			}
+			break
 		}
-	} else {
-		// check invocation issuer/subject alignment
-		cap := delegation.NewCapability(inv.Subject(), inv.Command(), policy.Policy{})
-		if !canIssue(cap, inv.Issuer()) {
-			return verrs.NewInvalidClaimError(fmt.Sprintf("%q cannot issue invocations for %q", inv.Issuer().DID(), inv.Subject().DID()))
+		if verifyErr != nil {
+			return verrs.NewUnverifiableSignatureError(prf, verifyErr)
 		}
 	}
-
 	return nil
 }
 
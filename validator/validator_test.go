@@ -1,13 +1,17 @@
 package validator_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"os"
 	"testing"
 
 	"github.com/alanshaw/ucantone/did"
+	"github.com/alanshaw/ucantone/ipld"
 	"github.com/alanshaw/ucantone/ipld/datamodel"
+	"github.com/alanshaw/ucantone/ipld/schema"
+	"github.com/alanshaw/ucantone/principal"
 	"github.com/alanshaw/ucantone/principal/absentee"
 	"github.com/alanshaw/ucantone/principal/ed25519"
 	"github.com/alanshaw/ucantone/testutil"
@@ -15,9 +19,12 @@ import (
 	"github.com/alanshaw/ucantone/ucan/command"
 	"github.com/alanshaw/ucantone/ucan/container"
 	"github.com/alanshaw/ucantone/ucan/delegation"
+	"github.com/alanshaw/ucantone/ucan/delegation/policy"
 	"github.com/alanshaw/ucantone/ucan/invocation"
 	"github.com/alanshaw/ucantone/validator"
+	"github.com/alanshaw/ucantone/validator/bindcap"
 	"github.com/alanshaw/ucantone/validator/capability"
+	cdm "github.com/alanshaw/ucantone/validator/capability/datamodel"
 	verrs "github.com/alanshaw/ucantone/validator/errors"
 	fdm "github.com/alanshaw/ucantone/validator/internal/fixtures/datamodel"
 	"github.com/stretchr/testify/require"
@@ -199,6 +206,591 @@ func TestNonStandardSignatureVerificationViaAttestation(t *testing.T) {
 	t.Log(auth)
 }
 
+func TestPowerline(t *testing.T) {
+	account := testutil.RandomSigner(t)
+	device := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	// account -> device, linking the device to the account for all subjects
+	deviceDlg, err := BlobAdd.Delegate(account, device, nil, delegation.WithPowerline())
+	require.NoError(t, err)
+
+	// spaces created after the device was linked are delegated to the account
+	for _, space := range []ucan.Signer{testutil.RandomSigner(t), testutil.RandomSigner(t)} {
+		// space -> account
+		accountDlg, err := BlobAdd.Delegate(space, account, space)
+		require.NoError(t, err)
+
+		t.Run("device invokes "+space.DID().String(), func(t *testing.T) {
+			inv, err := BlobAdd.Invoke(
+				device,
+				space,
+				datamodel.Map{"digest": []byte(testutil.RandomDigest(t))},
+				invocation.WithAudience(service),
+				invocation.WithProofs(accountDlg.Link(), deviceDlg.Link()),
+			)
+			require.NoError(t, err)
+
+			_, err = validator.Access(
+				t.Context(),
+				service.Verifier(),
+				BlobAdd,
+				inv,
+				validator.WithProofs(accountDlg, deviceDlg),
+			)
+			require.NoError(t, err)
+		})
+
+		t.Run("device re-delegates "+space.DID().String(), func(t *testing.T) {
+			// device -> bob
+			bobDlg, err := BlobAdd.Delegate(device, bob, space)
+			require.NoError(t, err)
+
+			inv, err := BlobAdd.Invoke(
+				bob,
+				space,
+				datamodel.Map{"digest": []byte(testutil.RandomDigest(t))},
+				invocation.WithAudience(service),
+				invocation.WithProofs(accountDlg.Link(), deviceDlg.Link(), bobDlg.Link()),
+			)
+			require.NoError(t, err)
+
+			_, err = validator.Access(
+				t.Context(),
+				service.Verifier(),
+				BlobAdd,
+				inv,
+				validator.WithProofs(accountDlg, deviceDlg, bobDlg),
+			)
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("powerline root", func(t *testing.T) {
+		space := testutil.RandomSigner(t)
+		inv, err := BlobAdd.Invoke(
+			device,
+			space,
+			datamodel.Map{"digest": []byte(testutil.RandomDigest(t))},
+			invocation.WithAudience(service),
+			invocation.WithProofs(deviceDlg.Link()),
+		)
+		require.NoError(t, err)
+
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(deviceDlg),
+		)
+		require.Error(t, err)
+
+		var namedErr NamedError
+		require.True(t, errors.As(err, &namedErr))
+		require.Equal(t, verrs.InvalidClaimErrorName, namedErr.Name())
+	})
+}
+
+func TestAuthorizationValidator(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	dlg, err := BlobAdd.Delegate(space, alice, space)
+	require.NoError(t, err)
+
+	inv, err := BlobAdd.Invoke(
+		alice,
+		space,
+		datamodel.Map{"digest": []byte(testutil.RandomDigest(t))},
+		invocation.WithAudience(service),
+		invocation.WithProofs(dlg.Link()),
+	)
+	require.NoError(t, err)
+
+	t.Run("called with authorization", func(t *testing.T) {
+		var called bool
+		_, err := validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(dlg),
+			validator.WithAuthorizationValidator(func(ctx context.Context, auth validator.Authorization) error {
+				called = true
+				require.Equal(t, inv.Link(), auth.Invocation.Link())
+				require.Contains(t, auth.Proofs, dlg.Link())
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+		require.True(t, called)
+	})
+
+	t.Run("failure rejects authorization", func(t *testing.T) {
+		_, err := validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(dlg),
+			validator.WithAuthorizationValidator(func(ctx context.Context, auth validator.Authorization) error {
+				return verrs.NewInvalidClaimError("nope")
+			}),
+		)
+		require.Error(t, err)
+
+		var namedErr NamedError
+		require.True(t, errors.As(err, &namedErr))
+		require.Equal(t, verrs.InvalidClaimErrorName, namedErr.Name())
+	})
+}
+
+func TestSignatureCache(t *testing.T) {
+	service := testutil.RandomSigner(t)
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	t.Run("skips verified signatures", func(t *testing.T) {
+		invoker, space, proofs := newProofChain(t, BlobAdd, 5)
+		inv := newChainInvocation(t, BlobAdd, invoker, space, service, proofs)
+
+		var verifications int
+		parsePrincipal := func(str string) (principal.Verifier, error) {
+			vfr, err := validator.ParsePrincipal(str)
+			if err != nil {
+				return nil, err
+			}
+			return countingVerifier{vfr, &verifications}, nil
+		}
+
+		cache := validator.NewSignatureCache(100)
+		for range 2 {
+			_, err = validator.Access(
+				t.Context(),
+				service.Verifier(),
+				BlobAdd,
+				inv,
+				validator.WithProofs(proofs...),
+				validator.WithPrincipalParser(parsePrincipal),
+				validator.WithSignatureCache(cache),
+			)
+			require.NoError(t, err)
+		}
+		require.Equal(t, 5, cache.Len())
+		// 1 invocation + 5 delegations on the first call, invocation only on the
+		// second.
+		require.Equal(t, 7, verifications)
+	})
+
+	t.Run("checks time bounds of cached delegations", func(t *testing.T) {
+		now := ucan.Now()
+		space := testutil.RandomSigner(t)
+		alice := testutil.RandomSigner(t)
+		dlg, err := BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now+60))
+		require.NoError(t, err)
+		inv := newChainInvocation(t, BlobAdd, alice, space, service, []ucan.Delegation{dlg})
+
+		cache := validator.NewSignatureCache(100)
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(dlg),
+			validator.WithSignatureCache(cache),
+			validator.WithValidationTime(now),
+		)
+		require.NoError(t, err)
+		require.Equal(t, 1, cache.Len())
+
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(dlg),
+			validator.WithSignatureCache(cache),
+			validator.WithValidationTime(now+120),
+		)
+		require.Error(t, err)
+
+		var namedErr NamedError
+		require.True(t, errors.As(err, &namedErr))
+		require.Equal(t, verrs.ExpiredErrorName, namedErr.Name())
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		cache := validator.NewSignatureCache(2)
+		key := testutil.RandomDID(t)
+		a, b, c := testutil.RandomCID(t), testutil.RandomCID(t), testutil.RandomCID(t)
+
+		cache.Add(a, key)
+		cache.Add(b, key)
+		require.True(t, cache.Has(a, key)) // a is now most recently used
+		cache.Add(c, key)
+
+		require.Equal(t, 2, cache.Len())
+		require.True(t, cache.Has(a, key))
+		require.False(t, cache.Has(b, key))
+		require.True(t, cache.Has(c, key))
+		require.False(t, cache.Has(a, testutil.RandomDID(t)))
+	})
+}
+
+func TestTrace(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	bob := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(t, err)
+
+	t.Run("authorized", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		inv := newChainInvocation(t, BlobAdd, alice, space, service, []ucan.Delegation{aliceDlg})
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg),
+			validator.WithTrace(&trace),
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, trace.Error)
+		require.Equal(t, inv.Link(), trace.Invocation)
+		require.Equal(t, validator.CheckOK, trace.Signature.Status)
+		require.Equal(t, validator.CheckOK, trace.TimeBounds.Status)
+		require.Equal(t, validator.CheckOK, trace.Alignment.Status)
+		require.Len(t, trace.Proofs, 1)
+		prf := trace.Proofs[0]
+		require.Equal(t, aliceDlg.Link(), prf.Link)
+		require.Equal(t, validator.CheckOK, prf.Resolution.Status)
+		require.Equal(t, validator.CheckOK, prf.Signature.Status)
+		require.Equal(t, validator.CheckOK, prf.TimeBounds.Status)
+		require.Equal(t, validator.CheckOK, prf.Alignment.Status)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		now := ucan.Now()
+		// space -> alice (expired) -> bob
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space, delegation.WithExpiration(now-1))
+		require.NoError(t, err)
+		bobDlg, err := BlobAdd.Delegate(alice, bob, space)
+		require.NoError(t, err)
+		inv := newChainInvocation(t, BlobAdd, bob, space, service, []ucan.Delegation{aliceDlg, bobDlg})
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg, bobDlg),
+			validator.WithValidationTime(now),
+			validator.WithTrace(&trace),
+		)
+		require.Error(t, err)
+		require.Equal(t, err, trace.Error)
+
+		require.Equal(t, validator.CheckOK, trace.TimeBounds.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Signature.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Match.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Authorization.Status)
+		require.Len(t, trace.Proofs, 2)
+		require.Equal(t, validator.CheckOK, trace.Proofs[0].Resolution.Status)
+		require.Equal(t, validator.CheckFailed, trace.Proofs[0].TimeBounds.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Proofs[0].Signature.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Proofs[1].Signature.Status)
+		require.Empty(t, trace.Proofs[1].Policy)
+	})
+
+	t.Run("policy mismatch", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		pol, err := policy.Build(policy.Equal(".size", 1))
+		require.NoError(t, err)
+		bobDlg, err := BlobAdd.Delegate(alice, bob, space, delegation.WithPolicy(pol))
+		require.NoError(t, err)
+
+		inv, err := BlobAdd.Invoke(
+			bob,
+			space,
+			datamodel.Map{"size": 2},
+			invocation.WithAudience(service),
+			invocation.WithProofs(aliceDlg.Link(), bobDlg.Link()),
+		)
+		require.NoError(t, err)
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg, bobDlg),
+			validator.WithTrace(&trace),
+		)
+		require.Error(t, err)
+
+		require.Equal(t, validator.CheckOK, trace.Signature.Status)
+		require.Equal(t, validator.CheckOK, trace.Alignment.Status)
+		require.Equal(t, validator.CheckOK, trace.Proofs[1].Signature.Status)
+		require.Equal(t, validator.CheckFailed, trace.Match.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Authorization.Status)
+		require.Len(t, trace.Proofs[1].Policy, 1)
+		stmt := trace.Proofs[1].Policy[0]
+		require.Equal(t, ".size", stmt.Statement.Selector())
+		require.Equal(t, validator.CheckFailed, stmt.Result.Status)
+
+		// statements are embedded as IPLD values
+		m := trace.ToIPLD()
+		prf := m["proofs"].([]ipld.Any)[1].(ipld.Map)
+		st := prf["policy"].([]ipld.Any)[0].(ipld.Map)["statement"]
+		require.Equal(t, []any{"==", ".size", int64(1)}, st)
+	})
+
+	t.Run("nested policy mismatch", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		pol, err := policy.Build(policy.Or(
+			policy.Equal(".size", 1),
+			policy.All(".items", policy.Equal(".n", 1)),
+		))
+		require.NoError(t, err)
+		bobDlg, err := BlobAdd.Delegate(alice, bob, space, delegation.WithPolicy(pol))
+		require.NoError(t, err)
+
+		inv, err := BlobAdd.Invoke(
+			bob,
+			space,
+			datamodel.Map{
+				"size":  2,
+				"items": []any{datamodel.Map{"n": 1}, datamodel.Map{"n": 2}},
+			},
+			invocation.WithAudience(service),
+			invocation.WithProofs(aliceDlg.Link(), bobDlg.Link()),
+		)
+		require.NoError(t, err)
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg, bobDlg),
+			validator.WithTrace(&trace),
+		)
+		require.Error(t, err)
+
+		require.Len(t, trace.Proofs[1].Policy, 1)
+		or := trace.Proofs[1].Policy[0]
+		require.Equal(t, policy.OpOr, or.Statement.Operator())
+		require.Equal(t, validator.CheckFailed, or.Result.Status)
+		require.Len(t, or.Statements, 2)
+
+		eq := or.Statements[0]
+		require.Equal(t, ".size", eq.Selector)
+		require.Equal(t, validator.CheckFailed, eq.Result.Status)
+
+		all := or.Statements[1]
+		require.Equal(t, ".items", all.Selector)
+		require.Equal(t, validator.CheckFailed, all.Result.Status)
+		// only the element that did not match is recorded
+		require.Len(t, all.Statements, 1)
+		require.Equal(t, ".items[1].n", all.Statements[0].Selector)
+		require.Equal(t, validator.CheckFailed, all.Statements[0].Result.Status)
+
+		m := trace.ToIPLD()
+		prf := m["proofs"].([]ipld.Any)[1].(ipld.Map)
+		nested := prf["policy"].([]ipld.Any)[0].(ipld.Map)["statements"].([]ipld.Any)
+		require.Len(t, nested, 2)
+		require.Equal(t, ".items", nested[1].(ipld.Map)["selector"])
+	})
+
+	t.Run("unavailable proof", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		bobDlg, err := BlobAdd.Delegate(alice, bob, space)
+		require.NoError(t, err)
+		inv := newChainInvocation(t, BlobAdd, bob, space, service, []ucan.Delegation{aliceDlg, bobDlg})
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(bobDlg),
+			validator.WithTrace(&trace),
+		)
+		require.Error(t, err)
+
+		require.Len(t, trace.Proofs, 2)
+		require.Equal(t, validator.CheckFailed, trace.Proofs[0].Resolution.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Proofs[0].Signature.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Proofs[1].Resolution.Status)
+		require.Equal(t, validator.CheckSkipped, trace.Signature.Status)
+	})
+
+	t.Run("records the real validation pass", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		inv := newChainInvocation(t, BlobAdd, alice, space, service, []ucan.Delegation{aliceDlg})
+
+		resolved := 0
+		revoked := errors.New("revoked")
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofResolver(func(ctx context.Context, link ucan.Link) (ucan.Delegation, error) {
+				resolved++
+				return aliceDlg, nil
+			}),
+			validator.WithAuthorizationValidator(func(ctx context.Context, auth validator.Authorization) error {
+				return revoked
+			}),
+			validator.WithTrace(&trace),
+		)
+		require.ErrorIs(t, err, revoked)
+		// proofs are resolved once, by the validator, not again for the trace
+		require.Equal(t, 1, resolved)
+		require.Equal(t, validator.CheckOK, trace.Match.Status)
+		require.Equal(t, validator.CheckFailed, trace.Authorization.Status)
+		require.ErrorIs(t, trace.Authorization.Error, revoked)
+	})
+
+	t.Run("serializes as IPLD map", func(t *testing.T) {
+		aliceDlg, err := BlobAdd.Delegate(space, alice, space)
+		require.NoError(t, err)
+		// bob is not the audience of the delegation
+		inv := newChainInvocation(t, BlobAdd, bob, space, service, []ucan.Delegation{aliceDlg})
+
+		var trace validator.Trace
+		_, err = validator.Access(
+			t.Context(),
+			service.Verifier(),
+			BlobAdd,
+			inv,
+			validator.WithProofs(aliceDlg),
+			validator.WithTrace(&trace),
+		)
+		require.Error(t, err)
+
+		var buf bytes.Buffer
+		require.NoError(t, trace.MarshalCBOR(&buf))
+
+		var m datamodel.Map
+		require.NoError(t, m.UnmarshalCBOR(&buf))
+		require.Equal(t, false, m["ok"])
+		require.Equal(t, inv.Link(), m["invocation"])
+		alignment := m["alignment"].(map[string]any)
+		require.Equal(t, "failed", alignment["status"])
+		require.Equal(t, verrs.PrincipalAlignmentErrorName, alignment["error"].(map[string]any)["name"])
+
+		proofs := m["proofs"].([]map[string]any)
+		require.Len(t, proofs, 1)
+		require.Equal(t, aliceDlg.Link(), proofs[0]["link"])
+		require.Equal(t, alice.DID().String(), proofs[0]["audience"])
+
+		buf.Reset()
+		require.NoError(t, trace.MarshalDagJSON(&buf))
+		require.Contains(t, buf.String(), `"status":"failed"`)
+	})
+}
+
+func BenchmarkAccess(b *testing.B) {
+	service, err := ed25519.Generate()
+	require.NoError(b, err)
+	BlobAdd, err := capability.New("/blob/add")
+	require.NoError(b, err)
+
+	invoker, space, proofs := newProofChain(b, BlobAdd, 5)
+	inv := newChainInvocation(b, BlobAdd, invoker, space, service, proofs)
+
+	b.Run("no cache", func(b *testing.B) {
+		for b.Loop() {
+			_, err := validator.Access(b.Context(), service.Verifier(), BlobAdd, inv, validator.WithProofs(proofs...))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("signature cache", func(b *testing.B) {
+		cache := validator.NewSignatureCache(100)
+		for b.Loop() {
+			_, err := validator.Access(b.Context(), service.Verifier(), BlobAdd, inv, validator.WithProofs(proofs...), validator.WithSignatureCache(cache))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// newProofChain creates a chain of delegations of the passed depth from a
+// random space, returning the audience of the final delegation, the space and
+// the delegations in order.
+func newProofChain(t testing.TB, cap *capability.Capability, depth int) (ucan.Signer, ucan.Signer, []ucan.Delegation) {
+	space, err := ed25519.Generate()
+	require.NoError(t, err)
+	var issuer ucan.Signer = space
+	var proofs []ucan.Delegation
+	for range depth {
+		audience, err := ed25519.Generate()
+		require.NoError(t, err)
+		dlg, err := cap.Delegate(issuer, audience, space)
+		require.NoError(t, err)
+		proofs = append(proofs, dlg)
+		issuer = audience
+	}
+	return issuer, space, proofs
+}
+
+func newChainInvocation(t testing.TB, cap *capability.Capability, invoker ucan.Signer, space ucan.Signer, service ucan.Signer, proofs []ucan.Delegation) ucan.Invocation {
+	var links []ucan.Link
+	for _, p := range proofs {
+		links = append(links, p.Link())
+	}
+	inv, err := cap.Invoke(
+		invoker,
+		space,
+		datamodel.Map{"digest": []byte{0x01, 0x02, 0x03}},
+		invocation.WithAudience(service),
+		invocation.WithProofs(links...),
+	)
+	require.NoError(t, err)
+	return inv
+}
+
+type countingVerifier struct {
+	principal.Verifier
+	count *int
+}
+
+func (cv countingVerifier) Verify(msg []byte, sig []byte) bool {
+	*cv.count++
+	return cv.Verifier.Verify(msg, sig)
+}
+
 func newMapProofResolver(proofs map[ucan.Link]ucan.Delegation) validator.ProofResolverFunc {
 	return func(_ context.Context, link ucan.Link) (ucan.Delegation, error) {
 		dlg, ok := proofs[link]
@@ -219,3 +811,83 @@ func decodeProofs(t *testing.T, vectorProofs [][]byte) map[ucan.Link]ucan.Delega
 	}
 	return proofs
 }
+
+func TestArgumentSchema(t *testing.T) {
+	space := testutil.RandomSigner(t)
+	alice := testutil.RandomSigner(t)
+	service := testutil.RandomSigner(t)
+
+	BlobAdd, err := capability.New(
+		"/blob/add",
+		capability.WithSchema(schema.Struct(
+			schema.Field("digest", schema.Bytes()),
+			schema.Field("size", schema.Int()),
+		)),
+		capability.WithPolicyBuilder(policy.GreaterThan(".size", 0)),
+	)
+	require.NoError(t, err)
+
+	dlg, err := BlobAdd.Delegate(space, alice, space)
+	require.NoError(t, err)
+
+	access := func(t *testing.T, args datamodel.Map) error {
+		inv, err := BlobAdd.Invoke(
+			alice,
+			space,
+			args,
+			invocation.WithAudience(service),
+			invocation.WithProofs(dlg.Link()),
+		)
+		require.NoError(t, err)
+		_, err = validator.Access(t.Context(), service.Verifier(), BlobAdd, inv, validator.WithProofs(dlg))
+		return err
+	}
+
+	t.Run("valid arguments", func(t *testing.T) {
+		err := access(t, datamodel.Map{"digest": []byte(testutil.RandomDigest(t)), "size": int64(138)})
+		require.NoError(t, err)
+	})
+
+	t.Run("malformed arguments", func(t *testing.T) {
+		// the policy would also fail, but the schema is checked first
+		err := access(t, datamodel.Map{"digest": []byte(testutil.RandomDigest(t)), "size": "big"})
+		require.Error(t, err)
+
+		var malformedErr *cdm.MalformedArgumentsErrorModel
+		require.True(t, errors.As(err, &malformedErr))
+		require.Equal(t, capability.MalformedArgumentsErrorName, malformedErr.Name())
+		require.Equal(t, ".size", malformedErr.Path)
+	})
+
+	t.Run("missing arguments", func(t *testing.T) {
+		err := access(t, datamodel.Map{"size": int64(138)})
+
+		var malformedErr *cdm.MalformedArgumentsErrorModel
+		require.True(t, errors.As(err, &malformedErr))
+		require.Equal(t, capability.MalformedArgumentsErrorName, malformedErr.Name())
+		require.Equal(t, ".digest", malformedErr.Path)
+	})
+
+	t.Run("bound arguments", func(t *testing.T) {
+		type blobAddArguments struct {
+			Digest []byte `ipld:"digest"`
+			Size   int64  `ipld:"size"`
+		}
+		BoundBlobAdd, err := bindcap.New[*datamodel.Typed[blobAddArguments]]("/blob/add")
+		require.NoError(t, err)
+
+		inv, err := BoundBlobAdd.Invoke(alice, space, &datamodel.Typed[blobAddArguments]{Value: blobAddArguments{Digest: []byte(testutil.RandomDigest(t))}})
+		require.NoError(t, err)
+		_, err = BoundBlobAdd.Match(inv, nil)
+		require.NoError(t, err)
+
+		inv, err = invocation.Invoke(alice, space, "/blob/add", datamodel.Map{"digest": []byte(testutil.RandomDigest(t)), "size": "big"})
+		require.NoError(t, err)
+		_, err = BoundBlobAdd.Match(inv, nil)
+
+		var malformedErr *cdm.MalformedArgumentsErrorModel
+		require.True(t, errors.As(err, &malformedErr))
+		require.Equal(t, capability.MalformedArgumentsErrorName, malformedErr.Name())
+		require.Equal(t, ".size", malformedErr.Path)
+	})
+}